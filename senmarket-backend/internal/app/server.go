@@ -15,7 +15,7 @@ import (
 	"senmarket/internal/config"
 	"senmarket/internal/handlers"
 	"senmarket/internal/middleware"
-	// "senmarket/internal/models"
+	"senmarket/internal/models"
 	"senmarket/internal/repository/redis"
 	"senmarket/internal/services"
 
@@ -45,6 +45,7 @@ type Application struct {
 	
 	// 🆕 SERVICES POUR LA MONÉTISATION
 	quotaService      *services.QuotaService
+	paymentReconciler *services.PaymentReconciler
 	
 	authMiddleware    *auth.Middleware
 	cacheMiddleware   *middleware.CacheMiddleware
@@ -59,6 +60,9 @@ type Application struct {
 	
 	// 🆕 HANDLERS POUR LA MONÉTISATION
 	quotaHandler      *handlers.QuotaHandler
+	reconciliationHandler *handlers.ReconciliationHandler
+	walletHandler         *handlers.WalletHandler
+	savedSearchHandler    *handlers.SavedSearchHandler
 }
 
 func New(cfg *config.Config) *Application {
@@ -141,8 +145,22 @@ func New(cfg *config.Config) *Application {
 	}
 
 	// Initialiser les services core
+	// WhatsApp : codes de connexion OTP et alertes des recherches sauvegardées
+	whatsAppService := services.NewWhatsAppService(
+		db,
+		cfg.WhatsApp.AccountSID,
+		cfg.WhatsApp.AuthToken,
+		cfg.WhatsApp.APIURL,
+		cfg.WhatsApp.BusinessNumber,
+		cfg.WhatsApp.Environment,
+		cfg.WhatsApp.Provider,
+	)
+
 	jwtService := auth.NewJWTService(cfg.JWT.Secret, cfg.JWT.Expiry)
-	authService := auth.NewService(db, jwtService, twilioSMSService)
+	authService := auth.NewService(db, jwtService, twilioSMSService, whatsAppService, redisRepo, cfg.JWT.RefreshExpiry)
+	if err := authService.PromoteAdmins(cfg.Auth.AdminPhones); err != nil {
+		log.Printf("⚠️ %v", err)
+	}
 	
 	// 🆕 SERVICE QUOTA - IMPORTANT: Créer avant ListingService
 	quotaService := services.NewQuotaService(db)
@@ -153,11 +171,59 @@ func New(cfg *config.Config) *Application {
 	contactService := services.NewContactService(db)
 	
 	// Services sans cache (pour l'instant)
-	paymentService := services.NewPaymentService(
+	waveClient := services.NewWaveClient(
+		os.Getenv("WAVE_API_URL"),
+		os.Getenv("WAVE_API_KEY"),
+		os.Getenv("WAVE_WEBHOOK_SECRET"),
+		cfg.Env,
+	)
+	if cfg.Env != "development" {
+		for _, name := range []string{"WAVE_WEBHOOK_SECRET", "ORANGE_MONEY_MERCHANT_SECRET"} {
+			if os.Getenv(name) == "" {
+				log.Printf("⚠️  %s manquant : les webhooks correspondants seront rejetés", name)
+			}
+		}
+		if !waveClient.IsConfigured() {
+			log.Printf("⚠️  WAVE_API_KEY manquant : les paiements Wave seront refusés")
+		}
+	}
+	paymentProviders := services.NewPaymentProviderRegistry(
+		services.NewOrangeMoneyProvider(
+			os.Getenv("ORANGE_MONEY_API_URL"),
+			os.Getenv("ORANGE_MONEY_MERCHANT_KEY"),
+			os.Getenv("ORANGE_MONEY_MERCHANT_SECRET"),
+			cfg.Env,
+		),
+		services.NewWaveProvider(waveClient),
+		// Free Money : pas d'API marchand, provider non proposé tant qu'il n'est pas implémenté
+	)
+	if cfg.Payment.MockEnabled {
+		log.Printf("🧪 Provider de paiement mock actif (PAYMENT_MOCK_ENABLED)")
+		paymentProviders.Register(services.NewMockPaymentProvider())
+	}
+	walletService := services.NewWalletService(db)
+	paymentService := services.NewPaymentService(db, paymentProviders, redisRepo, listingService, quotaService, walletService)
+	paymentReconciler := services.NewPaymentReconciler(
 		db,
-		os.Getenv("ORANGE_MONEY_API_URL"),
-		os.Getenv("ORANGE_MONEY_MERCHANT_KEY"),
-		os.Getenv("ORANGE_MONEY_MERCHANT_SECRET"),
+		paymentService,
+		cfg.Payment.ReconcileInterval,
+		cfg.Payment.ReconcileAfter,
+		cfg.Payment.PendingExpiry,
+	)
+	listingBoostScheduler := services.NewListingBoostScheduler(listingService, cfg.Listing.BoostExpiryInterval)
+	listingExpiryScheduler := services.NewListingExpiryScheduler(
+		listingService,
+		twilioSMSService,
+		cfg.Listing.ExpiryCheckInterval,
+		cfg.Listing.ExpiryWarningDays,
+	)
+
+	// Alertes des recherches sauvegardées (SMS / WhatsApp)
+	savedSearchService := services.NewSavedSearchService(db, listingService, twilioSMSService, whatsAppService)
+	savedSearchAlerter := services.NewSavedSearchAlerter(
+		savedSearchService,
+		cfg.Listing.SavedSearchAlertInterval,
+		cfg.Listing.SavedSearchDigestHour,
 	)
 
 	// ⭐ NOUVEAU: ImageService avec MinIO
@@ -175,7 +241,7 @@ func New(cfg *config.Config) *Application {
 	authHandler := handlers.NewAuthHandler(authService)
 	listingHandler := handlers.NewListingHandler(listingService)
 	categoryHandler := handlers.NewCategoryHandler(categoryService)
-	paymentHandler := handlers.NewPaymentHandler(paymentService)
+	paymentHandler := handlers.NewPaymentHandler(paymentService, listingService)
 	imageHandler := handlers.NewImageHandler(imageService)
 	contactHandler := handlers.NewContactHandler(contactService)
 	cacheHandler := handlers.NewCacheHandler(cacheService)
@@ -183,6 +249,9 @@ func New(cfg *config.Config) *Application {
 
 	// 🆕 HANDLER QUOTA
 	quotaHandler := handlers.NewQuotaHandler(quotaService)
+	reconciliationHandler := handlers.NewReconciliationHandler(paymentReconciler)
+	walletHandler := handlers.NewWalletHandler(walletService)
+	savedSearchHandler := handlers.NewSavedSearchHandler(savedSearchService)
 
 	// ============================================
 	// MIGRATIONS DÉSACTIVÉES
@@ -244,6 +313,7 @@ func New(cfg *config.Config) *Application {
 		
 		// 🆕 SERVICE
 		quotaService:      quotaService,
+		paymentReconciler: paymentReconciler,
 		
 		authMiddleware:    authMiddleware,
 		cacheMiddleware:   cacheMiddleware,
@@ -258,7 +328,17 @@ func New(cfg *config.Config) *Application {
 		
 		// 🆕 HANDLER
 		quotaHandler:      quotaHandler,
+		reconciliationHandler: reconciliationHandler,
+		walletHandler:         walletHandler,
+		savedSearchHandler:    savedSearchHandler,
+	}
+
+	// IP client : X-Forwarded-For n'est cru que des proxys configurés, sinon n'importe quel client
+	// pourrait changer d'IP à chaque requête et échapper aux limites et verrouillages par IP
+	if err := app.router.SetTrustedProxies(cfg.Auth.TrustedProxies); err != nil {
+		log.Fatalf("Erreur configuration TRUSTED_PROXIES: %v", err)
 	}
+	app.router.TrustedPlatform = cfg.Auth.TrustedPlatform
 
 	// Configurer les middlewares et routes
 	app.setupMiddleware()
@@ -267,6 +347,12 @@ func New(cfg *config.Config) *Application {
 	// Préchauffer le cache au démarrage
 	go app.warmupCache()
 
+	// Jobs de fond
+	go paymentReconciler.Start(context.Background())
+	go listingBoostScheduler.Start(context.Background())
+	go listingExpiryScheduler.Start(context.Background())
+	go savedSearchAlerter.Start(context.Background())
+
 	return app
 }
 
@@ -406,8 +492,8 @@ func (a *Application) setupRoutes() {
 			})
 		})
 
-		// ⭐ NOUVEAU: Endpoint MinIO status détaillé
-		api.GET("/storage/status", func(c *gin.Context) {
+		// ⭐ NOUVEAU: Endpoint MinIO status détaillé (administrateurs)
+		api.GET("/storage/status", a.authMiddleware.RequireRole(models.RoleAdmin), func(c *gin.Context) {
 			ctx := context.Background()
 			
 			buckets, err := a.minioClient.ListBuckets(ctx)
@@ -460,8 +546,8 @@ func (a *Application) setupRoutes() {
 			})
 		})
 
-		// Endpoint Twilio SMS status
-		api.GET("/sms/status", func(c *gin.Context) {
+		// Endpoint Twilio SMS status (administrateurs)
+		api.GET("/sms/status", a.authMiddleware.RequireRole(models.RoleAdmin), func(c *gin.Context) {
 			info, _ := a.twilioSMSService.GetAccountInfo()
 			stats := a.twilioSMSService.GetUsageStats()
 			
@@ -475,6 +561,7 @@ func (a *Application) setupRoutes() {
 		// ENDPOINTS CATEGORIES (SANS MIDDLEWARE POUR L'INSTANT)
 		api.GET("/categories", a.categoryHandler.GetCategories)
 		api.GET("/categories/stats", a.categoryHandler.GetCategoriesWithStats)
+		api.GET("/categories/tree", a.categoryHandler.GetCategoryTree)
 
 		// Rate limiting spécifique pour auth
 		auth := api.Group("/auth")
@@ -484,6 +571,11 @@ func (a *Application) setupRoutes() {
 			auth.POST("/login", a.authHandler.Login)
 			auth.POST("/verify", a.authHandler.VerifyPhone)
 			auth.POST("/send-code", a.authHandler.SendVerificationCode)
+			auth.POST("/refresh", a.authHandler.Refresh)
+			auth.POST("/password/forgot", a.authHandler.ForgotPassword)
+			auth.POST("/password/reset", a.authHandler.ResetPassword)
+			auth.POST("/otp/request", a.authHandler.RequestLoginCode)
+			auth.POST("/otp/verify", a.authHandler.VerifyLoginCode)
 		}
 
 		// Routes protégées (authentification requise)
@@ -492,15 +584,28 @@ func (a *Application) setupRoutes() {
 		{
 			protected.GET("/auth/profile", a.authHandler.Profile)
 			protected.PUT("/auth/profile", a.authHandler.UpdateProfile)
+			protected.POST("/auth/logout", a.authHandler.Logout)
+			protected.POST("/auth/logout-all", a.authHandler.LogoutAll)
+			protected.GET("/auth/sessions", a.authHandler.GetSessions)
+			protected.DELETE("/auth/sessions/:id", a.authHandler.RevokeSession)
 		}
 
 		// Routes catégories (publiques avec cache)
 		categories := api.Group("/categories")
 		{
 			categories.GET("/:id", a.categoryHandler.GetCategory)
-			categories.GET("/slug/:slug", a.categoryHandler.GetCategoryBySlug)
+			categories.GET("/slug/*path", a.categoryHandler.GetCategoryBySlug)
 			categories.GET("/:id/listings", a.categoryHandler.GetListingsByCategory)
 			categories.GET("/:id/stats", a.categoryHandler.GetCategoryStats)
+			categories.GET("/:id/attributes", a.categoryHandler.GetAttributeSchema)
+			categories.GET("/:id/attributes/versions", a.categoryHandler.GetAttributeSchemaVersions)
+		}
+
+		// Schémas d'attributs (administrateurs)
+		categoriesAdmin := api.Group("/categories")
+		categoriesAdmin.Use(a.authMiddleware.RequireRole(models.RoleAdmin))
+		{
+			categoriesAdmin.PUT("/:id/attributes", a.categoryHandler.PublishAttributeSchema)
 		}
 
 		// 🆕 ROUTES QUOTA (NOUVELLES)
@@ -509,6 +614,7 @@ func (a *Application) setupRoutes() {
 			// Routes publiques pour info générale
 			quota.GET("/current-phase", a.quotaHandler.GetCurrentPhase)
 			quota.GET("/pricing", a.quotaHandler.GetPricingInfo)
+			quota.GET("/packs", a.quotaHandler.GetListingPacks)
 		}
 
 		// Routes quota protégées (authentification requise)
@@ -519,9 +625,16 @@ func (a *Application) setupRoutes() {
 			quotaProtected.GET("/check", a.quotaHandler.CheckEligibility)
 			quotaProtected.GET("/summary", a.quotaHandler.GetQuotaSummary)
 			quotaProtected.GET("/history", a.quotaHandler.GetQuotaHistory)
-			quotaProtected.GET("/platform-stats", a.quotaHandler.GetPlatformStats)
-			quotaProtected.POST("/update-phase", a.quotaHandler.UpdateUserPhase)
-			quotaProtected.POST("/cleanup", a.quotaHandler.CleanupQuotas)
+			quotaProtected.GET("/credits", a.quotaHandler.GetListingCredits)
+		}
+
+		// Routes quota d'administration
+		quotaAdmin := api.Group("/quota")
+		quotaAdmin.Use(a.authMiddleware.RequireRole(models.RoleAdmin))
+		{
+			quotaAdmin.GET("/platform-stats", a.quotaHandler.GetPlatformStats)
+			quotaAdmin.POST("/update-phase", a.quotaHandler.UpdateUserPhase)
+			quotaAdmin.POST("/cleanup", a.quotaHandler.CleanupQuotas)
 		}
 
 		// Routes annonces (publiques avec auth optionnelle)
@@ -530,6 +643,8 @@ func (a *Application) setupRoutes() {
 		{
 			listings.GET("", a.listingHandler.GetListings)           // Cache géré dans le service
 			listings.GET("/search", a.listingHandler.SearchListings) // Cache géré dans le service
+			listings.GET("/suggest", a.listingHandler.SuggestListings)
+			listings.GET("/sales/stats", a.listingHandler.GetSalesStats)
 			listings.GET("/:id", a.listingHandler.GetListing)        // Cache géré dans le service
 		}
 
@@ -555,6 +670,25 @@ func (a *Application) setupRoutes() {
 			listingsProtected.POST("/:id/publish", a.listingHandler.PublishListing)       // 🆕 Nouveau
 			listingsProtected.GET("/my", a.listingHandler.GetMyListings)                  // 🆕 Modifié avec quotas
 			listingsProtected.POST("/:id/pay", a.paymentHandler.PayForListing)
+			listingsProtected.POST("/:id/boost", a.paymentHandler.BoostListing)
+			listingsProtected.POST("/:id/renew", a.paymentHandler.RenewListing)
+			listingsProtected.POST("/:id/sold", a.listingHandler.MarkListingSold)
+		}
+
+		// Recherches sauvegardées et alertes
+		savedSearches := api.Group("/saved-searches")
+		{
+			savedSearches.GET("/unsubscribe/:token", a.savedSearchHandler.ConfirmUnsubscribe) // Lien des alertes : confirmation sans effet
+			savedSearches.POST("/unsubscribe/:token", a.savedSearchHandler.Unsubscribe)       // Désabonnement, sans authentification
+		}
+		savedSearchesProtected := api.Group("/saved-searches")
+		savedSearchesProtected.Use(a.authMiddleware.RequireAuth())
+		{
+			savedSearchesProtected.GET("", a.savedSearchHandler.GetSavedSearches)
+			savedSearchesProtected.POST("", a.savedSearchHandler.CreateSavedSearch)
+			savedSearchesProtected.PUT("/:id", a.savedSearchHandler.UpdateSavedSearch)
+			savedSearchesProtected.DELETE("/:id", a.savedSearchHandler.DeleteSavedSearch)
+			savedSearchesProtected.GET("/:id/listings", a.savedSearchHandler.GetSavedSearchListings)
 		}
 
 		// Routes contact
@@ -577,17 +711,39 @@ func (a *Application) setupRoutes() {
 		{
 			payments.POST("/webhook/orange-money", a.paymentHandler.OrangeMoneyWebhook)
 			payments.POST("/webhook/wave", a.paymentHandler.WaveWebhook)
-			payments.POST("/webhook/free-money", a.paymentHandler.FreeMoneyWebhook)
+			if a.config.Payment.MockEnabled {
+				payments.POST("/webhook/mock", a.paymentHandler.MockWebhook)
+			}
 		}
 
 		paymentsProtected := api.Group("/payments")
 		paymentsProtected.Use(a.authMiddleware.RequireVerifiedUser())
 		{
 			paymentsProtected.POST("/initiate", a.paymentHandler.InitiatePayment)
+			paymentsProtected.POST("/packs", a.paymentHandler.PurchaseListingPack)
 			paymentsProtected.GET("/:id", a.paymentHandler.GetPayment)
 			paymentsProtected.GET("/my", a.paymentHandler.GetMyPayments)
 		}
 
+		// Portefeuille prépayé
+		wallet := api.Group("/wallet")
+		wallet.Use(a.authMiddleware.RequireVerifiedUser())
+		{
+			wallet.GET("", a.walletHandler.GetWallet)
+			wallet.GET("/transactions", a.walletHandler.GetTransactions)
+			wallet.GET("/statement", a.walletHandler.ExportStatement)
+			wallet.POST("/topup", a.paymentHandler.TopUpWallet)
+		}
+
+		// Réconciliation et remboursements (administrateurs)
+		paymentsAdmin := api.Group("/payments")
+		paymentsAdmin.Use(a.authMiddleware.RequireRole(models.RoleAdmin))
+		{
+			paymentsAdmin.GET("/reconciliation/reports", a.reconciliationHandler.GetReports)
+			paymentsAdmin.POST("/reconciliation/run", a.reconciliationHandler.RunReconciliation)
+			paymentsAdmin.POST("/:id/refund", a.paymentHandler.RefundPayment)
+		}
+
 		// Routes images avec MinIO
 		images := api.Group("/images")
 		{
@@ -603,31 +759,42 @@ func (a *Application) setupRoutes() {
 			imagesProtected.GET("/info", a.imageHandler.GetImageInfo)
 			// ⭐ NOUVEAUX endpoints MinIO
 			imagesProtected.GET("/signed-url", a.imageHandler.GetSignedURL)
-			imagesProtected.GET("/list", a.imageHandler.ListImages)
-			imagesProtected.DELETE("/delete-prefix", a.imageHandler.DeleteByPrefix)
+			imagesProtected.GET("/list", a.imageHandler.ListImages) // Préfixe libre réservé aux administrateurs
+		}
+
+		// Suppression par préfixe (administrateurs)
+		imagesAdmin := api.Group("/images")
+		imagesAdmin.Use(a.authMiddleware.RequireRole(models.RoleAdmin))
+		{
+			imagesAdmin.DELETE("/delete-prefix", a.imageHandler.DeleteByPrefix)
 		}
 
 		// Cache management (admin endpoints)
-		if a.config.Env != "production" {
-			cache := api.Group("/cache")
-			{
-				cache.GET("/stats", a.cacheHandler.GetCacheStats)
-				cache.DELETE("/clear", a.cacheHandler.ClearCache)
-				cache.POST("/warmup", a.cacheHandler.WarmupCache)
-			}
+		cache := api.Group("/cache")
+		cache.Use(a.authMiddleware.RequireRole(models.RoleAdmin))
+		{
+			cache.GET("/stats", a.cacheHandler.GetCacheStats)
+			cache.DELETE("/clear", a.cacheHandler.ClearCache)
+			cache.POST("/warmup", a.cacheHandler.WarmupCache)
 		}
 
-		// Monitoring endpoints
-		if a.config.Env != "production" {
-			monitoring := api.Group("/monitoring")
-			{
-				monitoring.GET("/redis", a.monitoringHandler.GetRedisMetrics)
-				monitoring.GET("/cache/hit-ratio", a.monitoringHandler.GetCacheHitRatio)
-				monitoring.GET("/cache/top-keys", a.monitoringHandler.GetTopKeys)
-				monitoring.GET("/memory", a.monitoringHandler.GetMemoryUsage)
-				// ⭐ NOUVEAU: Monitoring MinIO
-				monitoring.GET("/minio", a.getMinIOMetrics)
-			}
+		// Monitoring endpoints (administrateurs)
+		monitoring := api.Group("/monitoring")
+		monitoring.Use(a.authMiddleware.RequireRole(models.RoleAdmin))
+		{
+			monitoring.GET("/redis", a.monitoringHandler.GetRedisMetrics)
+			monitoring.GET("/cache/hit-ratio", a.monitoringHandler.GetCacheHitRatio)
+			monitoring.GET("/cache/top-keys", a.monitoringHandler.GetTopKeys)
+			monitoring.GET("/memory", a.monitoringHandler.GetMemoryUsage)
+			// ⭐ NOUVEAU: Monitoring MinIO
+			monitoring.GET("/minio", a.getMinIOMetrics)
+		}
+
+		// Administration des comptes
+		admin := api.Group("/admin")
+		admin.Use(a.authMiddleware.RequireRole(models.RoleAdmin))
+		{
+			admin.PUT("/users/:id/role", a.authHandler.UpdateUserRole)
 		}
 	}
 
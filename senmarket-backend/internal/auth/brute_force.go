@@ -0,0 +1,238 @@
+// internal/auth/brute_force.go
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"senmarket/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrTemporarilyLocked = errors.New("trop de tentatives échouées")
+
+// LockoutError verrouillage temporaire ; RetryAfter alimente l'en-tête Retry-After
+type LockoutError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockoutError) Error() string {
+	return fmt.Sprintf("%s, réessayez dans %s", ErrTemporarilyLocked, e.RetryAfter.Round(time.Second))
+}
+
+func (e *LockoutError) Unwrap() error {
+	return ErrTemporarilyLocked
+}
+
+const (
+	lockoutScopePhone = "phone"
+	lockoutScopeIP    = "ip"
+
+	bruteForcePrefix = "auth:bruteforce:"
+
+	// Les opérateurs mobiles partagent une même IP publique entre de nombreux abonnés (CGNAT) :
+	// par IP, le verrouillage n'est qu'un ralentissement court sur une fenêtre courte, jamais un blocage durable
+	ipLockoutWindow = time.Hour
+	ipLockoutMax    = 15 * time.Minute
+)
+
+// lockoutStep à partir de Failures échecs, verrouillage pendant Duration
+type lockoutStep struct {
+	Failures int64
+	Duration time.Duration
+}
+
+// lockoutPolicy paliers de verrouillage progressifs d'une action, par téléphone et par IP
+type lockoutPolicy struct {
+	action     string
+	window     time.Duration // Fenêtre glissante du compteur d'échecs par téléphone
+	phoneSteps []lockoutStep
+	ipSteps    []lockoutStep // Fenêtre ipLockoutWindow, durées plafonnées à ipLockoutMax
+}
+
+var (
+	// Connexion par mot de passe
+	loginLockout = lockoutPolicy{
+		action: "login",
+		window: 24 * time.Hour,
+		phoneSteps: []lockoutStep{
+			{Failures: 5, Duration: time.Minute},
+			{Failures: 10, Duration: 15 * time.Minute},
+			{Failures: 15, Duration: time.Hour},
+			{Failures: 20, Duration: 24 * time.Hour},
+		},
+		ipSteps: []lockoutStep{
+			{Failures: 50, Duration: time.Minute},
+			{Failures: 100, Duration: 5 * time.Minute},
+			{Failures: 200, Duration: ipLockoutMax},
+		},
+	}
+
+	// Codes reçus par SMS / WhatsApp (vérification, réinitialisation, connexion OTP)
+	codeLockout = lockoutPolicy{
+		action: "code",
+		window: 24 * time.Hour,
+		phoneSteps: []lockoutStep{
+			{Failures: 10, Duration: 15 * time.Minute},
+			{Failures: 20, Duration: time.Hour},
+			{Failures: 30, Duration: 24 * time.Hour},
+		},
+		ipSteps: []lockoutStep{
+			{Failures: 100, Duration: time.Minute},
+			{Failures: 200, Duration: 5 * time.Minute},
+			{Failures: 400, Duration: ipLockoutMax},
+		},
+	}
+)
+
+// lockoutDuration durée du palier atteint (0 en dessous du premier palier)
+func lockoutDuration(steps []lockoutStep, failures int64) time.Duration {
+	var duration time.Duration
+	for _, step := range steps {
+		if failures >= step.Failures {
+			duration = step.Duration
+		}
+	}
+	return duration
+}
+
+// ensureNotLocked refuse la tentative si le téléphone ou l'IP est verrouillé (sans Redis : pas de verrouillage)
+func (s *Service) ensureNotLocked(policy *lockoutPolicy, phone, ip string) error {
+	if s.cache == nil {
+		return nil
+	}
+
+	ctx := context.Background()
+	var remaining time.Duration
+	for _, key := range []string{
+		policy.lockKey(lockoutScopePhone, phone),
+		policy.lockKey(lockoutScopeIP, ip),
+	} {
+		ttl, err := s.cache.TTL(ctx, key)
+		if err != nil {
+			log.Printf("⚠️ Verrouillage indisponible (%s): %v", key, err)
+			continue
+		}
+		if ttl > remaining {
+			remaining = ttl
+		}
+	}
+
+	if remaining > 0 {
+		return &LockoutError{RetryAfter: remaining}
+	}
+	return nil
+}
+
+// recordFailure comptabilise un échec et verrouille au palier atteint
+func (s *Service) recordFailure(policy *lockoutPolicy, phone, ip string) {
+	if s.cache == nil {
+		return
+	}
+	s.countFailure(policy, lockoutScopePhone, phone, policy.phoneSteps, policy.window, phone, ip)
+	s.countFailure(policy, lockoutScopeIP, ip, policy.ipSteps, ipLockoutWindow, phone, ip)
+}
+
+func (s *Service) countFailure(policy *lockoutPolicy, scope, value string, steps []lockoutStep, window time.Duration, phone, ip string) {
+	if value == "" {
+		return
+	}
+
+	ctx := context.Background()
+	failures, err := s.cache.IncrEx(ctx, policy.failKey(scope, value), window)
+	if err != nil {
+		log.Printf("⚠️ Compteur d'échecs indisponible (%s %s): %v", policy.action, scope, err)
+		return
+	}
+
+	duration := lockoutDuration(steps, failures)
+	if duration == 0 {
+		return
+	}
+	if err := s.cache.Set(ctx, policy.lockKey(scope, value), failures, duration); err != nil {
+		log.Printf("⚠️ Erreur verrouillage (%s %s): %v", policy.action, scope, err)
+		return
+	}
+
+	lockedUntil := time.Now().Add(duration)
+	log.Printf("🔒 Verrouillage %s par %s %s pendant %s (%d échecs)", policy.action, scope, value, duration, failures)
+	s.recordSecurityEvent(&models.AuthSecurityEvent{
+		EventType:   models.AuthEventLockout,
+		Action:      policy.action,
+		Scope:       scope,
+		Phone:       phone,
+		IPAddress:   ip,
+		Failures:    failures,
+		LockedUntil: &lockedUntil,
+	})
+}
+
+// resetFailures remet à zéro le compteur du téléphone après un succès (celui de l'IP est conservé)
+func (s *Service) resetFailures(policy *lockoutPolicy, phone string) {
+	if s.cache == nil {
+		return
+	}
+	if err := s.cache.Del(context.Background(), policy.failKey(lockoutScopePhone, phone)); err != nil {
+		log.Printf("⚠️ Erreur remise à zéro des échecs (%s): %v", policy.action, err)
+	}
+}
+
+// codeFailed compte une saisie erronée : sur le code actif (brûlé au-delà de
+// models.MaxVerificationAttempts) et dans les compteurs de verrouillage
+func (s *Service) codeFailed(phone, purpose, ip string) {
+	s.recordFailure(&codeLockout, phone, ip)
+
+	var verification models.SMSVerification
+	if err := s.db.Where("phone = ? AND purpose = ? AND verified = ? AND expires_at > ?",
+		phone, purpose, false, time.Now()).
+		Order("created_at DESC").
+		First(&verification).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("⚠️ Erreur recherche code actif: %v", err)
+		}
+		return
+	}
+
+	// Incrément et invalidation atomiques (tentatives concurrentes)
+	if err := s.db.Model(&verification).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "attempts"}, {Name: "verified"}}}).
+		Where("verified = ?", false).
+		Updates(map[string]interface{}{
+			"attempts": gorm.Expr("attempts + 1"),
+			"verified": gorm.Expr("attempts + 1 >= ?", models.MaxVerificationAttempts),
+		}).Error; err != nil {
+		log.Printf("⚠️ Erreur comptage tentatives du code: %v", err)
+		return
+	}
+
+	if verification.Verified {
+		log.Printf("🔥 Code %s invalidé pour %s après %d tentatives", purpose, phone, verification.Attempts)
+		s.recordSecurityEvent(&models.AuthSecurityEvent{
+			EventType: models.AuthEventCodeBurned,
+			Action:    codeLockout.action,
+			Phone:     phone,
+			IPAddress: ip,
+			Failures:  int64(verification.Attempts),
+		})
+	}
+}
+
+// recordSecurityEvent conserve un événement de sécurité pour audit
+func (s *Service) recordSecurityEvent(event *models.AuthSecurityEvent) {
+	if err := s.db.Create(event).Error; err != nil {
+		log.Printf("⚠️ Erreur enregistrement événement de sécurité: %v", err)
+	}
+}
+
+func (p *lockoutPolicy) failKey(scope, value string) string {
+	return bruteForcePrefix + "fail:" + p.action + ":" + scope + ":" + value
+}
+
+func (p *lockoutPolicy) lockKey(scope, value string) string {
+	return bruteForcePrefix + "lock:" + p.action + ":" + scope + ":" + value
+}
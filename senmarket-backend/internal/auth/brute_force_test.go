@@ -0,0 +1,78 @@
+// internal/auth/brute_force_test.go
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLockoutDuration(t *testing.T) {
+	steps := []lockoutStep{
+		{Failures: 5, Duration: time.Minute},
+		{Failures: 10, Duration: 15 * time.Minute},
+		{Failures: 20, Duration: time.Hour},
+	}
+
+	tests := []struct {
+		failures int64
+		want     time.Duration
+	}{
+		{0, 0},
+		{4, 0},
+		{5, time.Minute},
+		{9, time.Minute},
+		{10, 15 * time.Minute},
+		{19, 15 * time.Minute},
+		{20, time.Hour},
+		{500, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := lockoutDuration(steps, tt.failures); got != tt.want {
+			t.Errorf("%d échecs: verrouillage %s, attendu %s", tt.failures, got, tt.want)
+		}
+	}
+
+	if got := lockoutDuration(nil, 1000); got != 0 {
+		t.Errorf("sans palier: verrouillage %s, attendu 0", got)
+	}
+}
+
+func TestLockoutPoliciesAreProgressive(t *testing.T) {
+	for _, policy := range []lockoutPolicy{loginLockout, codeLockout} {
+		for scope, steps := range map[string][]lockoutStep{
+			lockoutScopePhone: policy.phoneSteps,
+			lockoutScopeIP:    policy.ipSteps,
+		} {
+			for i := 1; i < len(steps); i++ {
+				if steps[i].Failures <= steps[i-1].Failures || steps[i].Duration <= steps[i-1].Duration {
+					t.Errorf("%s/%s: palier %d non progressif", policy.action, scope, i)
+				}
+			}
+		}
+	}
+}
+
+func TestIPLockoutsAreShortThrottles(t *testing.T) {
+	for _, policy := range []lockoutPolicy{loginLockout, codeLockout} {
+		if len(policy.ipSteps) == 0 {
+			t.Errorf("%s: aucun ralentissement par IP", policy.action)
+			continue
+		}
+		// Une IP d'opérateur (CGNAT) regroupe de nombreux abonnés : jamais de blocage durable
+		if got := lockoutDuration(policy.ipSteps, 1<<30); got > ipLockoutMax {
+			t.Errorf("%s: verrouillage IP de %s, maximum %s", policy.action, got, ipLockoutMax)
+		}
+		// Le premier palier IP doit laisser passer bien plus d'échecs que celui d'un téléphone
+		if policy.ipSteps[0].Failures < 5*policy.phoneSteps[0].Failures {
+			t.Errorf("%s: premier palier IP à %d échecs, trop bas", policy.action, policy.ipSteps[0].Failures)
+		}
+	}
+}
+
+func TestLockoutError(t *testing.T) {
+	err := &LockoutError{RetryAfter: 90 * time.Second}
+	if !errors.Is(err, ErrTemporarilyLocked) {
+		t.Fatal("LockoutError doit envelopper ErrTemporarilyLocked")
+	}
+}
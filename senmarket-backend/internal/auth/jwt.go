@@ -19,7 +19,9 @@ type JWTService struct {
 }
 
 type Claims struct {
-	UserID string `json:"user_id"`
+	UserID    string `json:"user_id"`
+	Role      string `json:"role"`
+	SessionID string `json:"sid,omitempty"` // Session serveur (révocable)
 	jwt.RegisteredClaims
 }
 
@@ -30,11 +32,13 @@ func NewJWTService(secretKey string, expiry time.Duration) *JWTService {
 	}
 }
 
-// GenerateToken génère un token JWT pour un utilisateur
-func (j *JWTService) GenerateToken(userID string) (string, error) {
+// GenerateToken génère un token d'accès JWT (courte durée) rattaché à une session
+func (j *JWTService) GenerateToken(userID, role, sessionID string) (string, error) {
 	now := time.Now()
 	claims := Claims{
-		UserID: userID,
+		UserID:    userID,
+		Role:      role,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			IssuedAt:  jwt.NewNumericDate(now),
 			ExpiresAt: jwt.NewNumericDate(now.Add(j.expiry)),
@@ -50,6 +54,15 @@ func (j *JWTService) GenerateToken(userID string) (string, error) {
 
 // ValidateToken valide un token JWT et retourne l'ID utilisateur
 func (j *JWTService) ValidateToken(tokenString string) (string, error) {
+	claims, err := j.ParseToken(tokenString)
+	if err != nil {
+		return "", err
+	}
+	return claims.UserID, nil
+}
+
+// ParseToken valide un token JWT et retourne ses claims (ID utilisateur, rôle)
+func (j *JWTService) ParseToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, ErrTokenInvalid
@@ -59,29 +72,24 @@ func (j *JWTService) ValidateToken(tokenString string) (string, error) {
 
 	if err != nil {
 		if errors.Is(err, jwt.ErrTokenExpired) {
-			return "", ErrTokenExpired
+			return nil, ErrTokenExpired
 		}
-		return "", ErrTokenInvalid
+		return nil, ErrTokenInvalid
 	}
 
 	if !token.Valid {
-		return "", ErrTokenInvalid
+		return nil, ErrTokenInvalid
 	}
 
 	claims, ok := token.Claims.(*Claims)
 	if !ok {
-		return "", ErrTokenInvalid
+		return nil, ErrTokenInvalid
 	}
 
-	return claims.UserID, nil
+	return claims, nil
 }
 
-// RefreshToken génère un nouveau token si l'ancien est encore valide
-func (j *JWTService) RefreshToken(tokenString string) (string, error) {
-	userID, err := j.ValidateToken(tokenString)
-	if err != nil {
-		return "", err
-	}
-
-	return j.GenerateToken(userID)
-}
\ No newline at end of file
+// Expiry durée de validité des tokens d'accès
+func (j *JWTService) Expiry() time.Duration {
+	return j.expiry
+}
@@ -10,7 +10,14 @@ import (
 
 type Middleware struct {
 	jwtService *JWTService
-	authService *Service
+	authService sessionUserLookup
+}
+
+// sessionUserLookup sessions et utilisateurs consultés à chaque requête (implémenté par Service)
+type sessionUserLookup interface {
+	IsSessionRevoked(sessionID string) bool
+	TouchSession(sessionID string)
+	GetUserByID(userID string) (*models.User, error)
 }
 
 func NewMiddleware(jwtService *JWTService, authService *Service) *Middleware {
@@ -23,51 +30,29 @@ func NewMiddleware(jwtService *JWTService, authService *Service) *Middleware {
 // RequireAuth middleware qui exige une authentification
 func (m *Middleware) RequireAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Extraire le token du header Authorization
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Token d'authentification requis",
-			})
-			c.Abort()
-			return
-		}
-
-		// Vérifier le format "Bearer token"
-		tokenParts := strings.Split(authHeader, " ")
-		if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Format de token invalide",
-			})
-			c.Abort()
+		if !m.authenticate(c) {
 			return
 		}
+		c.Next()
+	}
+}
 
-		token := tokenParts[1]
-
-		// Valider le token
-		userID, err := m.jwtService.ValidateToken(token)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Token invalide",
-			})
-			c.Abort()
+// RequireRole middleware qui exige une authentification et au moins le rôle indiqué
+// (le rôle est relu en base : une rétrogradation s'applique sans attendre l'expiration du token)
+func (m *Middleware) RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !m.authenticate(c) {
 			return
 		}
 
-		// Récupérer l'utilisateur
-		user, err := m.authService.GetUserByID(userID)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Utilisateur non trouvé",
+		if !c.MustGet("user").(*models.User).HasRole(role) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Accès réservé (rôle " + role + " requis)",
 			})
 			c.Abort()
 			return
 		}
 
-		// Ajouter l'utilisateur au contexte
-		c.Set("user", user)
-		c.Set("user_id", userID)
 		c.Next()
 	}
 }
@@ -75,33 +60,102 @@ func (m *Middleware) RequireAuth() gin.HandlerFunc {
 // RequireVerifiedUser middleware qui exige un utilisateur vérifié
 func (m *Middleware) RequireVerifiedUser() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// D'abord vérifier l'auth
-		m.RequireAuth()(c)
-		if c.IsAborted() {
+		if !m.authenticate(c) {
 			return
 		}
 
-		// Vérifier que l'utilisateur est vérifié
-		user, exists := c.Get("user")
-		if !exists {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Erreur récupération utilisateur",
+		if !c.MustGet("user").(*models.User).IsVerified {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Compte non vérifié. Vérifiez votre téléphone.",
 			})
 			c.Abort()
 			return
 		}
 
-		userModel := user.(*models.User)
-		if !userModel.IsVerified {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Compte non vérifié. Vérifiez votre téléphone.",
+		c.Next()
+	}
+}
+
+// authenticate vérifie le token, la session et l'utilisateur puis les place dans le contexte.
+// N'appelle jamais c.Next() : les contrôles des middlewares appelants passent avant le handler.
+// Retourne false après avoir interrompu la requête.
+func (m *Middleware) authenticate(c *gin.Context) bool {
+	// Extraire le token du header Authorization
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Token d'authentification requis",
+		})
+		c.Abort()
+		return false
+	}
+
+	// Vérifier le format "Bearer token"
+	tokenParts := strings.Split(authHeader, " ")
+	if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Format de token invalide",
+		})
+		c.Abort()
+		return false
+	}
+
+	// Valider le token
+	claims, err := m.jwtService.ParseToken(tokenParts[1])
+	if err != nil {
+		if err == ErrTokenExpired {
+			// Le client doit échanger son refresh token sur /auth/refresh
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Token expiré",
+				"code":  "token_expired",
 			})
 			c.Abort()
-			return
+			return false
 		}
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Token invalide",
+		})
+		c.Abort()
+		return false
+	}
 
-		c.Next()
+	// Tout token d'accès est rattaché à une session : sans sid, il ne serait jamais révocable
+	if claims.SessionID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Token invalide",
+		})
+		c.Abort()
+		return false
+	}
+
+	// Session révoquée (déconnexion, réutilisation de refresh token)
+	if m.authService.IsSessionRevoked(claims.SessionID) {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Session révoquée",
+			"code":  "session_revoked",
+		})
+		c.Abort()
+		return false
+	}
+
+	// Récupérer l'utilisateur
+	user, err := m.authService.GetUserByID(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Utilisateur non trouvé",
+		})
+		c.Abort()
+		return false
 	}
+
+	m.authService.TouchSession(claims.SessionID)
+
+	// Ajouter l'utilisateur au contexte
+	c.Set("user", user)
+	c.Set("user_id", claims.UserID)
+	c.Set("user_role", user.Role)
+	c.Set("session_id", claims.SessionID)
+	return true
 }
 
 // OptionalAuth middleware d'authentification optionnelle
@@ -120,11 +174,17 @@ func (m *Middleware) OptionalAuth() gin.HandlerFunc {
 		}
 
 		token := tokenParts[1]
-		userID, err := m.jwtService.ValidateToken(token)
+		claims, err := m.jwtService.ParseToken(token)
 		if err != nil {
 			c.Next()
 			return
 		}
+		userID := claims.UserID
+
+		if claims.SessionID == "" || m.authService.IsSessionRevoked(claims.SessionID) {
+			c.Next()
+			return
+		}
 
 		user, err := m.authService.GetUserByID(userID)
 		if err != nil {
@@ -134,6 +194,8 @@ func (m *Middleware) OptionalAuth() gin.HandlerFunc {
 
 		c.Set("user", user)
 		c.Set("user_id", userID)
+		c.Set("user_role", user.Role)
+		c.Set("session_id", claims.SessionID)
 		c.Next()
 	}
 }
\ No newline at end of file
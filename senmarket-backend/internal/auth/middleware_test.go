@@ -0,0 +1,149 @@
+// internal/auth/middleware_test.go
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"senmarket/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// fakeSessionUsers utilisateurs et sessions en mémoire
+type fakeSessionUsers struct {
+	users   map[string]*models.User
+	revoked map[string]bool
+}
+
+func (f *fakeSessionUsers) IsSessionRevoked(sessionID string) bool { return f.revoked[sessionID] }
+func (f *fakeSessionUsers) TouchSession(sessionID string)          {}
+
+func (f *fakeSessionUsers) GetUserByID(userID string) (*models.User, error) {
+	user, ok := f.users[userID]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+func newTestMiddleware(users ...*models.User) (*Middleware, *fakeSessionUsers) {
+	store := &fakeSessionUsers{users: map[string]*models.User{}, revoked: map[string]bool{}}
+	for _, user := range users {
+		store.users[user.ID.String()] = user
+	}
+	return &Middleware{jwtService: NewJWTService("test-secret", 15*time.Minute), authService: store}, store
+}
+
+// serve exécute le middleware sur une route de test et retourne le code HTTP.
+// Le handler protégé ne doit s'exécuter que si le middleware laisse passer la requête.
+func serve(t *testing.T, handler gin.HandlerFunc, token string) int {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	reached := false
+	router := gin.New()
+	router.GET("/", handler, func(c *gin.Context) {
+		reached = true
+		c.JSON(http.StatusOK, gin.H{"reached": true})
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if reached != (rec.Code == http.StatusOK) {
+		t.Errorf("handler exécuté = %v avec le code %d (corps %s)", reached, rec.Code, rec.Body.String())
+	}
+	return rec.Code
+}
+
+func TestRequireRole(t *testing.T) {
+	user := &models.User{ID: uuid.New(), Role: models.RoleUser}
+	moderator := &models.User{ID: uuid.New(), Role: models.RoleModerator}
+	admin := &models.User{ID: uuid.New(), Role: models.RoleAdmin}
+	m, _ := newTestMiddleware(user, moderator, admin)
+
+	tokenFor := func(u *models.User, claimedRole string) string {
+		token, err := m.jwtService.GenerateToken(u.ID.String(), claimedRole, uuid.NewString())
+		if err != nil {
+			t.Fatalf("génération token: %v", err)
+		}
+		return token
+	}
+
+	tests := []struct {
+		name     string
+		required string
+		token    string
+		want     int
+	}{
+		{"sans token", models.RoleAdmin, "", http.StatusUnauthorized},
+		{"utilisateur sur route admin", models.RoleAdmin, tokenFor(user, models.RoleUser), http.StatusForbidden},
+		{"modérateur sur route admin", models.RoleAdmin, tokenFor(moderator, models.RoleModerator), http.StatusForbidden},
+		{"admin sur route admin", models.RoleAdmin, tokenFor(admin, models.RoleAdmin), http.StatusOK},
+		{"admin sur route modérateur", models.RoleModerator, tokenFor(admin, models.RoleAdmin), http.StatusOK},
+		{"modérateur sur route modérateur", models.RoleModerator, tokenFor(moderator, models.RoleModerator), http.StatusOK},
+		// Le rôle est relu en base : un rôle admin dans le token ne suffit pas
+		{"rôle du token ignoré", models.RoleAdmin, tokenFor(user, models.RoleAdmin), http.StatusForbidden},
+		{"rôle inconnu", "superadmin", tokenFor(admin, models.RoleAdmin), http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		if got := serve(t, m.RequireRole(tt.required), tt.token); got != tt.want {
+			t.Errorf("%s: code %d, attendu %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRequireAuthSessions(t *testing.T) {
+	user := &models.User{ID: uuid.New(), Role: models.RoleUser}
+	m, store := newTestMiddleware(user)
+
+	sessionID := uuid.NewString()
+	token, _ := m.jwtService.GenerateToken(user.ID.String(), user.Role, sessionID)
+	if got := serve(t, m.RequireAuth(), token); got != http.StatusOK {
+		t.Fatalf("session active: code %d, attendu 200", got)
+	}
+
+	store.revoked[sessionID] = true
+	if got := serve(t, m.RequireAuth(), token); got != http.StatusUnauthorized {
+		t.Fatalf("session révoquée: code %d, attendu 401", got)
+	}
+
+	// Sans sid le token ne serait jamais révocable
+	withoutSession, _ := m.jwtService.GenerateToken(user.ID.String(), user.Role, "")
+	if got := serve(t, m.RequireAuth(), withoutSession); got != http.StatusUnauthorized {
+		t.Fatalf("token sans session: code %d, attendu 401", got)
+	}
+
+	otherKey := NewJWTService("autre-secret", 15*time.Minute)
+	forged, _ := otherKey.GenerateToken(user.ID.String(), models.RoleAdmin, uuid.NewString())
+	if got := serve(t, m.RequireAuth(), forged); got != http.StatusUnauthorized {
+		t.Fatalf("token mal signé: code %d, attendu 401", got)
+	}
+}
+
+func TestRequireVerifiedUser(t *testing.T) {
+	unverified := &models.User{ID: uuid.New(), Role: models.RoleUser}
+	verified := &models.User{ID: uuid.New(), Role: models.RoleUser, IsVerified: true}
+	m, _ := newTestMiddleware(unverified, verified)
+
+	for _, tt := range []struct {
+		user *models.User
+		want int
+	}{
+		{unverified, http.StatusForbidden},
+		{verified, http.StatusOK},
+	} {
+		token, _ := m.jwtService.GenerateToken(tt.user.ID.String(), tt.user.Role, uuid.NewString())
+		if got := serve(t, m.RequireVerifiedUser(), token); got != tt.want {
+			t.Errorf("vérifié = %v: code %d, attendu %d", tt.user.IsVerified, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+// internal/auth/otp_login.go
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"senmarket/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var ErrCodeDeliveryFailed = errors.New("impossible d'envoyer le code, réessayez plus tard")
+
+const (
+	loginCodeTTL = 10 * time.Minute
+
+	// Limites par fenêtre glissante (téléphone et adresse IP)
+	loginCodeRequestPhoneLimit = 5
+	loginCodeRequestIPLimit    = 20
+	loginCodeVerifyPhoneLimit  = 10
+	loginCodeVerifyIPLimit     = 50
+	loginCodeWindow            = time.Hour
+
+	// Profil minimal des comptes créés à la première connexion OTP
+	otpDefaultFirstName = "Utilisateur"
+	otpDefaultLastName  = "SenMarket"
+	otpDefaultRegion    = "Dakar"
+)
+
+// LoginCodeRequest demande d'un code de connexion sans mot de passe
+type LoginCodeRequest struct {
+	Phone   string `json:"phone" validate:"required,e164"`
+	Channel string `json:"channel" validate:"omitempty,oneof=whatsapp sms"` // Vide : WhatsApp puis SMS
+}
+
+// LoginCodeResponse canal ayant délivré le code
+type LoginCodeResponse struct {
+	Channel   string `json:"channel"`
+	ExpiresIn int64  `json:"expires_in"` // Validité du code (secondes)
+}
+
+// LoginCodeVerifyRequest échange du code contre une session.
+// Le profil est optionnel : il ne sert qu'à la création du compte à la première connexion.
+type LoginCodeVerifyRequest struct {
+	Phone      string `json:"phone" validate:"required,e164"`
+	Code       string `json:"code" validate:"required,len=6"`
+	FirstName  string `json:"first_name" validate:"omitempty,min=2,max=50"`
+	LastName   string `json:"last_name" validate:"omitempty,min=2,max=50"`
+	Region     string `json:"region" validate:"omitempty,max=100"`
+	DeviceName string `json:"device_name" validate:"max=255"` // Affiché dans la liste des sessions
+}
+
+// RequestLoginCode génère un code de connexion et l'envoie par WhatsApp, puis par SMS en secours
+func (s *Service) RequestLoginCode(req *LoginCodeRequest, ip string) (*LoginCodeResponse, error) {
+	if err := s.checkRateLimit("otp_request:phone:"+req.Phone, loginCodeRequestPhoneLimit, loginCodeWindow); err != nil {
+		return nil, err
+	}
+	if err := s.checkRateLimit("otp_request:ip:"+ip, loginCodeRequestIPLimit, loginCodeWindow); err != nil {
+		return nil, err
+	}
+
+	// Un seul code de connexion valide à la fois
+	if err := s.invalidateActiveCodes(req.Phone, models.VerificationPurposeLogin); err != nil {
+		return nil, err
+	}
+
+	code, err := generateVerificationCode()
+	if err != nil {
+		return nil, err
+	}
+	verification := models.SMSVerification{
+		Phone:     req.Phone,
+		Code:      code,
+		Purpose:   models.VerificationPurposeLogin,
+		ExpiresAt: time.Now().Add(loginCodeTTL),
+	}
+	if err := s.db.Create(&verification).Error; err != nil {
+		return nil, fmt.Errorf("erreur sauvegarde code: %w", err)
+	}
+
+	channel, err := s.deliverLoginCode(req.Phone, code, req.Channel)
+	if err != nil {
+		// Code jamais reçu : inutile de le laisser valide
+		s.db.Model(&verification).Update("verified", true)
+		return nil, err
+	}
+
+	if err := s.db.Model(&verification).Update("channel", channel).Error; err != nil {
+		log.Printf("⚠️ Erreur mise à jour canal du code: %v", err)
+	}
+
+	log.Printf("🔐 Code de connexion envoyé vers %s (%s)", req.Phone, channel)
+	return &LoginCodeResponse{
+		Channel:   channel,
+		ExpiresIn: int64(loginCodeTTL.Seconds()),
+	}, nil
+}
+
+// VerifyLoginCode échange le code contre une session ; la première connexion crée le compte
+func (s *Service) VerifyLoginCode(req *LoginCodeVerifyRequest, client ClientInfo) (*AuthResponse, error) {
+	if err := s.checkRateLimit("otp_verify:phone:"+req.Phone, loginCodeVerifyPhoneLimit, loginCodeWindow); err != nil {
+		return nil, err
+	}
+	if err := s.checkRateLimit("otp_verify:ip:"+client.IPAddress, loginCodeVerifyIPLimit, loginCodeWindow); err != nil {
+		return nil, err
+	}
+	if err := s.ensureNotLocked(&codeLockout, req.Phone, client.IPAddress); err != nil {
+		return nil, err
+	}
+
+	var user models.User
+	isNewUser := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var verification models.SMSVerification
+		if err := tx.Where("phone = ? AND code = ? AND purpose = ? AND verified = ?",
+			req.Phone, req.Code, models.VerificationPurposeLogin, false).
+			Order("created_at DESC").
+			First(&verification).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidCode
+			}
+			return fmt.Errorf("erreur recherche code: %w", err)
+		}
+		if verification.IsExpired() {
+			return ErrCodeExpired
+		}
+
+		// Consommation atomique : un code ne sert qu'une fois
+		result := tx.Model(&verification).Where("verified = ?", false).Update("verified", true)
+		if result.Error != nil {
+			return fmt.Errorf("erreur mise à jour vérification: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvalidCode
+		}
+
+		err := tx.Where("phone = ?", req.Phone).First(&user).Error
+		if err == nil {
+			// Le code prouve la possession du numéro
+			if !user.IsVerified {
+				return tx.Model(&user).Update("is_verified", true).Error
+			}
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("erreur recherche utilisateur: %w", err)
+		}
+
+		newUser, err := s.newOTPUser(req)
+		if err != nil {
+			return err
+		}
+		if err := tx.Omit("email").Create(newUser).Error; err != nil {
+			return fmt.Errorf("erreur création utilisateur: %w", err)
+		}
+		user = *newUser
+		isNewUser = true
+		return nil
+	})
+	if err == ErrInvalidCode {
+		s.codeFailed(req.Phone, models.VerificationPurposeLogin, client.IPAddress)
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.resetFailures(&codeLockout, req.Phone)
+
+	if isNewUser {
+		log.Printf("🆕 Compte créé par connexion OTP: %s", user.ID)
+	}
+
+	response, err := s.startSession(&user, client)
+	if err != nil {
+		return nil, err
+	}
+	response.IsNewUser = isNewUser
+	return response, nil
+}
+
+// deliverLoginCode envoie le code sur le canal demandé ; sans préférence, WhatsApp puis SMS
+func (s *Service) deliverLoginCode(phone, code, preferred string) (string, error) {
+	if preferred != models.VerificationChannelSMS && s.whatsApp != nil {
+		err := s.whatsApp.SendCode(phone, code)
+		if err == nil {
+			return models.VerificationChannelWhatsApp, nil
+		}
+		log.Printf("📱 Échec WhatsApp pour %s, bascule SMS: %v", phone, err)
+	}
+
+	message := fmt.Sprintf("🇸🇳 SenMarket: Votre code de connexion est %s. Valable 10 min. Ne le partagez pas.", code)
+	if err := s.sms.SendSMS(phone, message); err != nil {
+		log.Printf("📱 Erreur SMS code de connexion: %v", err)
+		return "", ErrCodeDeliveryFailed
+	}
+	return models.VerificationChannelSMS, nil
+}
+
+// newOTPUser compte à profil minimal, vérifié par le code ; le mot de passe aléatoire
+// pourra être remplacé via /auth/password/forgot
+func (s *Service) newOTPUser(req *LoginCodeVerifyRequest) (*models.User, error) {
+	password, err := generateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	hashedPassword, err := s.hashPassword(password)
+	if err != nil {
+		return nil, fmt.Errorf("erreur hachage mot de passe: %w", err)
+	}
+
+	user := &models.User{
+		Phone:        req.Phone,
+		PasswordHash: hashedPassword,
+		FirstName:    req.FirstName,
+		LastName:     req.LastName,
+		Region:       req.Region,
+		IsVerified:   true,
+		Role:         models.RoleUser,
+	}
+	if user.FirstName == "" {
+		user.FirstName = otpDefaultFirstName
+	}
+	if user.LastName == "" {
+		user.LastName = otpDefaultLastName
+	}
+	if user.Region == "" {
+		user.Region = otpDefaultRegion
+	}
+	return user, nil
+}
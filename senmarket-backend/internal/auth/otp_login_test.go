@@ -0,0 +1,115 @@
+// internal/auth/otp_login_test.go
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"senmarket/internal/models"
+)
+
+// fakeWhatsApp enregistre les codes envoyés par WhatsApp
+type fakeWhatsApp struct {
+	sent []string
+	err  error
+}
+
+func (f *fakeWhatsApp) SendCode(phone, code string) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.sent = append(f.sent, phone)
+	return nil
+}
+
+func TestDeliverLoginCode(t *testing.T) {
+	unavailable := errors.New("indisponible")
+	tests := []struct {
+		name      string
+		whatsApp  *fakeWhatsApp
+		smsErr    error
+		preferred string
+		want      string
+		wantErr   error
+		whatsSent int
+		smsSent   int
+	}{
+		{"WhatsApp d'abord", &fakeWhatsApp{}, nil, "", models.VerificationChannelWhatsApp, nil, 1, 0},
+		{"bascule SMS", &fakeWhatsApp{err: unavailable}, nil, "", models.VerificationChannelSMS, nil, 0, 1},
+		{"SMS demandé", &fakeWhatsApp{}, nil, models.VerificationChannelSMS, models.VerificationChannelSMS, nil, 0, 1},
+		{"sans WhatsApp", nil, nil, models.VerificationChannelWhatsApp, models.VerificationChannelSMS, nil, 0, 1},
+		{"aucun canal", &fakeWhatsApp{err: unavailable}, unavailable, "", "", ErrCodeDeliveryFailed, 0, 0},
+	}
+	for _, tt := range tests {
+		sms := &fakeSMS{err: tt.smsErr}
+		var whatsApp WhatsAppSender
+		if tt.whatsApp != nil {
+			whatsApp = tt.whatsApp
+		}
+		service := NewService(nil, nil, sms, whatsApp, nil, time.Hour)
+
+		channel, err := service.deliverLoginCode("+221761234567", "123456", tt.preferred)
+		if channel != tt.want || !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: canal = %q (%v), attendu %q (%v)", tt.name, channel, err, tt.want, tt.wantErr)
+		}
+		whatsSent := 0
+		if tt.whatsApp != nil {
+			whatsSent = len(tt.whatsApp.sent)
+		}
+		if whatsSent != tt.whatsSent || len(sms.sent) != tt.smsSent {
+			t.Errorf("%s: %d WhatsApp / %d SMS, attendu %d / %d", tt.name, whatsSent, len(sms.sent), tt.whatsSent, tt.smsSent)
+		}
+	}
+}
+
+func TestVerifyLoginCodeCreatesUser(t *testing.T) {
+	tx := testTx(t)
+	service := NewService(tx, NewJWTService("test-secret", 15*time.Minute), &fakeSMS{}, nil, nil, 30*24*time.Hour)
+	phone := testPhone()
+
+	verification := models.SMSVerification{
+		Phone:     phone,
+		Code:      "123456",
+		Purpose:   models.VerificationPurposeLogin,
+		ExpiresAt: time.Now().Add(loginCodeTTL),
+	}
+	if err := tx.Create(&verification).Error; err != nil {
+		t.Fatalf("création code: %v", err)
+	}
+
+	client := ClientInfo{DeviceName: "Test", IPAddress: "127.0.0.1"}
+	response, err := service.VerifyLoginCode(&LoginCodeVerifyRequest{Phone: phone, Code: "123456", FirstName: "Awa"}, client)
+	if err != nil {
+		t.Fatalf("connexion OTP: %v", err)
+	}
+	if !response.IsNewUser || response.SessionID == "" {
+		t.Fatalf("réponse inattendue: %+v", response)
+	}
+
+	var user models.User
+	if err := tx.Where("phone = ?", phone).First(&user).Error; err != nil {
+		t.Fatalf("compte non créé: %v", err)
+	}
+	if !user.IsVerified || user.FirstName != "Awa" || user.LastName != otpDefaultLastName || user.Region != otpDefaultRegion {
+		t.Fatalf("profil inattendu: %+v", user)
+	}
+
+	// Connexion suivante : même compte, pas de nouvelle création
+	verification = models.SMSVerification{
+		Phone:     phone,
+		Code:      "654321",
+		Purpose:   models.VerificationPurposeLogin,
+		ExpiresAt: time.Now().Add(loginCodeTTL),
+	}
+	if err := tx.Create(&verification).Error; err != nil {
+		t.Fatalf("création code: %v", err)
+	}
+	response, err = service.VerifyLoginCode(&LoginCodeVerifyRequest{Phone: phone, Code: "654321"}, client)
+	if err != nil {
+		t.Fatalf("seconde connexion: %v", err)
+	}
+	if response.IsNewUser || response.User.ID != user.ID {
+		t.Fatalf("seconde connexion: nouveau compte créé (%+v)", response)
+	}
+}
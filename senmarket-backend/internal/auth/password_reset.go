@@ -0,0 +1,177 @@
+// internal/auth/password_reset.go
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"senmarket/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var ErrTooManyAttempts = errors.New("trop de tentatives, réessayez plus tard")
+
+const (
+	passwordResetCodeTTL = 10 * time.Minute
+
+	// Limites par fenêtre glissante (téléphone et adresse IP)
+	passwordForgotPhoneLimit = 3
+	passwordForgotIPLimit    = 10
+	passwordResetPhoneLimit  = 5
+	passwordResetIPLimit     = 20
+	passwordResetWindow      = time.Hour
+
+	rateLimitPrefix = "auth:ratelimit:"
+)
+
+// ForgotPasswordRequest demande de code de réinitialisation
+type ForgotPasswordRequest struct {
+	Phone string `json:"phone" validate:"required"`
+}
+
+// ResetPasswordRequest nouveau mot de passe et code reçu par SMS
+type ResetPasswordRequest struct {
+	Phone       string `json:"phone" validate:"required"`
+	Code        string `json:"code" validate:"required,len=6"`
+	NewPassword string `json:"new_password" validate:"required,min=6"`
+}
+
+// RequestPasswordReset envoie un code de réinitialisation par SMS.
+// La réponse est identique que le numéro ait un compte ou non (pas d'énumération).
+func (s *Service) RequestPasswordReset(phone, ip string) error {
+	if err := s.checkRateLimit("pw_forgot:phone:"+phone, passwordForgotPhoneLimit, passwordResetWindow); err != nil {
+		return err
+	}
+	if err := s.checkRateLimit("pw_forgot:ip:"+ip, passwordForgotIPLimit, passwordResetWindow); err != nil {
+		return err
+	}
+
+	var user models.User
+	if err := s.db.Where("phone = ?", phone).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("🔑 Réinitialisation demandée pour un numéro inconnu (%s)", ip)
+			return nil
+		}
+		return fmt.Errorf("erreur recherche utilisateur: %w", err)
+	}
+
+	// Un seul code de réinitialisation valide à la fois
+	if err := s.invalidateActiveCodes(phone, models.VerificationPurposePasswordReset); err != nil {
+		return err
+	}
+
+	code, err := generateVerificationCode()
+	if err != nil {
+		return err
+	}
+	verification := models.SMSVerification{
+		Phone:     phone,
+		Code:      code,
+		Purpose:   models.VerificationPurposePasswordReset,
+		ExpiresAt: time.Now().Add(passwordResetCodeTTL),
+	}
+	if err := s.db.Create(&verification).Error; err != nil {
+		return fmt.Errorf("erreur sauvegarde code: %w", err)
+	}
+
+	message := fmt.Sprintf("🇸🇳 SenMarket: Code de réinitialisation du mot de passe : %s. Valable 10 min. Si vous n'êtes pas à l'origine de cette demande, ignorez ce message.", code)
+	if err := s.sms.SendSMS(phone, message); err != nil {
+		log.Printf("📱 Erreur SMS réinitialisation: %v", err)
+	}
+
+	log.Printf("🔑 Code de réinitialisation envoyé vers %s", phone)
+	return nil
+}
+
+// ResetPassword change le mot de passe avec le code reçu et déconnecte tous les appareils
+func (s *Service) ResetPassword(req *ResetPasswordRequest, ip string) error {
+	if err := s.checkRateLimit("pw_reset:phone:"+req.Phone, passwordResetPhoneLimit, passwordResetWindow); err != nil {
+		return err
+	}
+	if err := s.checkRateLimit("pw_reset:ip:"+ip, passwordResetIPLimit, passwordResetWindow); err != nil {
+		return err
+	}
+	if err := s.ensureNotLocked(&codeLockout, req.Phone, ip); err != nil {
+		return err
+	}
+
+	hashedPassword, err := s.hashPassword(req.NewPassword)
+	if err != nil {
+		return fmt.Errorf("erreur hachage mot de passe: %w", err)
+	}
+
+	var user models.User
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var verification models.SMSVerification
+		if err := tx.Where("phone = ? AND code = ? AND purpose = ? AND verified = ?",
+			req.Phone, req.Code, models.VerificationPurposePasswordReset, false).
+			Order("created_at DESC").
+			First(&verification).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidCode
+			}
+			return fmt.Errorf("erreur recherche code: %w", err)
+		}
+		if verification.IsExpired() {
+			return ErrCodeExpired
+		}
+
+		// Consommation atomique : un code ne sert qu'une fois
+		result := tx.Model(&verification).Where("verified = ?", false).Update("verified", true)
+		if result.Error != nil {
+			return fmt.Errorf("erreur mise à jour vérification: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvalidCode
+		}
+
+		if err := tx.Where("phone = ?", req.Phone).First(&user).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidCode
+			}
+			return fmt.Errorf("erreur recherche utilisateur: %w", err)
+		}
+
+		// Le code reçu par SMS prouve aussi la possession du numéro
+		return tx.Model(&user).Updates(map[string]interface{}{
+			"password_hash": hashedPassword,
+			"is_verified":   true,
+		}).Error
+	})
+	if err == ErrInvalidCode {
+		s.codeFailed(req.Phone, models.VerificationPurposePasswordReset, ip)
+	}
+	if err != nil {
+		return err
+	}
+	s.resetFailures(&codeLockout, req.Phone)
+
+	revoked, err := s.revokeUserSessions(user.ID.String(), models.SessionRevokedPassword)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("🔑 Mot de passe réinitialisé pour %s (%d session(s) révoquée(s))", user.ID, revoked)
+	return nil
+}
+
+// checkRateLimit compte les tentatives par clé sur la fenêtre (sans Redis : pas de limite)
+func (s *Service) checkRateLimit(key string, limit int, window time.Duration) error {
+	if s.cache == nil {
+		return nil
+	}
+
+	count, err := s.cache.IncrEx(context.Background(), rateLimitPrefix+key, window)
+	if err != nil {
+		log.Printf("⚠️ Limitation indisponible (%s): %v", key, err)
+		return nil
+	}
+	if count > int64(limit) {
+		return ErrTooManyAttempts
+	}
+	return nil
+}
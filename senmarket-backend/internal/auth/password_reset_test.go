@@ -0,0 +1,103 @@
+// internal/auth/password_reset_test.go
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"senmarket/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// createResetCode enregistre un code de réinitialisation connu pour le numéro
+func createResetCode(t *testing.T, tx *gorm.DB, phone, code string, expiresAt time.Time) {
+	t.Helper()
+	verification := models.SMSVerification{
+		Phone:     phone,
+		Code:      code,
+		Purpose:   models.VerificationPurposePasswordReset,
+		ExpiresAt: expiresAt,
+	}
+	if err := tx.Create(&verification).Error; err != nil {
+		t.Fatalf("création code: %v", err)
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	tx := testTx(t)
+	service := NewService(tx, NewJWTService("test-secret", 15*time.Minute), &fakeSMS{}, nil, nil, 30*24*time.Hour)
+
+	user := models.User{
+		Phone:        testPhone(),
+		PasswordHash: "x",
+		FirstName:    "Test",
+		LastName:     "Reset",
+		Region:       "Dakar",
+		Role:         models.RoleUser,
+	}
+	if err := tx.Omit("email").Create(&user).Error; err != nil {
+		t.Fatalf("création utilisateur: %v", err)
+	}
+	login, err := service.startSession(&user, ClientInfo{DeviceName: "Test", IPAddress: "127.0.0.1"})
+	if err != nil {
+		t.Fatalf("ouverture session: %v", err)
+	}
+
+	createResetCode(t, tx, user.Phone, "123456", time.Now().Add(passwordResetCodeTTL))
+	req := &ResetPasswordRequest{Phone: user.Phone, Code: "123456", NewPassword: "nouveau-secret"}
+	if err := service.ResetPassword(req, "127.0.0.1"); err != nil {
+		t.Fatalf("réinitialisation: %v", err)
+	}
+
+	var updated models.User
+	if err := tx.First(&updated, "id = ?", user.ID).Error; err != nil {
+		t.Fatalf("relecture utilisateur: %v", err)
+	}
+	if updated.PasswordHash == user.PasswordHash || !updated.IsVerified {
+		t.Fatal("mot de passe non changé ou numéro non vérifié")
+	}
+
+	// Tous les appareils sont déconnectés
+	if !service.IsSessionRevoked(login.SessionID) {
+		t.Fatal("session non révoquée après réinitialisation")
+	}
+
+	// Le code ne sert qu'une fois
+	req.NewPassword = "autre-secret"
+	if err := service.ResetPassword(req, "127.0.0.1"); !errors.Is(err, ErrInvalidCode) {
+		t.Fatalf("code réutilisé: erreur = %v, attendu ErrInvalidCode", err)
+	}
+}
+
+func TestResetPasswordExpiredCode(t *testing.T) {
+	tx := testTx(t)
+	service := NewService(tx, NewJWTService("test-secret", 15*time.Minute), &fakeSMS{}, nil, nil, time.Hour)
+
+	user := models.User{
+		Phone:        testPhone(),
+		PasswordHash: "x",
+		FirstName:    "Test",
+		LastName:     "Reset",
+		Region:       "Dakar",
+		Role:         models.RoleUser,
+	}
+	if err := tx.Omit("email").Create(&user).Error; err != nil {
+		t.Fatalf("création utilisateur: %v", err)
+	}
+
+	createResetCode(t, tx, user.Phone, "654321", time.Now().Add(-time.Minute))
+	req := &ResetPasswordRequest{Phone: user.Phone, Code: "654321", NewPassword: "nouveau-secret"}
+	if err := service.ResetPassword(req, "127.0.0.1"); !errors.Is(err, ErrCodeExpired) {
+		t.Fatalf("code expiré: erreur = %v, attendu ErrCodeExpired", err)
+	}
+
+	var unchanged models.User
+	if err := tx.First(&unchanged, "id = ?", user.ID).Error; err != nil {
+		t.Fatalf("relecture utilisateur: %v", err)
+	}
+	if unchanged.PasswordHash != user.PasswordHash {
+		t.Fatal("mot de passe changé avec un code expiré")
+	}
+}
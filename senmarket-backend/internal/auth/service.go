@@ -2,35 +2,49 @@
 package auth
 
 import (
+	"crypto/rand"
 	"errors"
 	"fmt"
-	"math/rand"
+	"log"
+	"math/big"
 	"time"
 
 	"senmarket/internal/models"
+	"senmarket/internal/repository/redis"
 
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
 var (
 	ErrInvalidCredentials = errors.New("identifiants invalides")
-	ErrUserNotFound      = errors.New("utilisateur non trouvé")
-	ErrUserExists        = errors.New("utilisateur existe déjà")
-	ErrInvalidCode       = errors.New("code de vérification invalide")
-	ErrCodeExpired       = errors.New("code de vérification expiré")
+	ErrUserNotFound       = errors.New("utilisateur non trouvé")
+	ErrUserExists         = errors.New("utilisateur existe déjà")
+	ErrInvalidCode        = errors.New("code de vérification invalide")
+	ErrCodeExpired        = errors.New("code de vérification expiré")
+	ErrInvalidRole        = errors.New("rôle invalide (user, moderator, admin)")
+	ErrOwnRoleChange      = errors.New("impossible de modifier son propre rôle")
 )
 
 type Service struct {
-	db  *gorm.DB
-	jwt *JWTService
-	sms SMSService
+	db            *gorm.DB
+	jwt           *JWTService
+	sms           SMSService
+	whatsApp      WhatsAppSender
+	cache         *redis.CacheRepository
+	refreshExpiry time.Duration
 }
 
 type SMSService interface {
 	SendSMS(phone, message string) error
 }
 
+// WhatsAppSender livraison des codes par WhatsApp (prioritaire sur le SMS)
+type WhatsAppSender interface {
+	SendCode(phone, code string) error
+}
+
 type RegisterRequest struct {
 	Phone     string `json:"phone" validate:"required,e164"`
 	Email     string `json:"email" validate:"omitempty,email"`
@@ -38,11 +52,14 @@ type RegisterRequest struct {
 	FirstName string `json:"first_name" validate:"required,min=2,max=50"`
 	LastName  string `json:"last_name" validate:"required,min=2,max=50"`
 	Region    string `json:"region" validate:"required"`
+
+	DeviceName string `json:"device_name" validate:"max=255"` // Affiché dans la liste des sessions
 }
 
 type LoginRequest struct {
-	Phone    string `json:"phone" validate:"required"`
-	Password string `json:"password" validate:"required"`
+	Phone      string `json:"phone" validate:"required"`
+	Password   string `json:"password" validate:"required"`
+	DeviceName string `json:"device_name" validate:"max=255"` // Affiché dans la liste des sessions
 }
 
 type VerifyRequest struct {
@@ -51,20 +68,27 @@ type VerifyRequest struct {
 }
 
 type AuthResponse struct {
-	User  *models.User `json:"user"`
-	Token string       `json:"token"`
+	User         *models.User `json:"user"`
+	Token        string       `json:"token"`         // Token d'accès (courte durée)
+	RefreshToken string       `json:"refresh_token"` // À échanger sur /auth/refresh
+	ExpiresIn    int64        `json:"expires_in"`    // Durée de validité du token d'accès (secondes)
+	SessionID    string       `json:"session_id"`
+	IsNewUser    bool         `json:"is_new_user,omitempty"` // Compte créé à la connexion OTP
 }
 
-func NewService(db *gorm.DB, jwtService *JWTService, smsService SMSService) *Service {
+func NewService(db *gorm.DB, jwtService *JWTService, smsService SMSService, whatsApp WhatsAppSender, cache *redis.CacheRepository, refreshExpiry time.Duration) *Service {
 	return &Service{
-		db:  db,
-		jwt: jwtService,
-		sms: smsService,
+		db:            db,
+		jwt:           jwtService,
+		sms:           smsService,
+		whatsApp:      whatsApp,
+		cache:         cache,
+		refreshExpiry: refreshExpiry,
 	}
 }
 
-// Register crée un nouveau compte utilisateur
-func (s *Service) Register(req *RegisterRequest) (*AuthResponse, error) {
+// Register crée un nouveau compte utilisateur et ouvre une session
+func (s *Service) Register(req *RegisterRequest, client ClientInfo) (*AuthResponse, error) {
 	// Vérifier existence par téléphone
 	var existingUser models.User
 	if err := s.db.Where("phone = ?", req.Phone).First(&existingUser).Error; err == nil {
@@ -93,6 +117,7 @@ func (s *Service) Register(req *RegisterRequest) (*AuthResponse, error) {
 		LastName:     req.LastName,
 		Region:       req.Region,
 		IsVerified:   false,
+		Role:         models.RoleUser,
 	}
 
 	if req.Email == "" {
@@ -110,76 +135,73 @@ func (s *Service) Register(req *RegisterRequest) (*AuthResponse, error) {
 		fmt.Printf("Erreur envoi SMS: %v\n", err)
 	}
 
-	// Générer le token JWT
-	token, err := s.jwt.GenerateToken(user.ID.String())
-	if err != nil {
-		return nil, fmt.Errorf("erreur génération token: %w", err)
-	}
-
-	return &AuthResponse{
-		User:  &user,
-		Token: token,
-	}, nil
+	// Ouvrir la session (token d'accès + refresh token)
+	return s.startSession(&user, client)
 }
 
-// Login authentifie un utilisateur
-func (s *Service) Login(req *LoginRequest) (*AuthResponse, error) {
+// Login authentifie un utilisateur et ouvre une session pour son appareil
+func (s *Service) Login(req *LoginRequest, client ClientInfo) (*AuthResponse, error) {
+	if err := s.ensureNotLocked(&loginLockout, req.Phone, client.IPAddress); err != nil {
+		return nil, err
+	}
+
 	var user models.User
 	if err := s.db.Where("phone = ?", req.Phone).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Numéro inconnu compté comme un échec (pas d'énumération)
+			s.recordFailure(&loginLockout, req.Phone, client.IPAddress)
 			return nil, ErrInvalidCredentials
 		}
 		return nil, fmt.Errorf("erreur recherche utilisateur: %w", err)
 	}
 
 	if !s.checkPassword(req.Password, user.PasswordHash) {
+		s.recordFailure(&loginLockout, req.Phone, client.IPAddress)
 		return nil, ErrInvalidCredentials
 	}
 
-	token, err := s.jwt.GenerateToken(user.ID.String())
-	if err != nil {
-		return nil, fmt.Errorf("erreur génération token: %w", err)
-	}
-
-	return &AuthResponse{
-		User:  &user,
-		Token: token,
-	}, nil
+	s.resetFailures(&loginLockout, req.Phone)
+	return s.startSession(&user, client)
 }
 
 // ✅ CORRECTION : SendVerificationCode avec logique de renvoi intelligent
 func (s *Service) SendVerificationCode(phone string) error {
 	// Chercher un code récent (moins de 3 minutes) qui n'est pas encore vérifié
 	var recentVerification models.SMSVerification
-	if err := s.db.Where("phone = ? AND verified = ? AND created_at > ?", 
-		phone, false, time.Now().Add(-3*time.Minute)).
+	if err := s.db.Where("phone = ? AND purpose = ? AND verified = ? AND created_at > ?",
+		phone, models.VerificationPurposePhone, false, time.Now().Add(-3*time.Minute)).
 		Order("created_at DESC").First(&recentVerification).Error; err == nil {
-		
+
 		// Si le code n'a pas expiré, renvoyer le même code
 		if time.Now().Before(recentVerification.ExpiresAt) {
 			message := fmt.Sprintf("🇸🇳 SenMarket: Votre code est %s. Valable 10 min. Ne le partagez pas.", recentVerification.Code)
-			
+
 			if err := s.sms.SendSMS(phone, message); err != nil {
 				fmt.Printf("📱 Erreur renvoi SMS: %v\n", err)
 				return fmt.Errorf("erreur renvoi SMS")
 			}
-			
-			fmt.Printf("📱 Code existant renvoyé: %s vers %s (créé il y a %v)\n", 
+
+			fmt.Printf("📱 Code existant renvoyé: %s vers %s (créé il y a %v)\n",
 				recentVerification.Code, phone, time.Since(recentVerification.CreatedAt).Round(time.Second))
 			return nil
 		}
 	}
-	
+
 	// Sinon, générer un nouveau code
 	return s.sendNewVerificationCode(phone)
 }
 
 // VerifyPhone vérifie le code et active le compte
-func (s *Service) VerifyPhone(req *VerifyRequest) error {
+func (s *Service) VerifyPhone(req *VerifyRequest, ip string) error {
+	if err := s.ensureNotLocked(&codeLockout, req.Phone, ip); err != nil {
+		return err
+	}
+
 	var verification models.SMSVerification
-	if err := s.db.Where("phone = ? AND code = ? AND verified = ?", 
-		req.Phone, req.Code, false).First(&verification).Error; err != nil {
+	if err := s.db.Where("phone = ? AND code = ? AND purpose = ? AND verified = ?",
+		req.Phone, req.Code, models.VerificationPurposePhone, false).First(&verification).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
+			s.codeFailed(req.Phone, models.VerificationPurposePhone, ip)
 			return ErrInvalidCode
 		}
 		return fmt.Errorf("erreur recherche code: %w", err)
@@ -199,6 +221,7 @@ func (s *Service) VerifyPhone(req *VerifyRequest) error {
 		return fmt.Errorf("erreur activation utilisateur: %w", err)
 	}
 
+	s.resetFailures(&codeLockout, req.Phone)
 	return nil
 }
 
@@ -214,6 +237,50 @@ func (s *Service) GetUserByID(userID string) (*models.User, error) {
 	return &user, nil
 }
 
+// UpdateUserRole change le rôle d'un utilisateur (action d'administrateur)
+func (s *Service) UpdateUserRole(actorID, userID, role string) (*models.User, error) {
+	if !models.IsValidRole(role) {
+		return nil, ErrInvalidRole
+	}
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, ErrUserNotFound
+	}
+	if actorID == userID {
+		return nil, ErrOwnRoleChange
+	}
+
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Model(user).Update("role", role).Error; err != nil {
+		return nil, fmt.Errorf("erreur mise à jour rôle: %w", err)
+	}
+
+	log.Printf("🛡️ Rôle de %s changé en %s par %s", userID, role, actorID)
+	return user, nil
+}
+
+// PromoteAdmins promeut administrateurs les comptes des numéros configurés (ADMIN_PHONES)
+func (s *Service) PromoteAdmins(phones []string) error {
+	if len(phones) == 0 {
+		return nil
+	}
+
+	result := s.db.Model(&models.User{}).
+		Where("phone IN ? AND role <> ?", phones, models.RoleAdmin).
+		Update("role", models.RoleAdmin)
+	if result.Error != nil {
+		return fmt.Errorf("erreur promotion administrateurs: %w", result.Error)
+	}
+
+	if result.RowsAffected > 0 {
+		log.Printf("🛡️ %d compte(s) promu(s) administrateur", result.RowsAffected)
+	}
+	return nil
+}
+
 // UpdateProfile met à jour le profil utilisateur
 func (s *Service) UpdateProfile(userID string, updates map[string]interface{}) (*models.User, error) {
 	var user models.User
@@ -271,36 +338,65 @@ func (s *Service) checkPassword(password, hash string) bool {
 
 // ✅ NOUVELLE MÉTHODE : sendNewVerificationCode génère toujours un nouveau code
 func (s *Service) sendNewVerificationCode(phone string) error {
+	// Un seul code de vérification valide à la fois : chaque renvoi ne doit pas ajouter un code à deviner
+	if err := s.invalidateActiveCodes(phone, models.VerificationPurposePhone); err != nil {
+		return err
+	}
+
 	// Générer un code à 6 chiffres
-	code := fmt.Sprintf("%06d", rand.Intn(1000000))
-	
+	code, err := generateVerificationCode()
+	if err != nil {
+		return err
+	}
+
 	// Sauvegarder en base
 	verification := models.SMSVerification{
 		Phone:     phone,
 		Code:      code,
+		Purpose:   models.VerificationPurposePhone,
 		Verified:  false,
 		ExpiresAt: time.Now().Add(10 * time.Minute),
 	}
-	
+
 	if err := s.db.Create(&verification).Error; err != nil {
 		return fmt.Errorf("erreur sauvegarde code: %w", err)
 	}
-	
+
 	// Message SMS
 	message := fmt.Sprintf("🇸🇳 SenMarket: Votre code est %s. Valable 10 min. Ne le partagez pas.", code)
-	
+
 	// Envoyer via Twilio
 	if err := s.sms.SendSMS(phone, message); err != nil {
 		fmt.Printf("📱 Erreur SMS: %v\n", err)
 		// Ne pas bloquer l'inscription même si SMS échoue
 		return nil
 	}
-	
+
 	fmt.Printf("📱 Nouveau code généré: %s vers %s\n", code, phone)
 	return nil
 }
 
+// invalidateActiveCodes marque utilisés les codes encore valides du téléphone pour cet usage,
+// avant l'émission d'un nouveau code (les tentatives ne sont comptées que sur le code actif)
+func (s *Service) invalidateActiveCodes(phone, purpose string) error {
+	if err := s.db.Model(&models.SMSVerification{}).
+		Where("phone = ? AND purpose = ? AND verified = ?", phone, purpose, false).
+		Update("verified", true).Error; err != nil {
+		return fmt.Errorf("erreur invalidation anciens codes: %w", err)
+	}
+	return nil
+}
+
+// generateVerificationCode code à 6 chiffres (crypto/rand : non prédictible)
+func generateVerificationCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", fmt.Errorf("erreur génération code: %w", err)
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
 // ✅ MÉTHODE HÉRITÉE : pour compatibilité (appelée par Register)
 func (s *Service) sendVerificationCode(phone string) error {
 	return s.sendNewVerificationCode(phone)
-}
\ No newline at end of file
+}
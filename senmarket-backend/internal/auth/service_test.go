@@ -0,0 +1,63 @@
+// internal/auth/service_test.go
+package auth
+
+import (
+	"errors"
+	"math/rand"
+	"strconv"
+	"testing"
+	"time"
+
+	"senmarket/internal/models"
+)
+
+// fakeSMS enregistre les SMS envoyés
+type fakeSMS struct {
+	sent []string
+	err  error
+}
+
+func (f *fakeSMS) SendSMS(phone, message string) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.sent = append(f.sent, phone)
+	return nil
+}
+
+// testPhone numéro sénégalais aléatoire (évite les collisions en base de test)
+func testPhone() string {
+	return "+22176" + strconv.Itoa(1000000+rand.Intn(8999999))
+}
+
+func TestSendNewVerificationCodeInvalidatesPreviousCodes(t *testing.T) {
+	tx := testTx(t)
+	service := NewService(tx, NewJWTService("test-secret", 15*time.Minute), &fakeSMS{}, nil, nil, time.Hour)
+	phone := testPhone()
+
+	if err := service.sendNewVerificationCode(phone); err != nil {
+		t.Fatalf("premier code: %v", err)
+	}
+	if err := service.sendNewVerificationCode(phone); err != nil {
+		t.Fatalf("second code: %v", err)
+	}
+
+	var codes []models.SMSVerification
+	if err := tx.Where("phone = ? AND purpose = ?", phone, models.VerificationPurposePhone).
+		Order("created_at ASC").Find(&codes).Error; err != nil || len(codes) != 2 {
+		t.Fatalf("codes en base: %d (%v), attendu 2", len(codes), err)
+	}
+	if !codes[0].Verified || codes[1].Verified {
+		t.Fatalf("seul le dernier code doit rester actif: %v / %v", codes[0].Verified, codes[1].Verified)
+	}
+
+	// L'ancien code ne vérifie plus le numéro
+	if codes[0].Code != codes[1].Code {
+		if err := service.VerifyPhone(&VerifyRequest{Phone: phone, Code: codes[0].Code}, "127.0.0.1"); !errors.Is(err, ErrInvalidCode) {
+			t.Fatalf("ancien code: erreur = %v, attendu ErrInvalidCode", err)
+		}
+	}
+	if err := service.VerifyPhone(&VerifyRequest{Phone: phone, Code: codes[1].Code}, "127.0.0.1"); err != nil {
+		t.Fatalf("code actif: %v", err)
+	}
+}
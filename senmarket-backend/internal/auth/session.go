@@ -0,0 +1,399 @@
+// internal/auth/session.go
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+	"unicode/utf8"
+
+	"senmarket/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrRefreshTokenInvalid = errors.New("refresh token invalide")
+	ErrRefreshTokenExpired = errors.New("refresh token expiré, reconnectez-vous")
+	ErrRefreshTokenReused  = errors.New("refresh token déjà utilisé : session révoquée par sécurité")
+	ErrSessionRevoked      = errors.New("session révoquée, reconnectez-vous")
+	ErrSessionNotFound     = errors.New("session introuvable")
+)
+
+const (
+	refreshTokenBytes      = 32
+	revokedSessionPrefix   = "auth:revoked_session:"
+	sessionSeenPrefix      = "auth:session_seen:"
+	sessionSeenThrottle    = 5 * time.Minute
+	sessionFieldMaxLength  = 255
+	sessionUserAgentMaxLen = 512
+)
+
+// ClientInfo appareil à l'origine d'une connexion
+type ClientInfo struct {
+	DeviceName string
+	UserAgent  string
+	IPAddress  string
+}
+
+// RefreshRequest échange d'un refresh token
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// SessionInfo session active telle que listée à l'utilisateur
+type SessionInfo struct {
+	ID         uuid.UUID `json:"id"`
+	DeviceName string    `json:"device_name"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	CreatedAt  time.Time `json:"created_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+// Refresh échange un refresh token contre une nouvelle paire (rotation).
+// Présenter un token déjà échangé révoque toute la session (vol probable).
+func (s *Service) Refresh(refreshToken string, client ClientInfo) (*AuthResponse, error) {
+	now := time.Now()
+	var (
+		user      models.User
+		session   models.UserSession
+		newToken  string
+		reusedSID *uuid.UUID
+	)
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var token models.RefreshToken
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ?", hashRefreshToken(refreshToken)).
+			First(&token).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRefreshTokenInvalid
+			}
+			return fmt.Errorf("erreur recherche refresh token: %w", err)
+		}
+
+		if err := checkRefreshToken(&token, now); err != nil {
+			if errors.Is(err, ErrRefreshTokenReused) {
+				reusedSID = &token.SessionID
+			}
+			return err
+		}
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&session, "id = ?", token.SessionID).Error; err != nil {
+			return fmt.Errorf("erreur récupération session: %w", err)
+		}
+		if !session.IsActive(now) {
+			return ErrSessionRevoked
+		}
+
+		if err := tx.First(&user, "id = ?", token.UserID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrUserNotFound
+			}
+			return fmt.Errorf("erreur récupération utilisateur: %w", err)
+		}
+
+		if err := tx.Model(&token).UpdateColumn("rotated_at", now).Error; err != nil {
+			return fmt.Errorf("erreur rotation refresh token: %w", err)
+		}
+
+		// Les tokens expirés de la session ne servent plus à détecter une réutilisation
+		tx.Where("session_id = ? AND expires_at < ?", session.ID, now).Delete(&models.RefreshToken{})
+
+		var err error
+		newToken, err = s.createRefreshToken(tx, &session, now)
+		if err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{
+			"last_seen_at": now,
+			"expires_at":   session.ExpiresAt,
+		}
+		if client.IPAddress != "" {
+			updates["ip_address"] = client.IPAddress
+		}
+		if client.UserAgent != "" {
+			updates["user_agent"] = truncate(client.UserAgent, sessionUserAgentMaxLen)
+		}
+		if err := tx.Model(&session).Updates(updates).Error; err != nil {
+			return fmt.Errorf("erreur mise à jour session: %w", err)
+		}
+
+		return nil
+	})
+
+	if errors.Is(err, ErrRefreshTokenReused) && reusedSID != nil {
+		log.Printf("🚨 Réutilisation d'un refresh token : session %s révoquée", *reusedSID)
+		if revokeErr := s.revokeSessions([]uuid.UUID{*reusedSID}, models.SessionRevokedReuse); revokeErr != nil {
+			log.Printf("❌ Erreur révocation session %s: %v", *reusedSID, revokeErr)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := s.jwt.GenerateToken(user.ID.String(), user.Role, session.ID.String())
+	if err != nil {
+		return nil, fmt.Errorf("erreur génération token: %w", err)
+	}
+
+	return s.authResponse(&user, &session, accessToken, newToken), nil
+}
+
+// checkRefreshToken un token déjà échangé signale une réutilisation (prioritaire sur l'expiration)
+func checkRefreshToken(token *models.RefreshToken, now time.Time) error {
+	if token.RotatedAt != nil {
+		return ErrRefreshTokenReused
+	}
+	if now.After(token.ExpiresAt) {
+		return ErrRefreshTokenExpired
+	}
+	return nil
+}
+
+// Logout révoque la session courante
+func (s *Service) Logout(userID, sessionID string) error {
+	return s.RevokeSession(userID, sessionID, models.SessionRevokedLogout)
+}
+
+// LogoutAll révoque toutes les sessions actives de l'utilisateur (tous les appareils)
+func (s *Service) LogoutAll(userID string) (int64, error) {
+	return s.revokeUserSessions(userID, models.SessionRevokedLogoutAll)
+}
+
+// revokeUserSessions révoque toutes les sessions actives d'un utilisateur
+func (s *Service) revokeUserSessions(userID, reason string) (int64, error) {
+	var ids []uuid.UUID
+	if err := s.db.Model(&models.UserSession{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Pluck("id", &ids).Error; err != nil {
+		return 0, fmt.Errorf("erreur récupération sessions: %w", err)
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	if err := s.revokeSessions(ids, reason); err != nil {
+		return 0, err
+	}
+	return int64(len(ids)), nil
+}
+
+// RevokeSession révoque une session de l'utilisateur
+func (s *Service) RevokeSession(userID, sessionID, reason string) error {
+	id, err := uuid.Parse(sessionID)
+	if err != nil {
+		return ErrSessionNotFound
+	}
+
+	var count int64
+	if err := s.db.Model(&models.UserSession{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("erreur récupération session: %w", err)
+	}
+	if count == 0 {
+		return ErrSessionNotFound
+	}
+
+	return s.revokeSessions([]uuid.UUID{id}, reason)
+}
+
+// ListSessions sessions actives de l'utilisateur, la plus récemment vue en premier
+func (s *Service) ListSessions(userID, currentSessionID string) ([]SessionInfo, error) {
+	var sessions []models.UserSession
+	if err := s.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error; err != nil {
+		return nil, fmt.Errorf("erreur récupération sessions: %w", err)
+	}
+
+	infos := make([]SessionInfo, 0, len(sessions))
+	for _, session := range sessions {
+		infos = append(infos, SessionInfo{
+			ID:         session.ID,
+			DeviceName: session.DeviceName,
+			UserAgent:  session.UserAgent,
+			IPAddress:  session.IPAddress,
+			LastSeenAt: session.LastSeenAt,
+			CreatedAt:  session.CreatedAt,
+			ExpiresAt:  session.ExpiresAt,
+			Current:    session.ID.String() == currentSessionID,
+		})
+	}
+	return infos, nil
+}
+
+// IsSessionRevoked vérifie la liste de révocation Redis (base de données si Redis indisponible)
+func (s *Service) IsSessionRevoked(sessionID string) bool {
+	if s.cache != nil {
+		revoked, err := s.cache.Exists(context.Background(), revokedSessionPrefix+sessionID)
+		if err == nil {
+			return revoked
+		}
+		log.Printf("⚠️ Redis indisponible pour la vérification de session: %v", err)
+	}
+
+	var session models.UserSession
+	if err := s.db.Select("id", "revoked_at", "expires_at").First(&session, "id = ?", sessionID).Error; err != nil {
+		return true
+	}
+	return !session.IsActive(time.Now())
+}
+
+// TouchSession met à jour la dernière activité de la session (au plus une fois toutes les 5 minutes)
+func (s *Service) TouchSession(sessionID string) {
+	if s.cache == nil {
+		return
+	}
+
+	first, err := s.cache.SetNX(context.Background(), sessionSeenPrefix+sessionID, 1, sessionSeenThrottle)
+	if err != nil || !first {
+		return
+	}
+
+	if err := s.db.Model(&models.UserSession{}).
+		Where("id = ?", sessionID).
+		UpdateColumn("last_seen_at", time.Now()).Error; err != nil {
+		log.Printf("⚠️ Erreur mise à jour session %s: %v", sessionID, err)
+	}
+}
+
+// startSession ouvre une session pour l'appareil et émet la paire de tokens
+func (s *Service) startSession(user *models.User, client ClientInfo) (*AuthResponse, error) {
+	now := time.Now()
+	session := models.UserSession{
+		UserID:     user.ID,
+		DeviceName: truncate(deviceName(client), sessionFieldMaxLength),
+		UserAgent:  truncate(client.UserAgent, sessionUserAgentMaxLen),
+		IPAddress:  client.IPAddress,
+		LastSeenAt: now,
+	}
+
+	var refreshToken string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		session.ExpiresAt = now.Add(s.refreshExpiry)
+		if err := tx.Create(&session).Error; err != nil {
+			return fmt.Errorf("erreur création session: %w", err)
+		}
+
+		var err error
+		refreshToken, err = s.createRefreshToken(tx, &session, now)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := s.jwt.GenerateToken(user.ID.String(), user.Role, session.ID.String())
+	if err != nil {
+		return nil, fmt.Errorf("erreur génération token: %w", err)
+	}
+
+	return s.authResponse(user, &session, accessToken, refreshToken), nil
+}
+
+// createRefreshToken émet un refresh token et prolonge la session (expiration glissante)
+func (s *Service) createRefreshToken(tx *gorm.DB, session *models.UserSession, now time.Time) (string, error) {
+	token, err := generateRefreshToken()
+	if err != nil {
+		return "", err
+	}
+
+	session.ExpiresAt = now.Add(s.refreshExpiry)
+	record := models.RefreshToken{
+		SessionID: session.ID,
+		UserID:    session.UserID,
+		TokenHash: hashRefreshToken(token),
+		ExpiresAt: session.ExpiresAt,
+	}
+	if err := tx.Create(&record).Error; err != nil {
+		return "", fmt.Errorf("erreur enregistrement refresh token: %w", err)
+	}
+	return token, nil
+}
+
+// revokeSessions révoque les sessions et bloque leurs tokens d'accès encore valides
+func (s *Service) revokeSessions(ids []uuid.UUID, reason string) error {
+	if err := s.db.Model(&models.UserSession{}).
+		Where("id IN ? AND revoked_at IS NULL", ids).
+		Updates(map[string]interface{}{
+			"revoked_at":     time.Now(),
+			"revoked_reason": reason,
+		}).Error; err != nil {
+		return fmt.Errorf("erreur révocation session: %w", err)
+	}
+
+	if s.cache != nil {
+		ctx := context.Background()
+		for _, id := range ids {
+			// Les tokens d'accès émis restent signés jusqu'à leur expiration : liste de révocation
+			if err := s.cache.Set(ctx, revokedSessionPrefix+id.String(), reason, s.jwt.Expiry()); err != nil {
+				log.Printf("⚠️ Erreur liste de révocation session %s: %v", id, err)
+			}
+		}
+	}
+	return nil
+}
+
+// authResponse réponse d'authentification (paire de tokens et session)
+func (s *Service) authResponse(user *models.User, session *models.UserSession, accessToken, refreshToken string) *AuthResponse {
+	return &AuthResponse{
+		User:         user,
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(s.jwt.Expiry().Seconds()),
+		SessionID:    session.ID.String(),
+	}
+}
+
+// generateRefreshToken token opaque aléatoire (256 bits)
+func generateRefreshToken() (string, error) {
+	buf := make([]byte, refreshTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("erreur génération refresh token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashRefreshToken empreinte SHA-256 stockée en base
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// deviceName nom fourni par l'application, sinon l'User-Agent
+func deviceName(client ClientInfo) string {
+	if client.DeviceName != "" {
+		return client.DeviceName
+	}
+	if client.UserAgent != "" {
+		return client.UserAgent
+	}
+	return "Appareil inconnu"
+}
+
+// truncate coupe à max octets sans casser un caractère UTF-8
+func truncate(value string, max int) string {
+	if len(value) <= max {
+		return value
+	}
+	// Reculer jusqu'au début d'un caractère : les octets de continuation ne sont jamais conservés seuls
+	for max > 0 && !utf8.RuneStart(value[max]) {
+		max--
+	}
+	return value[:max]
+}
@@ -0,0 +1,167 @@
+// internal/auth/session_test.go
+package auth
+
+import (
+	"errors"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"senmarket/internal/models"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		value string
+		max   int
+		want  string
+	}{
+		{"Android", 20, "Android"},
+		{"Android", 7, "Android"},
+		{"Android", 3, "And"},
+		{"Téléphone", 2, "T"}, // é sur 2 octets : coupé avant
+		{"Téléphone", 3, "Té"},
+		{"a€b", 3, "a"}, // € sur 3 octets, coupé après 2
+		{"a€b", 4, "a€"},
+		{"€", 0, ""},
+	}
+	for _, tt := range tests {
+		got := truncate(tt.value, tt.max)
+		if got != tt.want {
+			t.Errorf("truncate(%q, %d) = %q, attendu %q", tt.value, tt.max, got, tt.want)
+		}
+		if !utf8.ValidString(got) || len(got) > tt.max {
+			t.Errorf("truncate(%q, %d) = %q: UTF-8 invalide ou trop long", tt.value, tt.max, got)
+		}
+	}
+}
+
+func TestHashRefreshToken(t *testing.T) {
+	token, err := generateRefreshToken()
+	if err != nil {
+		t.Fatalf("génération: %v", err)
+	}
+
+	hash := hashRefreshToken(token)
+	if len(hash) != 64 || strings.Contains(hash, token) {
+		t.Fatalf("empreinte inattendue: %q", hash)
+	}
+	if hashRefreshToken(token) != hash {
+		t.Fatal("empreinte non déterministe")
+	}
+	if hashRefreshToken(token+"x") == hash {
+		t.Fatal("deux tokens différents ont la même empreinte")
+	}
+	// Empreinte SHA-256 connue
+	if got := hashRefreshToken("abc"); got != "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad" {
+		t.Fatalf("sha256(abc) = %s", got)
+	}
+
+	other, _ := generateRefreshToken()
+	if other == token {
+		t.Fatal("deux refresh tokens identiques")
+	}
+}
+
+func TestCheckRefreshToken(t *testing.T) {
+	now := time.Now()
+	rotated := now.Add(-time.Minute)
+
+	tests := []struct {
+		name  string
+		token models.RefreshToken
+		want  error
+	}{
+		{"valide", models.RefreshToken{ExpiresAt: now.Add(time.Hour)}, nil},
+		{"expiré", models.RefreshToken{ExpiresAt: now.Add(-time.Hour)}, ErrRefreshTokenExpired},
+		{"déjà échangé", models.RefreshToken{ExpiresAt: now.Add(time.Hour), RotatedAt: &rotated}, ErrRefreshTokenReused},
+		// Un token volé puis expiré reste une réutilisation : la session doit être révoquée
+		{"déjà échangé et expiré", models.RefreshToken{ExpiresAt: now.Add(-time.Hour), RotatedAt: &rotated}, ErrRefreshTokenReused},
+	}
+	for _, tt := range tests {
+		if err := checkRefreshToken(&tt.token, now); !errors.Is(err, tt.want) {
+			t.Errorf("%s: erreur = %v, attendu %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestRefreshRotationAndReuseDetection(t *testing.T) {
+	tx := testTx(t)
+	service := NewService(tx, NewJWTService("test-secret", 15*time.Minute), nil, nil, nil, 30*24*time.Hour)
+
+	user := models.User{
+		Phone:        "+22178" + strconv.Itoa(1000000+rand.Intn(8999999)),
+		PasswordHash: "x",
+		FirstName:    "Test",
+		LastName:     "Session",
+		Region:       "Dakar",
+		Role:         models.RoleUser,
+	}
+	if err := tx.Omit("email").Create(&user).Error; err != nil {
+		t.Fatalf("création utilisateur: %v", err)
+	}
+
+	client := ClientInfo{DeviceName: "Test", IPAddress: "127.0.0.1"}
+	login, err := service.startSession(&user, client)
+	if err != nil {
+		t.Fatalf("ouverture session: %v", err)
+	}
+
+	// Rotation : nouvelle paire, même session
+	rotated, err := service.Refresh(login.RefreshToken, client)
+	if err != nil {
+		t.Fatalf("rotation: %v", err)
+	}
+	if rotated.RefreshToken == login.RefreshToken || rotated.SessionID != login.SessionID {
+		t.Fatalf("rotation incorrecte: %+v", rotated)
+	}
+
+	// Réutilisation de l'ancien token : la session est révoquée
+	if _, err := service.Refresh(login.RefreshToken, client); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("réutilisation: erreur = %v, attendu ErrRefreshTokenReused", err)
+	}
+	if !service.IsSessionRevoked(login.SessionID) {
+		t.Fatal("session non révoquée après réutilisation")
+	}
+
+	// Le token légitime le plus récent ne sert plus
+	if _, err := service.Refresh(rotated.RefreshToken, client); !errors.Is(err, ErrSessionRevoked) {
+		t.Fatalf("token après révocation: erreur = %v, attendu ErrSessionRevoked", err)
+	}
+}
+
+// testTx transaction sur une base PostgreSQL migrée (TEST_DATABASE_URL), annulée en fin de test.
+// Sans TEST_DATABASE_URL le test est ignoré.
+func testTx(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL non défini : test sur base ignoré")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("connexion base de test: %v", err)
+	}
+
+	tx := db.Begin()
+	if tx.Error != nil {
+		t.Fatalf("ouverture transaction: %v", tx.Error)
+	}
+	t.Cleanup(func() {
+		tx.Rollback()
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return tx
+}
@@ -4,6 +4,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -13,8 +14,11 @@ type Config struct {
 	Database DatabaseConfig
 	Redis    RedisConfig
 	JWT      JWTConfig
+	Auth     AuthConfig
 	WhatsApp WhatsAppConfig
 	MinIO    MinIOConfig        // ⭐ NOUVEAU: Configuration MinIO
+	Payment  PaymentConfig
+	Listing  ListingConfig
 }
 
 type DatabaseConfig struct {
@@ -34,8 +38,16 @@ type RedisConfig struct {
 }
 
 type JWTConfig struct {
-	Secret string
-	Expiry time.Duration
+	Secret        string
+	Expiry        time.Duration // Durée des tokens d'accès
+	RefreshExpiry time.Duration // Durée d'une session sans activité (refresh token)
+}
+
+// Comptes et rôles
+type AuthConfig struct {
+	AdminPhones     []string // Numéros promus administrateurs au démarrage
+	TrustedProxies  []string // Proxys dont l'en-tête X-Forwarded-For est cru (vide : adresse de connexion)
+	TrustedPlatform string   // En-tête d'IP client posé par la plateforme (ex. CF-Connecting-IP)
 }
 
 type WhatsAppConfig struct {
@@ -57,14 +69,31 @@ type MinIOConfig struct {
 	Region     string
 }
 
+// Réconciliation des paiements restés en attente
+type PaymentConfig struct {
+	ReconcileInterval time.Duration // Fréquence du job
+	ReconcileAfter    time.Duration // Âge minimum d'un paiement pending avant interrogation du provider
+	PendingExpiry     time.Duration // Au-delà, un paiement toujours pending est marqué échoué
+	MockEnabled       bool          // Provider de test (PAYMENT_MOCK_ENABLED=true, jamais en production)
+}
+
+// Tâches périodiques sur les annonces
+type ListingConfig struct {
+	BoostExpiryInterval      time.Duration // Fréquence de retrait des boosts expirés
+	SavedSearchAlertInterval time.Duration // Fréquence des alertes de recherches sauvegardées
+	SavedSearchDigestHour    int           // Heure (UTC) d'envoi des résumés quotidiens
+	ExpiryCheckInterval      time.Duration // Fréquence d'expiration des annonces échues
+	ExpiryWarningDays        int           // Avertissement SMS N jours avant l'expiration (0 = désactivé)
+}
+
 func Load() (*Config, error) {
 	env := getEnv("ENV", "development")
 	
 	// Parse JWT expiry
-	jwtExpiryStr := getEnv("JWT_EXPIRY", "24h")
+	jwtExpiryStr := getEnv("JWT_EXPIRY", "15m")
 	jwtExpiry, err := time.ParseDuration(jwtExpiryStr)
 	if err != nil {
-		jwtExpiry = 24 * time.Hour
+		jwtExpiry = 15 * time.Minute
 	}
 
 	// Parse Redis DB
@@ -79,11 +108,15 @@ func Load() (*Config, error) {
 		Database: getDatabaseConfig(env),
 		Redis:    getRedisConfig(env),
 		JWT: JWTConfig{
-			Secret: getEnv("JWT_SECRET", getDefaultJWTSecret(env)),
-			Expiry: jwtExpiry,
+			Secret:        getEnv("JWT_SECRET", getDefaultJWTSecret(env)),
+			Expiry:        jwtExpiry,
+			RefreshExpiry: getEnvDuration("JWT_REFRESH_EXPIRY", 30*24*time.Hour),
 		},
+		Auth:     getAuthConfig(),
 		WhatsApp: getWhatsAppConfig(env),
 		MinIO:    getMinIOConfig(env),    // ⭐ NOUVEAU
+		Payment:  getPaymentConfig(env),
+		Listing:  getListingConfig(),
 	}
 
 	return config, nil
@@ -203,6 +236,35 @@ func getWhatsAppConfig(env string) WhatsAppConfig {
 	}
 }
 
+// Configuration de la réconciliation des paiements.
+// Le provider mock valide tout paiement : il exige un opt-in explicite, ENV seul ne suffit pas.
+func getPaymentConfig(env string) PaymentConfig {
+	return PaymentConfig{
+		ReconcileInterval: getEnvDuration("PAYMENT_RECONCILE_INTERVAL", 10*time.Minute),
+		ReconcileAfter:    getEnvDuration("PAYMENT_RECONCILE_AFTER", 15*time.Minute),
+		PendingExpiry:     getEnvDuration("PAYMENT_PENDING_EXPIRY", 24*time.Hour),
+		MockEnabled:       getEnvBool("PAYMENT_MOCK_ENABLED", false) && env != "production",
+	}
+}
+
+func getAuthConfig() AuthConfig {
+	return AuthConfig{
+		AdminPhones:     getEnvList("ADMIN_PHONES"),
+		TrustedProxies:  getEnvList("TRUSTED_PROXIES"),
+		TrustedPlatform: os.Getenv("TRUSTED_PLATFORM"),
+	}
+}
+
+func getListingConfig() ListingConfig {
+	return ListingConfig{
+		BoostExpiryInterval:      getEnvDuration("LISTING_BOOST_EXPIRY_INTERVAL", 5*time.Minute),
+		SavedSearchAlertInterval: getEnvDuration("SAVED_SEARCH_ALERT_INTERVAL", 5*time.Minute),
+		SavedSearchDigestHour:    getEnvInt("SAVED_SEARCH_DIGEST_HOUR", 8),
+		ExpiryCheckInterval:      getEnvDuration("LISTING_EXPIRY_CHECK_INTERVAL", 15*time.Minute),
+		ExpiryWarningDays:        getEnvInt("LISTING_EXPIRY_WARNING_DAYS", 3),
+	}
+}
+
 // Fonctions utilitaires pour les valeurs par défaut
 func getDefaultJWTSecret(env string) string {
 	switch env {
@@ -254,6 +316,44 @@ func getDefaultMinIOSSL(env string) string {
 	}
 }
 
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+	}
+	return defaultValue
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+// getEnvList liste séparée par des virgules (entrées vides ignorées)
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -2,7 +2,10 @@
 package handlers
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 
 	"senmarket/internal/auth"
 	"senmarket/internal/models"
@@ -55,7 +58,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	}
 
 	// Inscription
-	response, err := h.authService.Register(&req)
+	response, err := h.authService.Register(&req, clientInfo(c, req.DeviceName))
 	if err != nil {
 		status := http.StatusInternalServerError
 		if err == auth.ErrUserExists {
@@ -84,6 +87,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 // @Success 200 {object} auth.AuthResponse
 // @Failure 400 {object} map[string]interface{}
 // @Failure 401 {object} map[string]interface{}
+// @Failure 429 {object} map[string]interface{}
 // @Router /auth/login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req auth.LoginRequest
@@ -106,8 +110,12 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	}
 
 	// Connexion
-	response, err := h.authService.Login(&req)
+	response, err := h.authService.Login(&req, clientInfo(c, req.DeviceName))
 	if err != nil {
+		if abortLocked(c, err) {
+			return
+		}
+
 		status := http.StatusInternalServerError
 		if err == auth.ErrInvalidCredentials {
 			status = http.StatusUnauthorized
@@ -134,6 +142,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 // @Param verification body auth.VerifyRequest true "Code de vérification"
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]interface{}
+// @Failure 429 {object} map[string]interface{}
 // @Router /auth/verify [post]
 func (h *AuthHandler) VerifyPhone(c *gin.Context) {
 	var req auth.VerifyRequest
@@ -156,7 +165,11 @@ func (h *AuthHandler) VerifyPhone(c *gin.Context) {
 	}
 
 	// Vérification
-	if err := h.authService.VerifyPhone(&req); err != nil {
+	if err := h.authService.VerifyPhone(&req, c.ClientIP()); err != nil {
+		if abortLocked(c, err) {
+			return
+		}
+
 		status := http.StatusBadRequest
 		if err == auth.ErrInvalidCode || err == auth.ErrCodeExpired {
 			status = http.StatusBadRequest
@@ -218,6 +231,397 @@ func (h *AuthHandler) SendVerificationCode(c *gin.Context) {
 	})
 }
 
+// ForgotPassword godoc
+// @Summary Mot de passe oublié
+// @Description Envoie un code de réinitialisation par SMS. La réponse est la même que le numéro ait un compte ou non.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body auth.ForgotPasswordRequest true "Numéro de téléphone"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 429 {object} map[string]interface{}
+// @Router /auth/password/forgot [post]
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req auth.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Données invalides",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Validation échouée",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := h.authService.RequestPasswordReset(req.Phone, c.ClientIP()); err != nil {
+		status := http.StatusInternalServerError
+		if err == auth.ErrTooManyAttempts {
+			status = http.StatusTooManyRequests
+		}
+
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Si un compte existe pour ce numéro, un code de réinitialisation a été envoyé par SMS",
+	})
+}
+
+// ResetPassword godoc
+// @Summary Réinitialiser le mot de passe
+// @Description Définit un nouveau mot de passe avec le code reçu par SMS et déconnecte tous les appareils
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body auth.ResetPasswordRequest true "Téléphone, code et nouveau mot de passe"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 429 {object} map[string]interface{}
+// @Router /auth/password/reset [post]
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req auth.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Données invalides",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Validation échouée",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := h.authService.ResetPassword(&req, c.ClientIP()); err != nil {
+		if abortLocked(c, err) {
+			return
+		}
+
+		status := http.StatusInternalServerError
+		switch err {
+		case auth.ErrInvalidCode, auth.ErrCodeExpired:
+			status = http.StatusBadRequest
+		case auth.ErrTooManyAttempts:
+			status = http.StatusTooManyRequests
+		}
+
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Mot de passe réinitialisé. Reconnectez-vous sur vos appareils.",
+	})
+}
+
+// RequestLoginCode godoc
+// @Summary Demander un code de connexion
+// @Description Connexion sans mot de passe : envoie un code par WhatsApp, puis par SMS si WhatsApp échoue (ou directement par SMS si channel=sms)
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body auth.LoginCodeRequest true "Numéro de téléphone et canal préféré"
+// @Success 200 {object} auth.LoginCodeResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 429 {object} map[string]interface{}
+// @Failure 503 {object} map[string]interface{}
+// @Router /auth/otp/request [post]
+func (h *AuthHandler) RequestLoginCode(c *gin.Context) {
+	var req auth.LoginCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Données invalides",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Validation échouée",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	response, err := h.authService.RequestLoginCode(&req, c.ClientIP())
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch err {
+		case auth.ErrTooManyAttempts:
+			status = http.StatusTooManyRequests
+		case auth.ErrCodeDeliveryFailed:
+			status = http.StatusServiceUnavailable
+		}
+
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Code de connexion envoyé",
+		"data":    response,
+	})
+}
+
+// VerifyLoginCode godoc
+// @Summary Se connecter avec un code
+// @Description Échange le code reçu contre une session. Si aucun compte n'existe pour ce numéro, il est créé avec un profil minimal (is_new_user=true).
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body auth.LoginCodeVerifyRequest true "Téléphone, code et profil optionnel"
+// @Success 200 {object} auth.AuthResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 429 {object} map[string]interface{}
+// @Router /auth/otp/verify [post]
+func (h *AuthHandler) VerifyLoginCode(c *gin.Context) {
+	var req auth.LoginCodeVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Données invalides",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Validation échouée",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	response, err := h.authService.VerifyLoginCode(&req, clientInfo(c, req.DeviceName))
+	if err != nil {
+		if abortLocked(c, err) {
+			return
+		}
+
+		status := http.StatusInternalServerError
+		switch err {
+		case auth.ErrInvalidCode, auth.ErrCodeExpired:
+			status = http.StatusBadRequest
+		case auth.ErrTooManyAttempts:
+			status = http.StatusTooManyRequests
+		}
+
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	message := "Connexion réussie"
+	if response.IsNewUser {
+		message = "Compte créé et connecté"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"data":    response,
+	})
+}
+
+// Refresh godoc
+// @Summary Renouveler les tokens
+// @Description Échange un refresh token contre un nouveau token d'accès et un nouveau refresh token (rotation). Réutiliser un refresh token déjà échangé révoque la session.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param refresh body auth.RefreshRequest true "Refresh token"
+// @Success 200 {object} auth.AuthResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /auth/refresh [post]
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req auth.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Données invalides",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Validation échouée",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	response, err := h.authService.Refresh(req.RefreshToken, clientInfo(c, ""))
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch err {
+		case auth.ErrRefreshTokenInvalid, auth.ErrRefreshTokenExpired, auth.ErrRefreshTokenReused,
+			auth.ErrSessionRevoked, auth.ErrUserNotFound:
+			status = http.StatusUnauthorized
+		}
+
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Tokens renouvelés",
+		"data":    response,
+	})
+}
+
+// Logout godoc
+// @Summary Déconnexion
+// @Description Révoque la session courante : son refresh token et ses tokens d'accès ne sont plus acceptés
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /auth/logout [post]
+func (h *AuthHandler) Logout(c *gin.Context) {
+	sessionID := c.GetString("session_id")
+	if sessionID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Token sans session : reconnectez-vous pour obtenir une session révocable",
+		})
+		return
+	}
+
+	if err := h.authService.Logout(c.GetString("user_id"), sessionID); err != nil && err != auth.ErrSessionNotFound {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Déconnexion réussie",
+	})
+}
+
+// LogoutAll godoc
+// @Summary Déconnexion de tous les appareils
+// @Description Révoque toutes les sessions actives de l'utilisateur, y compris la session courante
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /auth/logout-all [post]
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	revoked, err := h.authService.LogoutAll(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":          "Déconnexion de tous les appareils réussie",
+		"revoked_sessions": revoked,
+	})
+}
+
+// GetSessions godoc
+// @Summary Sessions actives
+// @Description Liste les appareils connectés (nom, adresse IP, dernière activité), la session courante étant signalée
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /auth/sessions [get]
+func (h *AuthHandler) GetSessions(c *gin.Context) {
+	sessions, err := h.authService.ListSessions(c.GetString("user_id"), c.GetString("session_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  sessions,
+		"count": len(sessions),
+	})
+}
+
+// RevokeSession godoc
+// @Summary Déconnecter un appareil
+// @Description Révoque une des sessions actives de l'utilisateur
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID de la session"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /auth/sessions/{id} [delete]
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	if err := h.authService.RevokeSession(c.GetString("user_id"), c.Param("id"), models.SessionRevokedByUser); err != nil {
+		status := http.StatusInternalServerError
+		if err == auth.ErrSessionNotFound {
+			status = http.StatusNotFound
+		}
+
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Appareil déconnecté",
+	})
+}
+
+// clientInfo appareil à l'origine de la requête
+func clientInfo(c *gin.Context, deviceName string) auth.ClientInfo {
+	return auth.ClientInfo{
+		DeviceName: deviceName,
+		UserAgent:  c.Request.UserAgent(),
+		IPAddress:  c.ClientIP(),
+	}
+}
+
+// abortLocked répond 429 avec Retry-After si trop de tentatives ont échoué
+func abortLocked(c *gin.Context, err error) bool {
+	var lockout *auth.LockoutError
+	if !errors.As(err, &lockout) {
+		return false
+	}
+
+	retryAfter := int(math.Ceil(lockout.RetryAfter.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":       err.Error(),
+		"code":        "too_many_failures",
+		"retry_after": retryAfter,
+	})
+	return true
+}
+
 // Profile godoc
 // @Summary Profil utilisateur
 // @Description Récupère le profil de l'utilisateur connecté
@@ -279,4 +683,52 @@ func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 		"message": "Profil mis à jour",
 		"data": userModel,
 	})
-}
\ No newline at end of file
+}
+// UpdateUserRole godoc
+// @Summary Changer le rôle d'un utilisateur
+// @Description Attribue le rôle user, moderator ou admin à un utilisateur (administrateurs)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID de l'utilisateur"
+// @Param role body map[string]string true "Nouveau rôle (user, moderator, admin)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /admin/users/{id}/role [put]
+func (h *AuthHandler) UpdateUserRole(c *gin.Context) {
+	var req struct {
+		Role string `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Données invalides",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	user, err := h.authService.UpdateUserRole(c.GetString("user_id"), c.Param("id"), req.Role)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch err {
+		case auth.ErrInvalidRole, auth.ErrOwnRoleChange:
+			status = http.StatusBadRequest
+		case auth.ErrUserNotFound:
+			status = http.StatusNotFound
+		}
+
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Rôle mis à jour",
+		"data":    user,
+	})
+}
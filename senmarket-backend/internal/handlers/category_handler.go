@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 
 	"senmarket/internal/services"
 
@@ -60,6 +62,27 @@ func (h *CategoryHandler) GetCategoriesWithStats(c *gin.Context) {
 	})
 }
 
+// GetCategoryTree godoc
+// @Summary Arborescence des catégories
+// @Description Catégories et sous-catégories avec le nombre d'annonces (sous-catégories incluses)
+// @Tags categories
+// @Produce json
+// @Success 200 {array} services.CategoryTreeNode
+// @Router /categories/tree [get]
+func (h *CategoryHandler) GetCategoryTree(c *gin.Context) {
+	tree, err := h.categoryService.GetCategoryTree()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": tree,
+	})
+}
+
 // GetCategory godoc
 // @Summary Détail d'une catégorie
 // @Description Récupère une catégorie par ID
@@ -92,15 +115,15 @@ func (h *CategoryHandler) GetCategory(c *gin.Context) {
 
 // GetCategoryBySlug godoc
 // @Summary Catégorie par slug
-// @Description Récupère une catégorie par son slug
+// @Description Récupère une catégorie par son slug ou son chemin complet (ex: vehicles/cars)
 // @Tags categories
 // @Produce json
-// @Param slug path string true "Slug de la catégorie"
+// @Param path path string true "Slug ou chemin de la catégorie"
 // @Success 200 {object} models.Category
 // @Failure 404 {object} map[string]interface{}
-// @Router /categories/slug/{slug} [get]
+// @Router /categories/slug/{path} [get]
 func (h *CategoryHandler) GetCategoryBySlug(c *gin.Context) {
-	slug := c.Param("slug")
+	slug := c.Param("path")
 	
 	category, err := h.categoryService.GetCategoryBySlug(slug)
 	if err != nil {
@@ -133,6 +156,8 @@ func (h *CategoryHandler) GetCategoryBySlug(c *gin.Context) {
 // @Param sort query string false "Tri" Enums(date, price_asc, price_desc, views)
 // @Param page query int false "Page" default(1)
 // @Param limit query int false "Limite par page" default(20)
+// @Param cursor query string false "Curseur de pagination (next_cursor de la réponse précédente, remplace page)"
+// @Param attr.{key} query string false "Attribut de catégorie : attr.fuel=diesel, attr.year_min=2015"
 // @Success 200 {object} services.ListingResponse
 // @Failure 404 {object} map[string]interface{}
 // @Router /categories/{id}/listings [get]
@@ -147,12 +172,15 @@ func (h *CategoryHandler) GetListingsByCategory(c *gin.Context) {
 		})
 		return
 	}
+	query.Attributes = attributeFilters(c)
 
 	response, err := h.categoryService.GetListingsByCategory(categoryID, &query)
 	if err != nil {
 		status := http.StatusInternalServerError
 		if err == services.ErrCategoryNotFound {
 			status = http.StatusNotFound
+		} else if err == services.ErrInvalidCursor {
+			status = http.StatusBadRequest
 		}
 		
 		c.JSON(status, gin.H{
@@ -194,4 +222,112 @@ func (h *CategoryHandler) GetCategoryStats(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"data": stats,
 	})
-}
\ No newline at end of file
+}
+// GetAttributeSchema godoc
+// @Summary Schéma d'attributs d'une catégorie
+// @Description Récupère les attributs attendus pour les annonces de la catégorie (version courante par défaut)
+// @Tags categories
+// @Produce json
+// @Param id path string true "ID de la catégorie"
+// @Param version query int false "Version du schéma (courante si absente)"
+// @Success 200 {object} models.CategoryAttributeSchema
+// @Failure 404 {object} map[string]interface{}
+// @Router /categories/{id}/attributes [get]
+func (h *CategoryHandler) GetAttributeSchema(c *gin.Context) {
+	version, _ := strconv.Atoi(c.Query("version"))
+
+	schema, err := h.categoryService.GetAttributeSchema(c.Param("id"), version)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err == services.ErrCategoryNotFound || err == services.ErrAttributeSchemaNotFound {
+			status = http.StatusNotFound
+		}
+
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": schema,
+	})
+}
+
+// GetAttributeSchemaVersions godoc
+// @Summary Historique du schéma d'attributs
+// @Description Liste les versions du schéma d'attributs d'une catégorie (la plus récente en premier)
+// @Tags categories
+// @Produce json
+// @Param id path string true "ID de la catégorie"
+// @Success 200 {array} models.CategoryAttributeSchema
+// @Failure 404 {object} map[string]interface{}
+// @Router /categories/{id}/attributes/versions [get]
+func (h *CategoryHandler) GetAttributeSchemaVersions(c *gin.Context) {
+	schemas, err := h.categoryService.GetAttributeSchemaVersions(c.Param("id"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err == services.ErrCategoryNotFound {
+			status = http.StatusNotFound
+		}
+
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": schemas,
+	})
+}
+
+// PublishAttributeSchema godoc
+// @Summary Publier un schéma d'attributs
+// @Description Crée une nouvelle version du schéma d'attributs de la catégorie et la rend courante
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID de la catégorie"
+// @Param request body services.AttributeSchemaRequest true "Attributs"
+// @Success 201 {object} models.CategoryAttributeSchema
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /categories/{id}/attributes [put]
+func (h *CategoryHandler) PublishAttributeSchema(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req services.AttributeSchemaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Données invalides",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	schema, err := h.categoryService.PublishAttributeSchema(c.Param("id"), userID, &req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err == services.ErrCategoryNotFound {
+			status = http.StatusNotFound
+		} else if errors.Is(err, services.ErrInvalidAttributeSchema) {
+			status = http.StatusBadRequest
+		}
+
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Schéma d'attributs publié",
+		"data":    schema,
+	})
+}
@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"senmarket/internal/models"
 	"senmarket/internal/services"
 
 	"github.com/gin-gonic/gin"
@@ -412,7 +414,7 @@ func (h *ImageHandler) GetSignedURL(c *gin.Context) {
 // @Tags images
 // @Produce json
 // @Security BearerAuth
-// @Param prefix query string false "Préfixe de recherche"
+// @Param prefix query string false "Préfixe de recherche (hors de vos images : administrateurs)"
 // @Param limit query int false "Limite (défaut: 50)" default(50)
 // @Success 200 {object} map[string]interface{}
 // @Failure 401 {object} map[string]interface{}
@@ -428,10 +430,16 @@ func (h *ImageHandler) ListImages(c *gin.Context) {
 	}
 
 	// Paramètres de recherche
+	userPrefix := fmt.Sprintf("images/user_%v", userID)
 	prefix := c.Query("prefix")
 	if prefix == "" {
 		// Par défaut, lister les images de l'utilisateur
-		prefix = fmt.Sprintf("images/user_%v", userID)
+		prefix = userPrefix
+	} else if !strings.HasPrefix(prefix, userPrefix) && c.GetString("user_role") != models.RoleAdmin {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Accès réservé aux administrateurs hors de vos propres images",
+		})
+		return
 	}
 
 	limit := 50
@@ -521,7 +529,7 @@ func (h *ImageHandler) ValidateImage(c *gin.Context) {
 // ⭐ NOUVEAU: DeleteByPrefix - Supprime toutes les images avec un préfixe
 // DeleteByPrefix godoc
 // @Summary Supprimer images par préfixe
-// @Description Supprime toutes les images avec un préfixe donné (ex: toutes les images d'une annonce)
+// @Description Supprime toutes les images avec un préfixe donné (ex: toutes les images d'une annonce) - endpoint admin
 // @Tags images
 // @Produce json
 // @Security BearerAuth
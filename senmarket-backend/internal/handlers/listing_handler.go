@@ -2,8 +2,10 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"senmarket/internal/services"
 
@@ -95,6 +97,14 @@ func (h *ListingHandler) CreateListing(c *gin.Context) {
 			})
 			return
 		}
+
+		if err == services.ErrInvalidLocation || err == services.ErrCategoryNotFound || errors.Is(err, services.ErrInvalidAttributes) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Données invalides",
+				"details": err.Error(),
+			})
+			return
+		}
 		
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Erreur création annonce",
@@ -254,11 +264,18 @@ func (h *ListingHandler) PublishListing(c *gin.Context) {
 // @Param limit query int false "Limite par page" default(20)
 // @Param category_id query string false "ID catégorie"
 // @Param region query string false "Région"
+// @Param commune query string false "Commune / quartier (ex: Plateau, Almadies)"
 // @Param min_price query number false "Prix minimum"
 // @Param max_price query number false "Prix maximum"
 // @Param search query string false "Recherche"
-// @Param sort query string false "Tri" Enums(newest, oldest, price_asc, price_desc, views)
+// @Param near query string false "Point de référence lat,lng (ex: 14.6928,-17.4467)"
+// @Param radius_km query number false "Rayon autour de near en km" default(10)
+// @Param sort query string false "Tri (distance nécessite near)" Enums(newest, oldest, price_asc, price_desc, views, relevance, distance)
+// @Param cursor query string false "Curseur de pagination (next_cursor de la réponse précédente, remplace page)"
+// @Param facets query bool false "Inclure les facettes (catégories, régions, prix, photos)"
+// @Param attr.{key} query string false "Attribut de catégorie : attr.fuel=diesel, attr.year_min=2015, attr.rooms_max=4"
 // @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
 // @Router /listings [get]
 func (h *ListingHandler) GetListings(c *gin.Context) {
 	// Paramètres de pagination
@@ -275,6 +292,32 @@ func (h *ListingHandler) GetListings(c *gin.Context) {
 	if region := c.Query("region"); region != "" {
 		filters["region"] = region
 	}
+
+	if commune := strings.TrimSpace(c.Query("commune")); commune != "" {
+		filters["commune"] = commune
+	}
+
+	if nearStr := c.Query("near"); nearStr != "" {
+		near, err := services.ParseGeoPoint(nearStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Paramètre near invalide (format attendu: lat,lng)",
+			})
+			return
+		}
+		filters["near"] = *near
+
+		if radiusStr := c.Query("radius_km"); radiusStr != "" {
+			radius, err := strconv.ParseFloat(radiusStr, 64)
+			if err != nil || radius <= 0 {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": "Paramètre radius_km invalide",
+				})
+				return
+			}
+			filters["radius_km"] = radius
+		}
+	}
 	
 	if minPriceStr := c.Query("min_price"); minPriceStr != "" {
 		if minPrice, err := strconv.ParseFloat(minPriceStr, 64); err == nil {
@@ -296,9 +339,22 @@ func (h *ListingHandler) GetListings(c *gin.Context) {
 		filters["sort"] = sort
 	}
 
-	listings, total, err := h.listingService.GetListings(page, limit, filters)
+	if attributes := attributeFilters(c); len(attributes) > 0 {
+		filters["attributes"] = attributes
+	}
+
+	cursor := c.Query("cursor")
+	if cursor != "" {
+		filters["cursor"] = cursor
+	}
+
+	listings, total, nextCursor, err := h.listingService.GetListings(page, limit, filters)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		status := http.StatusInternalServerError
+		if err == services.ErrInvalidCursor {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{
 			"error": err.Error(),
 		})
 		return
@@ -307,19 +363,34 @@ func (h *ListingHandler) GetListings(c *gin.Context) {
 	// Calcul des pages
 	pages := int((total + int64(limit) - 1) / int64(limit))
 
+	data := gin.H{
+		"listings": listings,
+		"pagination": gin.H{
+			"page":        page,
+			"limit":       limit,
+			"total":       total,
+			"pages":       pages,
+			"has_next":    hasNextPage(cursor, nextCursor, page, pages),
+			"has_prev":    page > 1,
+			"next_cursor": nextCursor,
+		},
+	}
+
+	// Facettes calculées pour les mêmes filtres
+	if withFacets, _ := strconv.ParseBool(c.Query("facets")); withFacets {
+		facets, err := h.listingService.GetListingFacets(filters)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		data["facets"] = facets
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"data": gin.H{
-			"listings": listings,
-			"pagination": gin.H{
-				"page":        page,
-				"limit":       limit,
-				"total":       total,
-				"pages":       pages,
-				"has_next":    page < pages,
-				"has_prev":    page > 1,
-			},
-		},
+		"data":    data,
 	})
 }
 
@@ -367,6 +438,7 @@ func (h *ListingHandler) GetListing(c *gin.Context) {
 // @Security BearerAuth
 // @Param page query int false "Page" default(1)
 // @Param limit query int false "Limite par page" default(20)
+// @Param cursor query string false "Curseur de pagination (next_cursor de la réponse précédente, remplace page)"
 // @Success 200 {object} map[string]interface{}
 // @Failure 401 {object} map[string]interface{}
 // @Router /listings/my [get]
@@ -391,9 +463,15 @@ func (h *ListingHandler) GetMyListings(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 
-	listings, total, err := h.listingService.GetMyListings(userID.(string), page, limit)
+	cursor := c.Query("cursor")
+
+	listings, total, nextCursor, err := h.listingService.GetMyListings(userID.(string), page, limit, cursor)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		status := http.StatusInternalServerError
+		if err == services.ErrInvalidCursor {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{
 			"error": err.Error(),
 		})
 		return
@@ -427,12 +505,13 @@ func (h *ListingHandler) GetMyListings(c *gin.Context) {
 		"data": gin.H{
 			"listings": listings,
 			"pagination": gin.H{
-				"page":     page,
-				"limit":    limit,
-				"total":    total,
-				"pages":    pages,
-				"has_next": page < pages,
-				"has_prev": page > 1,
+				"page":        page,
+				"limit":       limit,
+				"total":       total,
+				"pages":       pages,
+				"has_next":    hasNextPage(cursor, nextCursor, page, pages),
+				"has_prev":    page > 1,
+				"next_cursor": nextCursor,
 			},
 			"stats": gin.H{
 				"total_listings":  total,
@@ -488,6 +567,9 @@ func (h *ListingHandler) UpdateListing(c *gin.Context) {
 		if err == services.ErrListingNotFound {
 			status = http.StatusNotFound
 		}
+		if err == services.ErrInvalidLocation || err == services.ErrSoldStatusRequiresSale || err == services.ErrStatusNotWritable || errors.Is(err, services.ErrInvalidAttributes) {
+			status = http.StatusBadRequest
+		}
 		
 		c.JSON(status, gin.H{
 			"error": err.Error(),
@@ -543,14 +625,129 @@ func (h *ListingHandler) DeleteListing(c *gin.Context) {
 	})
 }
 
+// MarkListingSold godoc
+// @Summary Marquer une annonce comme vendue
+// @Description Retire l'annonce des fils et enregistre le résultat : vente sur SenMarket ou ailleurs, prix final et acheteur (parmi les contacts reçus)
+// @Tags listings
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID de l'annonce"
+// @Param sale body services.MarkListingSoldRequest true "Résultat de la vente"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 422 {object} map[string]interface{}
+// @Router /listings/{id}/sold [post]
+func (h *ListingHandler) MarkListingSold(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	listingID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID annonce invalide",
+		})
+		return
+	}
+
+	var req services.MarkListingSoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Données invalides",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Validation échouée",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	sale, err := h.listingService.MarkListingSold(userID, listingID, &req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch err {
+		case services.ErrListingNotFound, services.ErrBuyerContactNotFound:
+			status = http.StatusNotFound
+		case services.ErrListingAlreadySold:
+			status = http.StatusConflict
+		case services.ErrListingNotSellable, services.ErrBuyerRequiresPlatform:
+			status = http.StatusUnprocessableEntity
+		}
+
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "🤝 Annonce marquée comme vendue",
+		"data":    sale,
+	})
+}
+
+// GetSalesStats godoc
+// @Summary Statistiques des ventes
+// @Description Résultats des ventes déclarées regroupés par catégorie ou par région : part conclue sur SenMarket, prix final moyen, délai de vente
+// @Tags listings
+// @Produce json
+// @Param group_by query string false "Regroupement (category, region)" default(category)
+// @Param category_id query string false "Filtrer par catégorie (sous-catégories incluses)"
+// @Param region query string false "Filtrer par région"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /listings/sales/stats [get]
+func (h *ListingHandler) GetSalesStats(c *gin.Context) {
+	groupBy := c.DefaultQuery("group_by", services.SalesStatsGroupCategory)
+
+	categoryID := c.Query("category_id")
+	if categoryID != "" {
+		if _, err := uuid.Parse(categoryID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "ID catégorie invalide",
+			})
+			return
+		}
+	}
+
+	stats, err := h.listingService.GetSalesStats(groupBy, categoryID, c.Query("region"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err == services.ErrInvalidSalesStatsGroup {
+			status = http.StatusBadRequest
+		}
+
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"group_by": groupBy,
+		"data":     stats,
+	})
+}
+
 // SearchListings godoc
 // @Summary Rechercher des annonces
-// @Description Recherche dans les titres et descriptions des annonces
+// @Description Recherche plein texte (titre, description, catégorie), insensible aux accents, triée par pertinence avec extraits surlignés
 // @Tags listings
 // @Produce json
-// @Param q query string true "Terme de recherche"
+// @Param q query string true "Terme de recherche (syntaxe web : \"phrase exacte\", -exclure, or)"
 // @Param page query int false "Page" default(1)
 // @Param limit query int false "Limite par page" default(20)
+// @Param cursor query string false "Curseur de pagination (next_cursor de la réponse précédente, remplace page)"
 // @Success 200 {object} map[string]interface{}
 // @Router /listings/search [get]
 func (h *ListingHandler) SearchListings(c *gin.Context) {
@@ -565,9 +762,15 @@ func (h *ListingHandler) SearchListings(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 
-	listings, total, err := h.listingService.SearchListings(query, page, limit)
+	cursor := c.Query("cursor")
+
+	listings, total, nextCursor, err := h.listingService.SearchListings(query, page, limit, cursor)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		status := http.StatusInternalServerError
+		if err == services.ErrInvalidCursor {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{
 			"error": err.Error(),
 		})
 		return
@@ -581,13 +784,62 @@ func (h *ListingHandler) SearchListings(c *gin.Context) {
 			"listings": listings,
 			"search_query": query,
 			"pagination": gin.H{
-				"page":     page,
-				"limit":    limit,
-				"total":    total,
-				"pages":    pages,
-				"has_next": page < pages,
-				"has_prev": page > 1,
+				"page":        page,
+				"limit":       limit,
+				"total":       total,
+				"pages":       pages,
+				"has_next":    hasNextPage(cursor, nextCursor, page, pages),
+				"has_prev":    page > 1,
+				"next_cursor": nextCursor,
 			},
 		},
 	})
-}
\ No newline at end of file
+}
+// SuggestListings godoc
+// @Summary Suggestions de recherche
+// @Description Autocomplétion à partir des recherches populaires, des catégories et des titres d'annonces (tolérante aux fautes de frappe)
+// @Tags listings
+// @Produce json
+// @Param q query string true "Début de la recherche (2 caractères minimum)"
+// @Param limit query int false "Nombre de suggestions" default(8)
+// @Success 200 {object} map[string]interface{}
+// @Router /listings/suggest [get]
+func (h *ListingHandler) SuggestListings(c *gin.Context) {
+	query := c.Query("q")
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "8"))
+
+	suggestions, err := h.listingService.SuggestListings(query, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Erreur récupération suggestions",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"query":       query,
+			"suggestions": suggestions,
+		},
+	})
+}
+
+// attributeFilters paramètres attr.* (ex: attr.year_min=2015&attr.fuel=diesel)
+func attributeFilters(c *gin.Context) map[string]string {
+	attributes := map[string]string{}
+	for key, values := range c.Request.URL.Query() {
+		if name := strings.TrimPrefix(key, "attr."); name != key && name != "" && len(values) > 0 {
+			attributes[name] = values[0]
+		}
+	}
+	return attributes
+}
+
+// hasNextPage page suivante disponible, selon le mode de pagination (curseur ou page)
+func hasNextPage(cursor, nextCursor string, page, pages int) bool {
+	if cursor != "" {
+		return nextCursor != ""
+	}
+	return page < pages
+}
@@ -2,38 +2,48 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 
+	"senmarket/internal/models"
 	"senmarket/internal/services"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
 )
 
 type PaymentHandler struct {
 	paymentService *services.PaymentService
+	listingService *services.ListingService
 	validator      *validator.Validate
 }
 
-func NewPaymentHandler(paymentService *services.PaymentService) *PaymentHandler {
+func NewPaymentHandler(paymentService *services.PaymentService, listingService *services.ListingService) *PaymentHandler {
 	return &PaymentHandler{
 		paymentService: paymentService,
+		listingService: listingService,
 		validator:      validator.New(),
 	}
 }
 
 // InitiatePayment godoc
 // @Summary Initier un paiement
-// @Description Initie un paiement via Orange Money, Wave ou Free Money
+// @Description Initie un paiement via Orange Money ou Wave
 // @Tags payments
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param payment body services.CreatePaymentRequest true "Données du paiement"
+// @Param Idempotency-Key header string false "Clé d'idempotence (rejeu sans double paiement)"
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]interface{}
 // @Failure 401 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 422 {object} map[string]interface{}
 // @Router /payments/initiate [post]
 func (h *PaymentHandler) InitiatePayment(c *gin.Context) {
 	userID, exists := c.Get("user_id")
@@ -63,11 +73,8 @@ func (h *PaymentHandler) InitiatePayment(c *gin.Context) {
 	}
 
 	// Initier le paiement
-	payment, response, err := h.paymentService.InitiatePayment(userID.(string), &req)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+	payment, response, ok := h.initiatePayment(c, userID.(string), &req)
+	if !ok {
 		return
 	}
 
@@ -79,6 +86,57 @@ func (h *PaymentHandler) InitiatePayment(c *gin.Context) {
 	})
 }
 
+// initiatePayment initie le paiement en honorant l'en-tête Idempotency-Key
+func (h *PaymentHandler) initiatePayment(c *gin.Context, userID string, req *services.CreatePaymentRequest) (*models.Payment, *services.PaymentInitiation, bool) {
+	key := c.GetHeader(services.IdempotencyKeyHeader)
+	if key == "" {
+		payment, response, err := h.paymentService.InitiatePayment(userID, req)
+		if err != nil {
+			c.JSON(paymentInitiationErrorStatus(err), gin.H{
+				"error": err.Error(),
+			})
+			return nil, nil, false
+		}
+		return payment, response, true
+	}
+
+	payment, response, replayed, err := h.paymentService.InitiatePaymentIdempotent(userID, key, req)
+	if err != nil {
+		c.JSON(paymentInitiationErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return nil, nil, false
+	}
+
+	if replayed {
+		c.Header("Idempotent-Replayed", "true")
+	}
+
+	return payment, response, true
+}
+
+// paymentInitiationErrorStatus code HTTP d'une erreur d'initiation de paiement
+func paymentInitiationErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrIdempotencyKeyConflict), errors.Is(err, services.ErrListingNotBoostable),
+		errors.Is(err, services.ErrListingNotRenewable), errors.Is(err, services.ErrListingRenewalTooEarly),
+		errors.Is(err, services.ErrRenewalNotPayable), errors.Is(err, services.ErrListingNotPayable):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, services.ErrIdempotencyKeyInProgress):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrIdempotencyKeyInvalid), errors.Is(err, services.ErrUnknownPack),
+		errors.Is(err, services.ErrInvalidTopUpAmount), errors.Is(err, services.ErrOperationNotSupported),
+		errors.Is(err, services.ErrInvalidListingID):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrInsufficientBalance), errors.Is(err, services.ErrRenewalPaymentRequired):
+		return http.StatusPaymentRequired
+	case errors.Is(err, services.ErrListingNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 // GetPayment godoc
 // @Summary Détail d'un paiement
 // @Description Récupère les détails d'un paiement
@@ -155,36 +213,91 @@ func (h *PaymentHandler) GetMyPayments(c *gin.Context) {
 	})
 }
 
-// OrangeMoneyWebhook godoc
-// @Summary Webhook Orange Money
-// @Description Traite les notifications de paiement Orange Money
+// RefundPayment godoc
+// @Summary Rembourser un paiement
+// @Description Rembourse tout ou partie d'un paiement complété via son provider (administrateurs)
 // @Tags payments
 // @Accept json
 // @Produce json
-// @Param webhook body map[string]interface{} true "Données du webhook"
+// @Security BearerAuth
+// @Param id path string true "ID du paiement"
+// @Param refund body services.RefundPaymentRequest true "Montant (0 = total restant), motif, dépublication"
 // @Success 200 {object} map[string]interface{}
-// @Router /payments/webhook/orange-money [post]
-func (h *PaymentHandler) OrangeMoneyWebhook(c *gin.Context) {
-	var payload map[string]interface{}
-	if err := c.ShouldBindJSON(&payload); err != nil {
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 422 {object} map[string]interface{}
+// @Failure 502 {object} map[string]interface{}
+// @Router /payments/{id}/refund [post]
+func (h *PaymentHandler) RefundPayment(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Utilisateur non authentifié",
+		})
+		return
+	}
+
+	var req services.RefundPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Payload invalide",
+			"error":   "Données invalides",
+			"details": err.Error(),
 		})
 		return
 	}
 
-	if err := h.paymentService.HandleWebhook("orange_money", payload); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Validation échouée",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	refund, err := h.paymentService.RefundPayment(c.Param("id"), userID.(string), &req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, services.ErrPaymentNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, services.ErrInvalidRefundAmount):
+			status = http.StatusBadRequest
+		case errors.Is(err, services.ErrPaymentNotRefundable), errors.Is(err, services.ErrOperationNotSupported):
+			status = http.StatusUnprocessableEntity
+		case errors.Is(err, services.ErrRefundFailed):
+			status = http.StatusBadGateway
+		}
+
+		c.JSON(status, gin.H{
+			"error":  err.Error(),
+			"refund": refund,
 		})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"status": "success",
+		"message": "Remboursement effectué avec succès",
+		"data":    refund,
 	})
 }
 
+// OrangeMoneyWebhook godoc
+// @Summary Webhook Orange Money
+// @Description Traite les notifications de paiement Orange Money
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Param webhook body map[string]interface{} true "Données du webhook"
+// @Success 200 {object} map[string]interface{}
+// @Param X-Timestamp header string false "Horodatage unix de la notification"
+// @Param X-Signature header string false "hex(HMAC-SHA256(secret, timestamp + \".\" + body))"
+// @Failure 401 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /payments/webhook/orange-money [post]
+func (h *PaymentHandler) OrangeMoneyWebhook(c *gin.Context) {
+	h.handleProviderWebhook(c, "orange_money")
+}
+
 // WaveWebhook godoc
 // @Summary Webhook Wave
 // @Description Traite les notifications de paiement Wave
@@ -193,20 +306,59 @@ func (h *PaymentHandler) OrangeMoneyWebhook(c *gin.Context) {
 // @Produce json
 // @Param webhook body map[string]interface{} true "Données du webhook"
 // @Success 200 {object} map[string]interface{}
+// @Param Wave-Signature header string true "Signature HMAC Wave (t=...,v1=...)"
 // @Router /payments/webhook/wave [post]
 func (h *PaymentHandler) WaveWebhook(c *gin.Context) {
-	var payload map[string]interface{}
-	if err := c.ShouldBindJSON(&payload); err != nil {
+	h.handleProviderWebhook(c, "wave")
+}
+
+// MockWebhook godoc
+// @Summary Webhook provider mock
+// @Description Simule une notification de paiement (hors production)
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Param webhook body services.PaymentNotification true "Notification simulée"
+// @Success 200 {object} map[string]interface{}
+// @Router /payments/webhook/mock [post]
+func (h *PaymentHandler) MockWebhook(c *gin.Context) {
+	h.handleProviderWebhook(c, "mock")
+}
+
+// handleProviderWebhook transmet le corps brut et les en-têtes au provider
+func (h *PaymentHandler) handleProviderWebhook(c *gin.Context, provider string) {
+	// Le corps brut est nécessaire pour vérifier la signature
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil || len(body) == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "Payload invalide",
 		})
 		return
 	}
 
-	if err := h.paymentService.HandleWebhook("wave", payload); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+	if err := h.paymentService.HandleWebhook(provider, c.Request.Header, body, c.ClientIP()); err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidWebhookSignature):
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Signature invalide",
+			})
+		case errors.Is(err, services.ErrWebhookExpired):
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Webhook expiré",
+			})
+		case errors.Is(err, services.ErrWebhookReplay):
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "Webhook déjà traité",
+			})
+		case errors.Is(err, services.ErrPaymentNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+		}
 		return
 	}
 
@@ -215,46 +367,320 @@ func (h *PaymentHandler) WaveWebhook(c *gin.Context) {
 	})
 }
 
-// FreeMoneyWebhook godoc
-// @Summary Webhook Free Money
-// @Description Traite les notifications de paiement Free Money
+// TopUpWallet godoc
+// @Summary Recharger le portefeuille
+// @Description Rechargement du portefeuille via Orange Money ou Wave, crédité à la confirmation du paiement
+// @Tags wallet
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param topup body map[string]interface{} true "Montant, méthode de paiement et téléphone"
+// @Param Idempotency-Key header string false "Clé d'idempotence (rejeu sans double paiement)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 422 {object} map[string]interface{}
+// @Router /wallet/topup [post]
+func (h *PaymentHandler) TopUpWallet(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Utilisateur non authentifié",
+		})
+		return
+	}
+
+	var req struct {
+		Amount        float64 `json:"amount" validate:"required,min=200,max=500000"`
+		PaymentMethod string  `json:"payment_method" validate:"required,oneof=orange_money wave mock"`
+		Phone         string  `json:"phone" validate:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Données invalides",
+		})
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Validation échouée",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	paymentReq := services.CreatePaymentRequest{
+		Amount:        req.Amount,
+		Purpose:       models.PaymentPurposeWalletTopUp,
+		PaymentMethod: req.PaymentMethod,
+		Phone:         req.Phone,
+	}
+
+	payment, response, ok := h.initiatePayment(c, userID.(string), &paymentReq)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Rechargement du portefeuille initié",
+		"amount":      payment.Amount,
+		"payment":     payment,
+		"payment_url": response.PaymentURL,
+	})
+}
+
+// PurchaseListingPack godoc
+// @Summary Acheter un pack d'annonces
+// @Description Paiement d'un pack (5 ou 10 annonces) crédité à la confirmation du paiement
 // @Tags payments
 // @Accept json
 // @Produce json
-// @Param webhook body map[string]interface{} true "Données du webhook"
+// @Security BearerAuth
+// @Param pack body map[string]string true "Pack (pack_5, pack_10), méthode de paiement et téléphone"
+// @Param Idempotency-Key header string false "Clé d'idempotence (rejeu sans double paiement)"
 // @Success 200 {object} map[string]interface{}
-// @Router /payments/webhook/free-money [post]
-func (h *PaymentHandler) FreeMoneyWebhook(c *gin.Context) {
-	var payload map[string]interface{}
-	if err := c.ShouldBindJSON(&payload); err != nil {
+// @Failure 400 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 422 {object} map[string]interface{}
+// @Router /payments/packs [post]
+func (h *PaymentHandler) PurchaseListingPack(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Utilisateur non authentifié",
+		})
+		return
+	}
+
+	var req struct {
+		Pack          string `json:"pack" validate:"required,oneof=pack_5 pack_10"`
+		PaymentMethod string `json:"payment_method" validate:"required,oneof=orange_money wave mock wallet"`
+		Phone         string `json:"phone" validate:"required_unless=PaymentMethod wallet"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Payload invalide",
+			"error": "Données invalides",
 		})
 		return
 	}
 
-	if err := h.paymentService.HandleWebhook("free_money", payload); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Validation échouée",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// Le montant est fixé par la configuration du pack
+	paymentReq := services.CreatePaymentRequest{
+		Purpose:       req.Pack,
+		PaymentMethod: req.PaymentMethod,
+		Phone:         req.Phone,
+	}
+
+	payment, response, ok := h.initiatePayment(c, userID.(string), &paymentReq)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Paiement du pack initié",
+		"pack":        req.Pack,
+		"amount":      payment.Amount,
+		"payment":     payment,
+		"payment_url": response.PaymentURL,
+	})
+}
+
+// BoostListing godoc
+// @Summary Mettre en avant une annonce
+// @Description Paiement d'un boost (annonce à la une) ou d'une mise en couleur, actif pour une durée limitée
+// @Tags listings
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID de l'annonce"
+// @Param boost body map[string]string true "Option (boost, highlight), méthode de paiement et téléphone"
+// @Param Idempotency-Key header string false "Clé d'idempotence (rejeu sans double paiement)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 422 {object} map[string]interface{}
+// @Router /listings/{id}/boost [post]
+func (h *PaymentHandler) BoostListing(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Utilisateur non authentifié",
+		})
+		return
+	}
+
+	listingID := c.Param("id")
+	if _, err := uuid.Parse(listingID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID annonce invalide",
+		})
+		return
+	}
+
+	var req struct {
+		Option        string `json:"option" validate:"omitempty,oneof=boost highlight"`
+		PaymentMethod string `json:"payment_method" validate:"required,oneof=orange_money wave mock wallet"`
+		Phone         string `json:"phone" validate:"required_unless=PaymentMethod wallet"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Données invalides",
+		})
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Validation échouée",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if req.Option == "" {
+		req.Option = models.PaymentPurposeBoost
+	}
+
+	// Le montant est fixé par la configuration (PremiumBoostPrice, FeaturedColorPrice)
+	paymentReq := services.CreatePaymentRequest{
+		ListingID:     listingID,
+		Purpose:       req.Option,
+		PaymentMethod: req.PaymentMethod,
+		Phone:         req.Phone,
+	}
+
+	payment, response, ok := h.initiatePayment(c, userID.(string), &paymentReq)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Paiement de la mise en avant initié",
+		"option":      req.Option,
+		"amount":      payment.Amount,
+		"payment":     payment,
+		"payment_url": response.PaymentURL,
+	})
+}
+
+// RenewListing godoc
+// @Summary Renouveler une annonce
+// @Description Prolonge l'annonce de 30 jours : gratuit en phase de lancement et de crédits, payant (prix standard) en phase payante. Ouvert 7 jours avant l'expiration ou après.
+// @Tags listings
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID de l'annonce"
+// @Param payment body map[string]string false "Méthode de paiement (phase payante uniquement)"
+// @Param Idempotency-Key header string false "Clé d'idempotence (rejeu sans double paiement)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 402 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 422 {object} map[string]interface{}
+// @Router /listings/{id}/renew [post]
+func (h *PaymentHandler) RenewListing(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	listingID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID annonce invalide",
+		})
+		return
+	}
+
+	quote, err := h.listingService.GetRenewalQuote(userID, listingID)
+	if err != nil {
+		c.JSON(paymentInitiationErrorStatus(err), gin.H{
 			"error": err.Error(),
 		})
 		return
 	}
 
+	// Phase gratuite : renouvellement immédiat
+	if quote.Free {
+		listing, err := h.listingService.RenewListing(userID, listingID)
+		if err != nil {
+			c.JSON(paymentInitiationErrorStatus(err), gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "🔄 Annonce renouvelée gratuitement",
+			"renewal": quote,
+			"data":    listing,
+		})
+		return
+	}
+
+	var req struct {
+		PaymentMethod string `json:"payment_method" validate:"required,oneof=orange_money wave mock wallet"`
+		Phone         string `json:"phone" validate:"required_unless=PaymentMethod wallet"`
+	}
+	_ = c.ShouldBindJSON(&req)
+
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusPaymentRequired, gin.H{
+			"error":   services.ErrRenewalPaymentRequired.Error(),
+			"details": err.Error(),
+			"renewal": quote,
+		})
+		return
+	}
+
+	// Le montant est fixé par la configuration (StandardListingPrice)
+	paymentReq := services.CreatePaymentRequest{
+		ListingID:     listingID.String(),
+		Purpose:       models.PaymentPurposeRenewal,
+		PaymentMethod: req.PaymentMethod,
+		Phone:         req.Phone,
+	}
+
+	payment, response, ok := h.initiatePayment(c, userID.String(), &paymentReq)
+	if !ok {
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"status": "success",
+		"message":     "Paiement du renouvellement initié",
+		"renewal":     quote,
+		"amount":      payment.Amount,
+		"payment":     payment,
+		"payment_url": response.PaymentURL,
 	})
 }
 
 // PayForListing godoc
 // @Summary Payer pour publier une annonce
-// @Description Paiement de 200 FCFA pour publier une annonce
+// @Description Paiement du prix standard (configuration) pour publier une annonce
 // @Tags payments
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "ID de l'annonce"
 // @Param payment body map[string]string true "Méthode de paiement"
+// @Param Idempotency-Key header string false "Clé d'idempotence (rejeu sans double paiement)"
 // @Success 200 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 422 {object} map[string]interface{}
 // @Router /listings/{id}/pay [post]
 func (h *PaymentHandler) PayForListing(c *gin.Context) {
 	userID, exists := c.Get("user_id")
@@ -268,8 +694,8 @@ func (h *PaymentHandler) PayForListing(c *gin.Context) {
 	listingID := c.Param("id")
 	
 	var req struct {
-		PaymentMethod string `json:"payment_method" validate:"required,oneof=orange_money wave free_money"`
-		Phone         string `json:"phone" validate:"required"`
+		PaymentMethod string `json:"payment_method" validate:"required,oneof=orange_money wave mock wallet"`
+		Phone         string `json:"phone" validate:"required_unless=PaymentMethod wallet"`
 	}
 	
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -287,25 +713,22 @@ func (h *PaymentHandler) PayForListing(c *gin.Context) {
 		return
 	}
 
-	// Créer la requête de paiement
+	// Le montant est fixé par la configuration (StandardListingPrice)
 	paymentReq := services.CreatePaymentRequest{
 		ListingID:     listingID,
-		Amount:        200.00, // Prix fixe pour publier
+		Purpose:       models.PaymentPurposeListing,
 		PaymentMethod: req.PaymentMethod,
 		Phone:         req.Phone,
 	}
 
-	payment, response, err := h.paymentService.InitiatePayment(userID.(string), &paymentReq)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+	payment, response, ok := h.initiatePayment(c, userID.(string), &paymentReq)
+	if !ok {
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Paiement initié pour publier l'annonce",
-		"amount": "200 FCFA",
+		"amount": fmt.Sprintf("%.0f FCFA", payment.Amount),
 		"payment": payment,
 		"payment_url": response.PaymentURL,
 	})
@@ -232,7 +232,7 @@ func (h *QuotaHandler) GetQuotaHistory(c *gin.Context) {
 
 // GetPlatformStats godoc
 // @Summary Statistiques plateforme
-// @Description Récupère les statistiques globales de la plateforme (quotas, utilisateurs, revenus)
+// @Description Récupère les statistiques globales de la plateforme (quotas, utilisateurs, revenus) - endpoint admin
 // @Tags quota
 // @Produce json
 // @Security BearerAuth
@@ -241,9 +241,6 @@ func (h *QuotaHandler) GetQuotaHistory(c *gin.Context) {
 // @Failure 500 {object} map[string]interface{}
 // @Router /quota/platform-stats [get]
 func (h *QuotaHandler) GetPlatformStats(c *gin.Context) {
-	// Note: Dans une vraie application, vous voudriez probablement 
-	// restreindre cet endpoint aux administrateurs seulement
-	
 	stats, err := h.quotaService.GetPlatformStats()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -327,10 +324,11 @@ func (h *QuotaHandler) GetCurrentPhase(c *gin.Context) {
 
 // UpdateUserPhase godoc
 // @Summary Mettre à jour phase utilisateur
-// @Description Fait passer un utilisateur à la phase suivante (usage interne/admin)
+// @Description Fait passer un utilisateur (par défaut l'administrateur connecté) à la phase suivante - endpoint admin
 // @Tags quota
 // @Produce json
 // @Security BearerAuth
+// @Param user_id query string false "ID de l'utilisateur ciblé"
 // @Success 200 {object} map[string]interface{}
 // @Failure 401 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
@@ -344,7 +342,12 @@ func (h *QuotaHandler) UpdateUserPhase(c *gin.Context) {
 		return
 	}
 	
-	userUUID, err := uuid.Parse(userID.(string))
+	target := userID.(string)
+	if targetID := c.Query("user_id"); targetID != "" {
+		target = targetID
+	}
+
+	userUUID, err := uuid.Parse(target)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "ID utilisateur invalide",
@@ -403,6 +406,72 @@ func (h *QuotaHandler) GetPricingInfo(c *gin.Context) {
 	})
 }
 
+// GetListingPacks godoc
+// @Summary Packs d'annonces
+// @Description Liste les packs d'annonces prépayées et leurs prix actuels
+// @Tags quota
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /quota/packs [get]
+func (h *QuotaHandler) GetListingPacks(c *gin.Context) {
+	packs, err := h.quotaService.GetListingPacks()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Erreur récupération packs",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    packs,
+	})
+}
+
+// GetListingCredits godoc
+// @Summary Crédits d'annonces
+// @Description Solde, lots actifs (avec expiration) et historique des crédits de packs
+// @Tags quota
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /quota/credits [get]
+func (h *QuotaHandler) GetListingCredits(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Utilisateur non authentifié",
+		})
+		return
+	}
+
+	userUUID, err := uuid.Parse(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID utilisateur invalide",
+		})
+		return
+	}
+
+	credits, err := h.quotaService.GetListingCredits(userUUID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Erreur récupération crédits",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    credits,
+	})
+}
+
 // CleanupQuotas godoc
 // @Summary Nettoyer anciens quotas
 // @Description Supprime les quotas anciens (plus de 12 mois) - endpoint admin
@@ -414,9 +483,6 @@ func (h *QuotaHandler) GetPricingInfo(c *gin.Context) {
 // @Failure 500 {object} map[string]interface{}
 // @Router /quota/cleanup [post]
 func (h *QuotaHandler) CleanupQuotas(c *gin.Context) {
-	// Note: Dans une vraie application, cet endpoint devrait être 
-	// restreint aux administrateurs et appelé par un cron job
-	
 	if err := h.quotaService.CleanupOldQuotas(); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Erreur nettoyage quotas",
@@ -0,0 +1,70 @@
+// internal/handlers/reconciliation_handler.go
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"senmarket/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReconciliationHandler struct {
+	reconciler *services.PaymentReconciler
+}
+
+func NewReconciliationHandler(reconciler *services.PaymentReconciler) *ReconciliationHandler {
+	return &ReconciliationHandler{
+		reconciler: reconciler,
+	}
+}
+
+// GetReports godoc
+// @Summary Rapports de réconciliation
+// @Description Derniers rapports du job de réconciliation des paiements en attente - endpoint admin
+// @Tags payments
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Nombre de rapports" default(5)
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /payments/reconciliation/reports [get]
+func (h *ReconciliationHandler) GetReports(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "5"))
+	if limit <= 0 {
+		limit = 5
+	}
+
+	reports := h.reconciler.Reports()
+	if len(reports) > limit {
+		reports = reports[:limit]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"reports": reports,
+			"total":   len(reports),
+		},
+	})
+}
+
+// RunReconciliation godoc
+// @Summary Lancer une réconciliation
+// @Description Interroge immédiatement les providers pour les paiements en attente - endpoint admin
+// @Tags payments
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} services.ReconciliationReport
+// @Failure 401 {object} map[string]interface{}
+// @Router /payments/reconciliation/run [post]
+func (h *ReconciliationHandler) RunReconciliation(c *gin.Context) {
+	report := h.reconciler.Run("manual")
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Réconciliation effectuée",
+		"data":    report,
+	})
+}
@@ -0,0 +1,393 @@
+// internal/handlers/saved_search_handler.go
+package handlers
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"strconv"
+
+	"senmarket/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type SavedSearchHandler struct {
+	savedSearchService *services.SavedSearchService
+	validator          *validator.Validate
+}
+
+func NewSavedSearchHandler(savedSearchService *services.SavedSearchService) *SavedSearchHandler {
+	return &SavedSearchHandler{
+		savedSearchService: savedSearchService,
+		validator:          validator.New(),
+	}
+}
+
+// CreateSavedSearch godoc
+// @Summary Sauvegarder une recherche
+// @Description Enregistre un jeu de filtres (catégorie, région, prix, mots-clés) avec alerte SMS/WhatsApp instantanée ou quotidienne
+// @Tags saved-searches
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param search body services.SavedSearchRequest true "Critères et préférences d'alerte"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /saved-searches [post]
+func (h *SavedSearchHandler) CreateSavedSearch(c *gin.Context) {
+	userUUID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	req, ok := h.bindRequest(c)
+	if !ok {
+		return
+	}
+
+	search, err := h.savedSearchService.CreateSavedSearch(userUUID, req)
+	if err != nil {
+		c.JSON(savedSearchErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Recherche sauvegardée",
+		"data":    search,
+	})
+}
+
+// GetSavedSearches godoc
+// @Summary Mes recherches sauvegardées
+// @Tags saved-searches
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /saved-searches [get]
+func (h *SavedSearchHandler) GetSavedSearches(c *gin.Context) {
+	userUUID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	searches, err := h.savedSearchService.GetSavedSearches(userUUID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": searches,
+	})
+}
+
+// UpdateSavedSearch godoc
+// @Summary Modifier une recherche sauvegardée
+// @Description Remplace les critères, le canal, la fréquence ou l'état de l'alerte
+// @Tags saved-searches
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID de la recherche"
+// @Param search body services.SavedSearchRequest true "Critères et préférences d'alerte"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /saved-searches/{id} [put]
+func (h *SavedSearchHandler) UpdateSavedSearch(c *gin.Context) {
+	userUUID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	searchID, ok := savedSearchID(c)
+	if !ok {
+		return
+	}
+
+	req, ok := h.bindRequest(c)
+	if !ok {
+		return
+	}
+
+	search, err := h.savedSearchService.UpdateSavedSearch(userUUID, searchID, req)
+	if err != nil {
+		c.JSON(savedSearchErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    search,
+	})
+}
+
+// DeleteSavedSearch godoc
+// @Summary Supprimer une recherche sauvegardée
+// @Tags saved-searches
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID de la recherche"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /saved-searches/{id} [delete]
+func (h *SavedSearchHandler) DeleteSavedSearch(c *gin.Context) {
+	userUUID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	searchID, ok := savedSearchID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.savedSearchService.DeleteSavedSearch(userUUID, searchID); err != nil {
+		c.JSON(savedSearchErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Recherche sauvegardée supprimée",
+	})
+}
+
+// GetSavedSearchListings godoc
+// @Summary Annonces d'une recherche sauvegardée
+// @Description Exécute la recherche sauvegardée (mêmes filtres que GET /listings)
+// @Tags saved-searches
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID de la recherche"
+// @Param page query int false "Page" default(1)
+// @Param limit query int false "Limite par page" default(20)
+// @Param cursor query string false "Curseur de pagination"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /saved-searches/{id}/listings [get]
+func (h *SavedSearchHandler) GetSavedSearchListings(c *gin.Context) {
+	userUUID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	searchID, ok := savedSearchID(c)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 || limit > 50 {
+		limit = 20
+	}
+	cursor := c.Query("cursor")
+
+	listings, total, nextCursor, err := h.savedSearchService.GetMatchingListings(userUUID, searchID, page, limit, cursor)
+	if err != nil {
+		c.JSON(savedSearchErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	pages := int((total + int64(limit) - 1) / int64(limit))
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"listings": listings,
+			"pagination": gin.H{
+				"page":        page,
+				"limit":       limit,
+				"total":       total,
+				"pages":       pages,
+				"has_next":    hasNextPage(cursor, nextCursor, page, pages),
+				"has_prev":    page > 1,
+				"next_cursor": nextCursor,
+			},
+		},
+	})
+}
+
+// ConfirmUnsubscribe godoc
+// @Summary Page de confirmation du désabonnement
+// @Description Lien envoyé dans chaque alerte. Sans effet : les aperçus de liens (SMS, WhatsApp) suivent les GET ; le désabonnement se fait par POST
+// @Tags saved-searches
+// @Produce html
+// @Param token path string true "Jeton de désabonnement"
+// @Param all query bool false "Désactiver toutes les alertes"
+// @Success 200 {string} string "Page de confirmation"
+// @Failure 404 {string} string "Lien invalide"
+// @Router /saved-searches/unsubscribe/{token} [get]
+func (h *SavedSearchHandler) ConfirmUnsubscribe(c *gin.Context) {
+	all, _ := strconv.ParseBool(c.Query("all"))
+
+	search, err := h.savedSearchService.GetByUnsubscribeToken(c.Param("token"))
+	if err != nil {
+		renderUnsubscribePage(c, savedSearchErrorStatus(err), unsubscribePage{Error: unsubscribeErrorMessage(err)})
+		return
+	}
+
+	renderUnsubscribePage(c, http.StatusOK, unsubscribePage{
+		Name:   search.Name,
+		All:    all,
+		Action: c.Request.URL.RequestURI(),
+	})
+}
+
+// Unsubscribe godoc
+// @Summary Se désabonner d'une alerte
+// @Description Soumission de la page de confirmation : désactive la recherche (ou toutes les alertes de l'utilisateur avec all=true)
+// @Tags saved-searches
+// @Accept x-www-form-urlencoded
+// @Produce json,html
+// @Param token path string true "Jeton de désabonnement"
+// @Param all query bool false "Désactiver toutes les alertes"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /saved-searches/unsubscribe/{token} [post]
+func (h *SavedSearchHandler) Unsubscribe(c *gin.Context) {
+	all, _ := strconv.ParseBool(c.Query("all"))
+	if formAll, _ := strconv.ParseBool(c.PostForm("all")); formAll {
+		all = true
+	}
+
+	count, err := h.savedSearchService.Unsubscribe(c.Param("token"), all)
+
+	// Formulaire soumis depuis un navigateur : réponse HTML
+	if c.NegotiateFormat(gin.MIMEJSON, gin.MIMEHTML) == gin.MIMEHTML {
+		if err != nil {
+			renderUnsubscribePage(c, savedSearchErrorStatus(err), unsubscribePage{Error: unsubscribeErrorMessage(err)})
+			return
+		}
+		renderUnsubscribePage(c, http.StatusOK, unsubscribePage{Done: true, All: all})
+		return
+	}
+
+	if err != nil {
+		c.JSON(savedSearchErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"message":     "Vous ne recevrez plus ces alertes",
+		"deactivated": count,
+	})
+}
+
+// unsubscribePage données de la page de désabonnement
+type unsubscribePage struct {
+	Name   string
+	All    bool
+	Action string
+	Done   bool
+	Error  string
+}
+
+var unsubscribeTemplate = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html lang="fr">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>SenMarket - Alertes</title>
+</head>
+<body>
+{{if .Error}}
+<p>{{.Error}}</p>
+{{else if .Done}}
+<p>{{if .All}}Vous ne recevrez plus aucune alerte SenMarket.{{else}}Vous ne recevrez plus cette alerte.{{end}}</p>
+{{else}}
+<p>{{if .All}}Désactiver toutes vos alertes SenMarket ?{{else}}Désactiver l'alerte « {{.Name}} » ?{{end}}</p>
+<form method="post" action="{{.Action}}">
+<button type="submit">Me désabonner</button>
+</form>
+{{end}}
+</body>
+</html>
+`))
+
+// renderUnsubscribePage affiche la page de désabonnement
+func renderUnsubscribePage(c *gin.Context, status int, page unsubscribePage) {
+	var body bytes.Buffer
+	if err := unsubscribeTemplate.Execute(&body, page); err != nil {
+		c.String(http.StatusInternalServerError, "Erreur interne")
+		return
+	}
+	c.Data(status, "text/html; charset=utf-8", body.Bytes())
+}
+
+// unsubscribeErrorMessage message affiché sur la page de désabonnement
+func unsubscribeErrorMessage(err error) string {
+	if err == services.ErrSavedSearchNotFound {
+		return "Ce lien de désabonnement n'est pas valide."
+	}
+	return "Une erreur est survenue, réessayez plus tard."
+}
+
+// bindRequest décode et valide le corps de la requête
+func (h *SavedSearchHandler) bindRequest(c *gin.Context) (*services.SavedSearchRequest, bool) {
+	var req services.SavedSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Données invalides",
+			"details": err.Error(),
+		})
+		return nil, false
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Validation échouée",
+			"details": err.Error(),
+		})
+		return nil, false
+	}
+	return &req, true
+}
+
+func savedSearchID(c *gin.Context) (uuid.UUID, bool) {
+	searchID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID de recherche invalide",
+		})
+		return uuid.Nil, false
+	}
+	return searchID, true
+}
+
+// savedSearchErrorStatus code HTTP associé à une erreur de recherche sauvegardée
+func savedSearchErrorStatus(err error) int {
+	switch err {
+	case services.ErrSavedSearchNotFound:
+		return http.StatusNotFound
+	case services.ErrSavedSearchLimit:
+		return http.StatusConflict
+	case services.ErrSavedSearchEmpty, services.ErrInvalidPriceRange, services.ErrInvalidRegion,
+		services.ErrCategoryNotFound, services.ErrInvalidCursor:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,224 @@
+// internal/handlers/wallet_handler.go
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"senmarket/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const walletStatementDateLayout = "2006-01-02"
+
+type WalletHandler struct {
+	walletService *services.WalletService
+}
+
+func NewWalletHandler(walletService *services.WalletService) *WalletHandler {
+	return &WalletHandler{
+		walletService: walletService,
+	}
+}
+
+// GetWallet godoc
+// @Summary Solde du portefeuille
+// @Description Récupère le compte portefeuille et le solde de l'utilisateur connecté
+// @Tags wallet
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /wallet [get]
+func (h *WalletHandler) GetWallet(c *gin.Context) {
+	userUUID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	account, err := h.walletService.GetAccount(userUUID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"balance":  account.Balance,
+			"currency": account.Currency,
+			"account":  account,
+		},
+	})
+}
+
+// GetTransactions godoc
+// @Summary Historique du portefeuille
+// @Description Liste paginée des mouvements du portefeuille (plus récent en premier)
+// @Tags wallet
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Numéro de page" default(1)
+// @Param limit query int false "Éléments par page" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Router /wallet/transactions [get]
+func (h *WalletHandler) GetTransactions(c *gin.Context) {
+	userUUID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	lines, total, err := h.walletService.GetTransactions(userUUID, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"transactions": lines,
+			"total":        total,
+			"page":         page,
+			"limit":        limit,
+			"pages":        int((total + int64(limit) - 1) / int64(limit)),
+		},
+	})
+}
+
+// ExportStatement godoc
+// @Summary Relevé du portefeuille
+// @Description Relevé sur une période (par défaut le mois en cours), en JSON ou CSV
+// @Tags wallet
+// @Produce json
+// @Produce text/csv
+// @Security BearerAuth
+// @Param from query string false "Début (YYYY-MM-DD, inclus)"
+// @Param to query string false "Fin (YYYY-MM-DD, incluse)"
+// @Param format query string false "json ou csv" default(json)
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /wallet/statement [get]
+func (h *WalletHandler) ExportStatement(c *gin.Context) {
+	userUUID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	to := from.AddDate(0, 1, 0)
+
+	if value := c.Query("from"); value != "" {
+		parsed, err := time.ParseInLocation(walletStatementDateLayout, value, now.Location())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Date de début invalide (format YYYY-MM-DD)",
+			})
+			return
+		}
+		from = parsed
+	}
+	if value := c.Query("to"); value != "" {
+		parsed, err := time.ParseInLocation(walletStatementDateLayout, value, now.Location())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Date de fin invalide (format YYYY-MM-DD)",
+			})
+			return
+		}
+		to = parsed.AddDate(0, 0, 1) // Date de fin incluse
+	}
+	if !to.After(from) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "La date de fin doit être postérieure à la date de début",
+		})
+		return
+	}
+
+	statement, err := h.walletService.GetStatement(userUUID, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if c.DefaultQuery("format", "json") != "csv" {
+		c.JSON(http.StatusOK, gin.H{
+			"data": statement,
+		})
+		return
+	}
+
+	filename := fmt.Sprintf("releve-portefeuille-%s-%s.csv",
+		from.Format(walletStatementDateLayout), to.AddDate(0, 0, -1).Format(walletStatementDateLayout))
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Status(http.StatusOK)
+
+	writer := csv.NewWriter(c.Writer)
+	writer.Write([]string{"date", "type", "objet", "libellé", "débit", "crédit", "solde"})
+	writer.Write([]string{from.Format(walletStatementDateLayout), "ouverture", "", "Solde d'ouverture", "", "", formatWalletAmount(statement.OpeningBalance)})
+	for _, line := range statement.Lines {
+		debit, credit := "", ""
+		if line.Amount < 0 {
+			debit = formatWalletAmount(-line.Amount)
+		} else {
+			credit = formatWalletAmount(line.Amount)
+		}
+		writer.Write([]string{
+			line.Date.Format(time.RFC3339),
+			line.Type,
+			line.Purpose,
+			line.Description,
+			debit,
+			credit,
+			formatWalletAmount(line.BalanceAfter),
+		})
+	}
+	writer.Write([]string{"", "clôture", "", "Totaux et solde de clôture",
+		formatWalletAmount(statement.TotalDebits), formatWalletAmount(statement.TotalCredits), formatWalletAmount(statement.ClosingBalance)})
+	writer.Flush()
+}
+
+// currentUserID extrait l'utilisateur connecté
+func currentUserID(c *gin.Context) (uuid.UUID, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Utilisateur non authentifié",
+		})
+		return uuid.Nil, false
+	}
+
+	userUUID, err := uuid.Parse(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID utilisateur invalide",
+		})
+		return uuid.Nil, false
+	}
+	return userUUID, true
+}
+
+// formatWalletAmount montant en FCFA sans décimales
+func formatWalletAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 0, 64)
+}
@@ -3,10 +3,9 @@ package external
 
 import (
 	"context"
-	"fmt"
-	
-	"senmarket/internal/services" // ⭐ Ton service Payment existant
+
 	"senmarket/internal/models"   // ⭐ Tes modèles GORM existants
+	"senmarket/internal/services" // ⭐ Ton service Payment existant
 )
 
 // PaymentGatewayAdapter - Adapteur pour connecter ton service Payment existant
@@ -21,38 +20,36 @@ func NewPaymentGatewayAdapter(paymentService *services.PaymentService) *PaymentG
 	}
 }
 
-// CreatePayment - Créer un paiement (utilise tes vrais types)
-func (a *PaymentGatewayAdapter) CreatePayment(ctx context.Context, request *services.CreatePaymentRequest) (*models.Payment, error) {
-	// ✅ CORRIGÉ: Utilise models.Payment qui existe dans tes modèles GORM
-	// Pour l'instant, on simule car on ne connait pas la méthode exacte de ton PaymentService
-	// Tu peux adapter selon tes vraies méthodes PaymentService
-	
-	return nil, fmt.Errorf("méthode CreatePayment à implémenter selon ton PaymentService existant")
+// CreatePayment - Créer un paiement via le provider correspondant à PaymentMethod
+func (a *PaymentGatewayAdapter) CreatePayment(ctx context.Context, userID string, request *services.CreatePaymentRequest) (*models.Payment, *services.PaymentInitiation, error) {
+	return a.paymentService.InitiatePayment(userID, request)
 }
 
-// ProcessOrangeMoneyPayment - Traiter un paiement Orange Money (méthode helper)
-func (a *PaymentGatewayAdapter) ProcessOrangeMoneyPayment(ctx context.Context, amount float64, phone string) error {
-	// Ici tu peux utiliser les vraies méthodes de ton PaymentService
-	// pour Orange Money, Wave, etc.
-	
-	return fmt.Errorf("méthode Orange Money à implémenter selon ton PaymentService existant")
+// ProcessOrangeMoneyPayment - Initier un paiement Orange Money (méthode helper)
+func (a *PaymentGatewayAdapter) ProcessOrangeMoneyPayment(ctx context.Context, userID string, amount float64, phone string) (*services.PaymentInitiation, error) {
+	_, response, err := a.paymentService.InitiatePayment(userID, &services.CreatePaymentRequest{
+		Amount:        amount,
+		PaymentMethod: "orange_money",
+		Phone:         phone,
+	})
+	return response, err
 }
 
-// GetPaymentStatus - Récupérer le statut d'un paiement (méthode helper)
+// GetPaymentStatus - Récupérer le statut d'un paiement, synchronisé avec le provider
 func (a *PaymentGatewayAdapter) GetPaymentStatus(ctx context.Context, paymentID string) (string, error) {
-	// Utilise les vraies méthodes de ton PaymentService
-	
-	return "pending", nil // Placeholder
+	payment, err := a.paymentService.QueryPaymentStatus(paymentID)
+	if err != nil {
+		return "", err
+	}
+	return payment.Status, nil
 }
 
 // IsConfigured - Vérifier si le service de paiement est configuré
 func (a *PaymentGatewayAdapter) IsConfigured() bool {
-	// Vérifier que ton PaymentService est bien configuré
-	return a.paymentService != nil
+	return a.paymentService != nil && len(a.paymentService.SupportedMethods()) > 0
 }
 
 // GetSupportedMethods - Récupérer les méthodes de paiement supportées
 func (a *PaymentGatewayAdapter) GetSupportedMethods() []string {
-	// Retourne les méthodes de paiement que ton service supporte
-	return []string{"orange_money", "wave", "free_money", "card"}
+	return a.paymentService.SupportedMethods()
 }
@@ -0,0 +1,39 @@
+// internal/models/auth_security_event.go
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Types d'événements de sécurité
+const (
+	AuthEventLockout    = "lockout"
+	AuthEventCodeBurned = "code_burned"
+)
+
+// AuthSecurityEvent verrouillage ou code invalidé après trop de tentatives, conservé pour audit
+type AuthSecurityEvent struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	EventType   string     `json:"event_type" gorm:"not null"`
+	Action      string     `json:"action" gorm:"not null"` // login, code
+	Scope       string     `json:"scope,omitempty"`        // phone, ip
+	Phone       string     `json:"phone,omitempty" gorm:"index"`
+	IPAddress   string     `json:"ip_address,omitempty" gorm:"index"`
+	Failures    int64      `json:"failures"`
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+func (e *AuthSecurityEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+func (AuthSecurityEvent) TableName() string {
+	return "auth_security_events"
+}
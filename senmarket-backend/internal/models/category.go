@@ -19,9 +19,16 @@ type Category struct {
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Version courante du schéma d'attributs (0 = pas d'attributs)
+	AttributeSchemaVersion int `json:"attribute_schema_version" gorm:"default:0"`
+
+	// Catégorie parente (nil = catégorie racine)
+	ParentID *uuid.UUID `json:"parent_id" gorm:"type:uuid;index"`
 	
 	// Relations
-	Listings []Listing `json:"listings,omitempty" gorm:"foreignKey:CategoryID"`
+	Listings []Listing  `json:"listings,omitempty" gorm:"foreignKey:CategoryID"`
+	Children []Category `json:"children,omitempty" gorm:"foreignKey:ParentID"`
 }
 
 // BeforeCreate hook
@@ -0,0 +1,109 @@
+// internal/models/category_attribute.go
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	AttributeTypeString  = "string"
+	AttributeTypeNumber  = "number"
+	AttributeTypeInteger = "integer"
+	AttributeTypeBoolean = "boolean"
+	AttributeTypeEnum    = "enum"
+)
+
+// AttributeField définition d'un attribut dans le schéma d'une catégorie
+type AttributeField struct {
+	Key        string   `json:"key"`
+	Label      string   `json:"label"`
+	Type       string   `json:"type"`
+	Required   bool     `json:"required,omitempty"`
+	Options    []string `json:"options,omitempty"` // Valeurs autorisées (type enum)
+	Min        *float64 `json:"min,omitempty"`
+	Max        *float64 `json:"max,omitempty"`
+	Unit       string   `json:"unit,omitempty"`
+	Filterable bool     `json:"filterable,omitempty"`
+}
+
+// AttributeFields liste ordonnée des attributs, stockée en JSONB
+type AttributeFields []AttributeField
+
+func (f AttributeFields) Value() (driver.Value, error) {
+	if f == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(f)
+	return string(data), err
+}
+
+func (f *AttributeFields) Scan(value interface{}) error {
+	return scanJSONB(value, f)
+}
+
+// ListingAttributes valeurs des attributs d'une annonce, stockées en JSONB
+type ListingAttributes map[string]interface{}
+
+func (a ListingAttributes) Value() (driver.Value, error) {
+	if a == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(a)
+	return string(data), err
+}
+
+func (a *ListingAttributes) Scan(value interface{}) error {
+	return scanJSONB(value, a)
+}
+
+// CategoryAttributeSchema version figée du schéma d'attributs d'une catégorie.
+// Une modification crée une nouvelle version ; les annonces gardent la version utilisée à leur validation.
+type CategoryAttributeSchema struct {
+	ID         uuid.UUID       `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	CategoryID uuid.UUID       `json:"category_id" gorm:"type:uuid;not null;uniqueIndex:idx_category_schema_version"`
+	Version    int             `json:"version" gorm:"not null;uniqueIndex:idx_category_schema_version"`
+	Fields     AttributeFields `json:"fields" gorm:"type:jsonb;not null"`
+	CreatedBy  *uuid.UUID      `json:"created_by,omitempty" gorm:"type:uuid"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+func (s *CategoryAttributeSchema) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+func (CategoryAttributeSchema) TableName() string {
+	return "category_attribute_schemas"
+}
+
+// Field retourne la définition d'un attribut par sa clé
+func (s *CategoryAttributeSchema) Field(key string) (*AttributeField, bool) {
+	for i := range s.Fields {
+		if s.Fields[i].Key == key {
+			return &s.Fields[i], true
+		}
+	}
+	return nil, false
+}
+
+// scanJSONB décode une colonne JSONB
+func scanJSONB(value interface{}, dest interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, dest)
+	case string:
+		return json.Unmarshal([]byte(v), dest)
+	default:
+		return errors.New("type JSONB non supporté")
+	}
+}
@@ -0,0 +1,32 @@
+// internal/models/idempotency_key.go
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PaymentIdempotencyKey clé Idempotency-Key associée à une initiation de paiement
+type PaymentIdempotencyKey struct {
+	ID             uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID         uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_idempotency_user_key"`
+	IdempotencyKey string     `json:"idempotency_key" gorm:"not null;uniqueIndex:idx_idempotency_user_key"`
+	RequestHash    string     `json:"request_hash" gorm:"not null"`
+	PaymentID      *uuid.UUID `json:"payment_id" gorm:"type:uuid"` // NULL tant que l'initiation est en cours
+	Response       string     `json:"response" gorm:"type:text"`   // JSON de la réponse provider
+	CreatedAt      time.Time  `json:"created_at"`
+	ExpiresAt      time.Time  `json:"expires_at" gorm:"not null;index"`
+}
+
+func (k *PaymentIdempotencyKey) BeforeCreate(tx *gorm.DB) error {
+	if k.ID == uuid.Nil {
+		k.ID = uuid.New()
+	}
+	return nil
+}
+
+func (PaymentIdempotencyKey) TableName() string {
+	return "payment_idempotency_keys"
+}
@@ -8,23 +8,40 @@ import (
 	"gorm.io/gorm"
 )
 
+// ListingDurationDays durée de publication d'une annonce (création ou renouvellement)
+const ListingDurationDays = 30
+
 type Listing struct {
-	ID          uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	UserID      uuid.UUID      `json:"user_id" gorm:"type:uuid;not null;index"`
-	CategoryID  uuid.UUID      `json:"category_id" gorm:"type:uuid;not null;index"`
-	Title       string         `json:"title" gorm:"not null" validate:"required,min=5,max=200"`
-	Description string         `json:"description" gorm:"type:text;not null" validate:"required,min=20"`
-	Price       float64        `json:"price" gorm:"type:decimal(12,2);not null" validate:"required,min=0"`
-	Currency    string         `json:"currency" gorm:"default:'XOF'"`
-	Region      string         `json:"region" gorm:"not null" validate:"required"`
-	Images      pq.StringArray `json:"images" gorm:"type:text[]"`
-	Status      string         `json:"status" gorm:"default:'draft'" validate:"oneof=draft active sold expired"`
-	ViewsCount  int            `json:"views_count" gorm:"default:0"`
-	IsFeatured  bool           `json:"is_featured" gorm:"default:false"`
-	ExpiresAt   *time.Time     `json:"expires_at"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+	ID               uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID           uuid.UUID      `json:"user_id" gorm:"type:uuid;not null;index"`
+	CategoryID       uuid.UUID      `json:"category_id" gorm:"type:uuid;not null;index"`
+	Title            string         `json:"title" gorm:"not null" validate:"required,min=5,max=200"`
+	Description      string         `json:"description" gorm:"type:text;not null" validate:"required,min=20"`
+	Price            float64        `json:"price" gorm:"type:decimal(12,2);not null" validate:"required,min=0"`
+	Currency         string         `json:"currency" gorm:"default:'XOF'"`
+	Region           string         `json:"region" gorm:"not null" validate:"required"`
+	Commune          string         `json:"commune"`
+	Latitude         *float64       `json:"latitude"`
+	Longitude        *float64       `json:"longitude"`
+	DistanceKm       *float64       `json:"distance_km,omitempty" gorm:"-:all"` // Renseigné pour une recherche à proximité
+	Images           pq.StringArray `json:"images" gorm:"type:text[]"`
+	Status           string         `json:"status" gorm:"default:'draft'" validate:"oneof=draft active sold expired"`
+	ViewsCount       int            `json:"views_count" gorm:"default:0"`
+	IsFeatured       bool           `json:"is_featured" gorm:"default:false"`
+	BoostedUntil     *time.Time     `json:"boosted_until"`
+	IsHighlighted    bool           `json:"is_highlighted" gorm:"default:false"`
+	HighlightedUntil *time.Time     `json:"highlighted_until"`
+	ExpiresAt        *time.Time     `json:"expires_at"`
+	ExpiryWarnedAt   *time.Time     `json:"-"`
+	RenewalCount     int            `json:"renewal_count" gorm:"default:0"`
+	SoldAt           *time.Time     `json:"sold_at"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Attributs spécifiques à la catégorie, validés contre la version de schéma indiquée
+	Attributes             ListingAttributes `json:"attributes" gorm:"type:jsonb;default:'{}'"`
+	AttributeSchemaVersion int               `json:"attribute_schema_version" gorm:"default:0"`
 	
 	// Relations
 	User     User     `json:"user,omitempty" gorm:"foreignKey:UserID"`
@@ -40,7 +57,7 @@ func (l *Listing) BeforeCreate(tx *gorm.DB) error {
 	
 	// Set expiration date (30 days from now)
 	if l.ExpiresAt == nil {
-		expiresAt := time.Now().AddDate(0, 0, 30)
+		expiresAt := time.Now().AddDate(0, 0, ListingDurationDays)
 		l.ExpiresAt = &expiresAt
 	}
 	
@@ -68,6 +85,11 @@ func (l *Listing) IsActive() bool {
 		   (l.ExpiresAt == nil || time.Now().Before(*l.ExpiresAt))
 }
 
+// IsBoosted vérifie si la mise en avant payante est en cours
+func (l *Listing) IsBoosted() bool {
+	return l.IsFeatured && l.BoostedUntil != nil && time.Now().Before(*l.BoostedUntil)
+}
+
 // IncrementViews augmente le compteur de vues
 func (l *Listing) IncrementViews(db *gorm.DB) error {
 	return db.Model(l).Update("views_count", gorm.Expr("views_count + 1")).Error
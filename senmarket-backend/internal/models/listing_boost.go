@@ -0,0 +1,32 @@
+// internal/models/listing_boost.go
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ListingBoost option premium achetée pour une annonce (boost ou mise en couleur)
+type ListingBoost struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ListingID uuid.UUID  `json:"listing_id" gorm:"type:uuid;not null;index"`
+	UserID    uuid.UUID  `json:"user_id" gorm:"type:uuid;not null"`
+	PaymentID *uuid.UUID `json:"payment_id" gorm:"type:uuid;uniqueIndex"`
+	Type      string     `json:"type" gorm:"not null" validate:"oneof=boost highlight"`
+	StartsAt  time.Time  `json:"starts_at"`
+	EndsAt    time.Time  `json:"ends_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (b *ListingBoost) BeforeCreate(tx *gorm.DB) error {
+	if b.ID == uuid.Nil {
+		b.ID = uuid.New()
+	}
+	return nil
+}
+
+func (ListingBoost) TableName() string {
+	return "listing_boosts"
+}
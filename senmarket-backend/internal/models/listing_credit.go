@@ -0,0 +1,72 @@
+// internal/models/listing_credit.go
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Objets de paiement
+const (
+	PaymentPurposeListing   = "listing"
+	PaymentPurposePack5     = "pack_5"
+	PaymentPurposePack10    = "pack_10"
+	PaymentPurposeBoost     = "boost"
+	PaymentPurposeHighlight = "highlight"
+	PaymentPurposeRenewal   = "renewal"
+)
+
+// ListingCredit lot de crédits d'annonces issu d'un pack acheté
+type ListingCredit struct {
+	ID               uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID           uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	PaymentID        *uuid.UUID `json:"payment_id" gorm:"type:uuid;uniqueIndex"`
+	Pack             string     `json:"pack" gorm:"not null"`
+	CreditsTotal     int        `json:"credits_total" gorm:"not null"`
+	CreditsRemaining int        `json:"credits_remaining" gorm:"not null"`
+	Price            float64    `json:"price" gorm:"type:decimal(10,2)"`
+	ExpiresAt        time.Time  `json:"expires_at" gorm:"not null"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+func (c *ListingCredit) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
+
+func (ListingCredit) TableName() string {
+	return "listing_credits"
+}
+
+// IsExpired vérifie si le lot a expiré
+func (c *ListingCredit) IsExpired() bool {
+	return time.Now().After(c.ExpiresAt)
+}
+
+// ListingCreditTransaction mouvement du journal des crédits
+type ListingCreditTransaction struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID      uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	CreditID    uuid.UUID  `json:"credit_id" gorm:"type:uuid;not null;index"`
+	ListingID   *uuid.UUID `json:"listing_id,omitempty" gorm:"type:uuid"`
+	Type        string     `json:"type" gorm:"not null" validate:"oneof=purchase consume expire revoke"`
+	Quantity    int        `json:"quantity" gorm:"not null"` // positif = crédit, négatif = débit
+	Description string     `json:"description,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+func (t *ListingCreditTransaction) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+func (ListingCreditTransaction) TableName() string {
+	return "listing_credit_transactions"
+}
@@ -0,0 +1,33 @@
+// internal/models/listing_renewal.go
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ListingRenewal prolongation de la durée de publication d'une annonce
+type ListingRenewal struct {
+	ID                uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ListingID         uuid.UUID  `json:"listing_id" gorm:"type:uuid;not null;index"`
+	UserID            uuid.UUID  `json:"user_id" gorm:"type:uuid;not null"`
+	PaymentID         *uuid.UUID `json:"payment_id" gorm:"type:uuid;uniqueIndex"` // NULL si renouvellement gratuit
+	Phase             string     `json:"phase" gorm:"not null"`
+	Price             float64    `json:"price" gorm:"type:decimal(10,2)"`
+	PreviousExpiresAt *time.Time `json:"previous_expires_at"`
+	ExpiresAt         time.Time  `json:"expires_at"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+func (r *ListingRenewal) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+func (ListingRenewal) TableName() string {
+	return "listing_renewals"
+}
@@ -0,0 +1,38 @@
+// internal/models/listing_sale.go
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ListingSale résultat d'une vente déclarée par le vendeur
+type ListingSale struct {
+	ID             uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ListingID      uuid.UUID  `json:"listing_id" gorm:"type:uuid;not null;uniqueIndex"`
+	UserID         uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	CategoryID     uuid.UUID  `json:"category_id" gorm:"type:uuid;not null;index"`
+	Region         string     `json:"region" gorm:"not null"`
+	SoldOnPlatform bool       `json:"sold_on_platform"`
+	FinalPrice     *float64   `json:"final_price" gorm:"type:decimal(12,2)"` // Non communiqué si NULL
+	ListedPrice    float64    `json:"listed_price" gorm:"type:decimal(12,2);not null"`
+	Currency       string     `json:"currency" gorm:"default:'XOF'"`
+	BuyerContactID *uuid.UUID `json:"buyer_contact_id" gorm:"type:uuid"`
+	BuyerUserID    *uuid.UUID `json:"buyer_user_id" gorm:"type:uuid"`
+	ListedAt       time.Time  `json:"listed_at"`
+	SoldAt         time.Time  `json:"sold_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+func (s *ListingSale) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+func (ListingSale) TableName() string {
+	return "listing_sales"
+}
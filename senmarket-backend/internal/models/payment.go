@@ -13,12 +13,14 @@ type Payment struct {
 	ID              uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
 	UserID          uuid.UUID      `json:"user_id" gorm:"type:uuid;not null;index"`
 	ListingID       *uuid.UUID     `json:"listing_id" gorm:"type:uuid;index"` // Peut être null pour d'autres types de paiements
+	Purpose         string         `json:"purpose" gorm:"default:'listing'"`    // listing, pack_5, pack_10, boost, highlight, renewal, wallet_topup
 	Amount          float64        `json:"amount" gorm:"type:decimal(10,2);not null;default:200.00"`
 	Currency        string         `json:"currency" gorm:"default:'XOF'"`
-	PaymentMethod   string         `json:"payment_method" gorm:"not null" validate:"oneof=orange_money wave free_money card"`
+	PaymentMethod   string         `json:"payment_method" gorm:"not null" validate:"oneof=orange_money wave free_money card mock wallet"`
 	PaymentProvider string         `json:"payment_provider"`
 	TransactionID   string         `json:"transaction_id" gorm:"uniqueIndex"`
-	Status          string         `json:"status" gorm:"default:'pending'" validate:"oneof=pending completed failed cancelled"`
+	Status          string         `json:"status" gorm:"default:'pending'" validate:"oneof=pending completed failed cancelled refunded partially_refunded"`
+	RefundedAmount  float64        `json:"refunded_amount" gorm:"type:decimal(10,2);default:0"`
 	FailureReason   string         `json:"failure_reason,omitempty"`
 	CreatedAt       time.Time      `json:"created_at"`
 	CompletedAt     *time.Time     `json:"completed_at"`
@@ -28,6 +30,7 @@ type Payment struct {
 	// Relations
 	User    User     `json:"user,omitempty" gorm:"foreignKey:UserID"`
 	Listing *Listing `json:"listing,omitempty" gorm:"foreignKey:ListingID"`
+	Refunds []Refund `json:"refunds,omitempty" gorm:"foreignKey:PaymentID"`
 }
 
 // BeforeCreate hook
@@ -53,6 +56,16 @@ func (p *Payment) IsPending() bool {
 	return p.Status == "pending"
 }
 
+// IsRefundable vérifie si le paiement peut encore être remboursé
+func (p *Payment) IsRefundable() bool {
+	return (p.Status == "completed" || p.Status == "partially_refunded") && p.RefundableAmount() > 0
+}
+
+// RefundableAmount montant restant remboursable
+func (p *Payment) RefundableAmount() float64 {
+	return p.Amount - p.RefundedAmount
+}
+
 // MarkAsCompleted marque le paiement comme terminé
 func (p *Payment) MarkAsCompleted(db *gorm.DB) error {
 	now := time.Now()
@@ -182,6 +182,77 @@ func (pc *PricingConfig) GetPricingInfo() map[string]interface{} {
 	}
 }
 
+// ListingPackValidityDays durée de validité des crédits d'un pack
+const ListingPackValidityDays = 90
+
+// Durée des options premium achetées pour une annonce
+const (
+	ListingBoostDurationDays     = 7
+	ListingHighlightDurationDays = 7
+)
+
+// GetListingOptionPrice retourne le prix et la durée d'une option premium (boost, highlight)
+func (pc *PricingConfig) GetListingOptionPrice(option string) (float64, int, bool) {
+	switch option {
+	case PaymentPurposeBoost:
+		return pc.PremiumBoostPrice, ListingBoostDurationDays, true
+	case PaymentPurposeHighlight:
+		return pc.FeaturedColorPrice, ListingHighlightDurationDays, true
+	}
+	return 0, 0, false
+}
+
+// GetListingRenewalPrice retourne le prix d'un renouvellement d'annonce :
+// gratuit en phase de lancement et de crédits, prix standard en phase payante
+func (pc *PricingConfig) GetListingRenewalPrice() (float64, bool) {
+	if pc.GetCurrentPhase() != "paid_system" {
+		return 0, true
+	}
+	return pc.StandardListingPrice, false
+}
+
+// ListingPack pack d'annonces prépayées vendu selon la configuration
+type ListingPack struct {
+	Code         string  `json:"code"`
+	Credits      int     `json:"credits"`
+	Price        float64 `json:"price"`
+	Discount     float64 `json:"discount"`
+	Currency     string  `json:"currency"`
+	ValidityDays int     `json:"validity_days"`
+}
+
+// GetListingPacks retourne les packs disponibles aux prix configurés
+func (pc *PricingConfig) GetListingPacks() []ListingPack {
+	return []ListingPack{
+		{
+			Code:         PaymentPurposePack5,
+			Credits:      5,
+			Price:        pc.Pack5ListingsPrice,
+			Discount:     pc.Pack5Discount,
+			Currency:     pc.Currency,
+			ValidityDays: ListingPackValidityDays,
+		},
+		{
+			Code:         PaymentPurposePack10,
+			Credits:      10,
+			Price:        pc.Pack10ListingsPrice,
+			Discount:     pc.Pack10Discount,
+			Currency:     pc.Currency,
+			ValidityDays: ListingPackValidityDays,
+		},
+	}
+}
+
+// GetListingPack retourne le pack correspondant au code
+func (pc *PricingConfig) GetListingPack(code string) (*ListingPack, bool) {
+	for _, pack := range pc.GetListingPacks() {
+		if pack.Code == code {
+			return &pack, true
+		}
+	}
+	return nil, false
+}
+
 // TransitionToNextPhase fait passer à la phase suivante
 func (pc *PricingConfig) TransitionToNextPhase(db *gorm.DB, adminUserID *uuid.UUID) error {
 	currentPhase := pc.GetCurrentPhase()
@@ -0,0 +1,35 @@
+// internal/models/refund.go
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Refund struct {
+	ID               uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	PaymentID        uuid.UUID  `json:"payment_id" gorm:"type:uuid;not null;index"`
+	Amount           float64    `json:"amount" gorm:"type:decimal(10,2);not null"`
+	Currency         string     `json:"currency" gorm:"default:'XOF'"`
+	Reason           string     `json:"reason" gorm:"type:text;not null"`
+	Status           string     `json:"status" gorm:"default:'pending'" validate:"oneof=pending completed failed"`
+	FailureReason    string     `json:"failure_reason,omitempty"`
+	UnpublishListing bool       `json:"unpublish_listing" gorm:"default:false"`
+	RequestedBy      *uuid.UUID `json:"requested_by" gorm:"type:uuid"`
+	CreatedAt        time.Time  `json:"created_at"`
+	CompletedAt      *time.Time `json:"completed_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+func (r *Refund) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+func (Refund) TableName() string {
+	return "refunds"
+}
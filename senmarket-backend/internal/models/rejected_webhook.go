@@ -0,0 +1,33 @@
+// internal/models/rejected_webhook.go
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RejectedWebhook webhook de paiement refusé, conservé pour audit
+type RejectedWebhook struct {
+	ID           uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Provider     string    `json:"provider" gorm:"not null;index"`
+	Reason       string    `json:"reason" gorm:"not null;index"` // invalid_signature, expired, replay, invalid_payload
+	ErrorMessage string    `json:"error_message" gorm:"type:text"`
+	Signature    string    `json:"signature" gorm:"type:text"`
+	Headers      string    `json:"headers" gorm:"type:text"` // JSON
+	Payload      string    `json:"payload" gorm:"type:text"`
+	RemoteIP     string    `json:"remote_ip"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (r *RejectedWebhook) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+func (RejectedWebhook) TableName() string {
+	return "rejected_webhooks"
+}
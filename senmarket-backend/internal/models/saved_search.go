@@ -0,0 +1,96 @@
+// internal/models/saved_search.go
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	AlertChannelSMS      = "sms"
+	AlertChannelWhatsApp = "whatsapp"
+
+	AlertFrequencyInstant = "instant"
+	AlertFrequencyDaily   = "daily"
+)
+
+// SavedSearch jeu de filtres enregistré par un acheteur, avec alerte sur les nouvelles annonces
+type SavedSearch struct {
+	ID               uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID           uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	Name             string     `json:"name" gorm:"not null"`
+	CategoryID       *uuid.UUID `json:"category_id" gorm:"type:uuid"`
+	Region           string     `json:"region"`
+	MinPrice         *float64   `json:"min_price" gorm:"type:decimal(12,2)"`
+	MaxPrice         *float64   `json:"max_price" gorm:"type:decimal(12,2)"`
+	Keywords         string     `json:"keywords"`
+	Channel          string     `json:"channel" gorm:"default:'sms'" validate:"oneof=sms whatsapp"`
+	Frequency        string     `json:"frequency" gorm:"default:'instant'" validate:"oneof=instant daily"`
+	IsActive         bool       `json:"is_active" gorm:"default:true"`
+	UnsubscribeToken string     `json:"-" gorm:"uniqueIndex;not null"`
+	LastCheckedAt    time.Time  `json:"last_checked_at"`
+	LastNotifiedAt   *time.Time `json:"last_notified_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+
+	// Relations
+	User     User      `json:"-" gorm:"foreignKey:UserID"`
+	Category *Category `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
+}
+
+// SavedSearchMatch annonce correspondant à une recherche sauvegardée
+type SavedSearchMatch struct {
+	ID            uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	SavedSearchID uuid.UUID  `json:"saved_search_id" gorm:"type:uuid;not null"`
+	ListingID     uuid.UUID  `json:"listing_id" gorm:"type:uuid;not null"`
+	NotifiedAt    *time.Time `json:"notified_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+
+	// Relations
+	Listing Listing `json:"listing,omitempty" gorm:"foreignKey:ListingID"`
+}
+
+func (s *SavedSearch) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+func (SavedSearch) TableName() string {
+	return "saved_searches"
+}
+
+// Filters filtres au format de ListingService.GetListings
+func (s *SavedSearch) Filters() map[string]interface{} {
+	filters := map[string]interface{}{}
+	if s.CategoryID != nil {
+		filters["category_id"] = s.CategoryID.String()
+	}
+	if s.Region != "" {
+		filters["region"] = s.Region
+	}
+	if s.MinPrice != nil {
+		filters["min_price"] = *s.MinPrice
+	}
+	if s.MaxPrice != nil {
+		filters["max_price"] = *s.MaxPrice
+	}
+	if s.Keywords != "" {
+		filters["search"] = s.Keywords
+	}
+	return filters
+}
+
+func (m *SavedSearchMatch) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
+
+func (SavedSearchMatch) TableName() string {
+	return "saved_search_matches"
+}
@@ -8,11 +8,30 @@ import (
 	"gorm.io/gorm"
 )
 
+// Usages d'un code envoyé par SMS / WhatsApp (un code ne vaut que pour son usage)
+const (
+	VerificationPurposePhone         = "phone_verification"
+	VerificationPurposePasswordReset = "password_reset"
+	VerificationPurposeLogin         = "login"
+)
+
+// MaxVerificationAttempts saisies erronées tolérées avant invalidation du code
+const MaxVerificationAttempts = 5
+
+// Canaux de livraison d'un code
+const (
+	VerificationChannelWhatsApp = "whatsapp"
+	VerificationChannelSMS      = "sms"
+)
+
 type SMSVerification struct {
 	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
 	Phone     string    `json:"phone" gorm:"not null"`
 	Code      string    `json:"code" gorm:"not null"`
+	Purpose   string    `json:"purpose" gorm:"default:'phone_verification';not null"`
+	Channel   string    `json:"channel" gorm:"default:'sms';not null"`
 	Verified  bool      `json:"verified" gorm:"default:false"`
+	Attempts  int       `json:"attempts" gorm:"default:0;not null"`
 	ExpiresAt time.Time `json:"expires_at"`
 	CreatedAt time.Time `json:"created_at"`
 }
@@ -31,6 +31,9 @@ type User struct {
 	// Métadonnées pour tracking des phases
 	OnboardingPhase      string     `json:"onboarding_phase" gorm:"default:'free_launch'" comment:"Phase actuelle de l'utilisateur: free_launch, credit_system, paid"`
 	RegistrationPhase    string     `json:"registration_phase" gorm:"default:'launch'" comment:"Phase lors de l'inscription: launch, transition, paid"`
+
+	// Rôle d'accès : user, moderator, admin
+	Role string `json:"role" gorm:"default:'user';not null"`
 	
 	// Champs existants
 	CreatedAt   time.Time      `json:"created_at"`
@@ -69,6 +72,43 @@ func (User) TableName() string {
 	return "users"
 }
 
+// Rôles d'accès, du moins au plus privilégié
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+var roleRanks = map[string]int{
+	RoleUser:      1,
+	RoleModerator: 2,
+	RoleAdmin:     3,
+}
+
+// IsValidRole vérifie qu'un rôle existe
+func IsValidRole(role string) bool {
+	_, ok := roleRanks[role]
+	return ok
+}
+
+// HasRole vérifie que l'utilisateur a au moins le rôle demandé (admin > moderator > user)
+func (u *User) HasRole(role string) bool {
+	required, ok := roleRanks[role]
+	if !ok {
+		return false
+	}
+	current := roleRanks[u.Role]
+	if u.Role == "" {
+		current = roleRanks[RoleUser]
+	}
+	return current >= required
+}
+
+// IsAdmin vérifie si l'utilisateur est administrateur
+func (u *User) IsAdmin() bool {
+	return u.HasRole(RoleAdmin)
+}
+
 // GetFullName retourne le nom complet
 func (u *User) GetFullName() string {
 	return u.FirstName + " " + u.LastName
@@ -0,0 +1,91 @@
+// internal/models/wallet.go
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Moyen de paiement interne : débit du portefeuille
+const PaymentMethodWallet = "wallet"
+
+// Rechargement du portefeuille via un provider mobile money
+const PaymentPurposeWalletTopUp = "wallet_topup"
+
+// Comptes système de contrepartie
+const (
+	WalletAccountTopUps  = "system:topups"
+	WalletAccountRevenue = "system:revenue"
+)
+
+// WalletAccount compte du portefeuille (utilisateur ou système)
+type WalletAccount struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Code      string     `json:"code" gorm:"not null;uniqueIndex"`
+	Type      string     `json:"type" gorm:"not null" validate:"oneof=user system"`
+	UserID    *uuid.UUID `json:"user_id,omitempty" gorm:"type:uuid;uniqueIndex"`
+	Balance   float64    `json:"balance" gorm:"type:decimal(12,2);default:0"`
+	Currency  string     `json:"currency" gorm:"default:'XOF'"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+func (a *WalletAccount) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+func (WalletAccount) TableName() string {
+	return "wallet_accounts"
+}
+
+// WalletTransaction opération métier équilibrée (rechargement, achat, remboursement)
+type WalletTransaction struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID      uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	Type        string     `json:"type" gorm:"not null" validate:"oneof=topup purchase refund"`
+	Purpose     string     `json:"purpose,omitempty"`
+	Amount      float64    `json:"amount" gorm:"type:decimal(12,2);not null"`
+	PaymentID   *uuid.UUID `json:"payment_id,omitempty" gorm:"type:uuid"`
+	RefundID    *uuid.UUID `json:"refund_id,omitempty" gorm:"type:uuid"`
+	Description string     `json:"description,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+
+	Entries []WalletEntry `json:"entries,omitempty" gorm:"foreignKey:TransactionID"`
+}
+
+func (t *WalletTransaction) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+func (WalletTransaction) TableName() string {
+	return "wallet_transactions"
+}
+
+// WalletEntry écriture d'une transaction sur un compte (positif = crédit, négatif = débit)
+type WalletEntry struct {
+	ID            uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TransactionID uuid.UUID `json:"transaction_id" gorm:"type:uuid;not null;index"`
+	AccountID     uuid.UUID `json:"account_id" gorm:"type:uuid;not null;index"`
+	Amount        float64   `json:"amount" gorm:"type:decimal(12,2);not null"`
+	BalanceAfter  float64   `json:"balance_after" gorm:"type:decimal(12,2);not null"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func (e *WalletEntry) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+func (WalletEntry) TableName() string {
+	return "wallet_entries"
+}
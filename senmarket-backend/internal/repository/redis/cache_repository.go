@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -80,6 +81,11 @@ func (r *CacheRepository) IncrEx(ctx context.Context, key string, ttl time.Durat
 	return incrCmd.Val(), nil
 }
 
+// TTL durée de vie restante d'une clé (négative si absente ou sans expiration)
+func (r *CacheRepository) TTL(ctx context.Context, key string) (time.Duration, error) {
+	return r.client.TTL(ctx, key).Result()
+}
+
 // GetPattern récupère toutes les clés matchant un pattern
 func (r *CacheRepository) GetPattern(ctx context.Context, pattern string) ([]string, error) {
 	return r.client.Keys(ctx, pattern).Result()
@@ -107,4 +113,38 @@ func (r *CacheRepository) SetHash(ctx context.Context, key string, fields map[st
 // GetHash récupère un hash complet
 func (r *CacheRepository) GetHash(ctx context.Context, key string) (map[string]string, error) {
 	return r.client.HGetAll(ctx, key).Result()
-}
\ No newline at end of file
+}
+
+// ZIncrBy incrémente le score d'un membre d'un sorted set
+func (r *CacheRepository) ZIncrBy(ctx context.Context, key string, increment float64, member string) (float64, error) {
+	return r.client.ZIncrBy(ctx, key, increment, member).Result()
+}
+
+// ZRevRange récupère les membres d'un sorted set par score décroissant
+func (r *CacheRepository) ZRevRange(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	return r.client.ZRevRange(ctx, key, start, stop).Result()
+}
+
+// ZRemRangeByRank supprime les membres d'un sorted set entre deux rangs
+func (r *CacheRepository) ZRemRangeByRank(ctx context.Context, key string, start, stop int64) error {
+	return r.client.ZRemRangeByRank(ctx, key, start, stop).Err()
+}
+
+// ZRevRangeByScore récupère au plus count membres de score >= min, par score décroissant
+func (r *CacheRepository) ZRevRangeByScore(ctx context.Context, key string, min float64, count int64) ([]string, error) {
+	return r.client.ZRevRangeByScore(ctx, key, &redis.ZRangeBy{
+		Min:   strconv.FormatFloat(min, 'f', -1, 64),
+		Max:   "+inf",
+		Count: count,
+	}).Result()
+}
+
+// ZUnionStore additionne les scores de plusieurs sorted sets dans dest
+func (r *CacheRepository) ZUnionStore(ctx context.Context, dest string, keys ...string) error {
+	return r.client.ZUnionStore(ctx, dest, &redis.ZStore{Keys: keys, Aggregate: "SUM"}).Err()
+}
+
+// Expire définit la durée de vie d'une clé
+func (r *CacheRepository) Expire(ctx context.Context, key string, ttl time.Duration) error {
+	return r.client.Expire(ctx, key, ttl).Err()
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"senmarket/internal/models"
@@ -33,6 +34,7 @@ const (
 	CACHE_LISTINGS_REGION   = "listings:region:"
 	CACHE_LISTINGS_FEATURED = "listings:featured"
 	CACHE_LISTINGS_COUNT    = "listings:count"
+	CACHE_LISTINGS_FACETS   = "listings:facets"
 
 	// Categories
 	CACHE_CATEGORIES_ALL   = "categories:all"
@@ -47,6 +49,9 @@ const (
 	// Search
 	CACHE_SEARCH_PREFIX   = "search:"
 	CACHE_SEARCH_POPULAR  = "search:popular"
+	CACHE_SEARCH_SCORES   = "search:popular:window" // Sorted set agrégé sur la fenêtre glissante
+	CACHE_SEARCH_DAY      = "search:popular:day:"   // Sorted set des recherches d'une journée (UTC)
+	CACHE_SEARCH_SUGGEST  = "search:suggest:"
 
 	// Stats
 	CACHE_STATS_GLOBAL    = "stats:global"
@@ -56,6 +61,9 @@ const (
 	CACHE_SESSION_PREFIX  = "session:"
 	CACHE_RATE_LIMIT      = "rate:"
 
+	// Paiements
+	CACHE_WEBHOOK_NONCE   = "webhook:nonce:"
+
 	// TTL Values
 	TTL_SHORT    = 5 * time.Minute   // Données fréquemment modifiées
 	TTL_MEDIUM   = 30 * time.Minute  // Données modérément stables
@@ -124,6 +132,20 @@ func (s *CacheService) GetCachedSearchResults(ctx context.Context, query string)
 	return results, nil
 }
 
+// CacheListingFacets stocke les facettes d'un jeu de filtres
+func (s *CacheService) CacheListingFacets(ctx context.Context, filters map[string]interface{}, facets *ListingFacets) error {
+	return s.cache.Set(ctx, s.buildListingFacetsKey(filters), facets, TTL_SHORT)
+}
+
+// GetCachedListingFacets récupère les facettes d'un jeu de filtres
+func (s *CacheService) GetCachedListingFacets(ctx context.Context, filters map[string]interface{}) (*ListingFacets, error) {
+	var facets ListingFacets
+	if err := s.cache.Get(ctx, s.buildListingFacetsKey(filters), &facets); err != nil {
+		return nil, err
+	}
+	return &facets, nil
+}
+
 // CacheFeaturedListings stocke les annonces à la une
 func (s *CacheService) CacheFeaturedListings(ctx context.Context, listings []models.Listing) error {
 	return s.cache.Set(ctx, CACHE_LISTINGS_FEATURED, listings, TTL_MEDIUM)
@@ -145,21 +167,27 @@ func (s *CacheService) InvalidateListingCache(ctx context.Context, listingID str
 	// Supprimer l'annonce spécifique
 	listingKey := CACHE_LISTING_PREFIX + listingID
 	
+	if err := s.cache.Del(ctx, listingKey); err != nil {
+		return err
+	}
+	
+	return s.InvalidateListingsCache(ctx)
+}
+
+// InvalidateListingsCache invalide les listes d'annonces (pages, recherches, à la une...)
+func (s *CacheService) InvalidateListingsCache(ctx context.Context) error {
 	// Invalider les caches liés
 	patterns := []string{
 		CACHE_LISTINGS_PAGE + "*",
 		CACHE_LISTINGS_SEARCH + "*",
 		CACHE_LISTINGS_CATEGORY + "*",
 		CACHE_LISTINGS_REGION + "*",
+		CACHE_LISTINGS_FACETS + "*",
 		CACHE_LISTINGS_FEATURED,
+		CACHE_LISTINGS_COUNT,
 		CACHE_STATS_GLOBAL,
 	}
 	
-	// Supprimer l'annonce spécifique
-	if err := s.cache.Del(ctx, listingKey); err != nil {
-		return err
-	}
-	
 	// Invalider les patterns
 	for _, pattern := range patterns {
 		if err := s.cache.DelPattern(ctx, pattern); err != nil {
@@ -175,6 +203,19 @@ func (s *CacheService) InvalidateListingCache(ctx context.Context, listingID str
 // CATEGORIES CACHE
 // ============================================
 
+// InvalidateCategoryCache invalide les catégories (liste, détails, schémas d'attributs)
+func (s *CacheService) InvalidateCategoryCache(ctx context.Context) error {
+	if err := s.cache.Del(ctx, CACHE_CATEGORIES_ALL, "categories:with_counts"); err != nil {
+		return err
+	}
+	return s.cache.DelPattern(ctx, CACHE_CATEGORY_PREFIX+"*")
+}
+
+// InvalidateCategoryStatsCache invalide les statistiques de toutes les catégories
+func (s *CacheService) InvalidateCategoryStatsCache(ctx context.Context) error {
+	return s.cache.DelPattern(ctx, CACHE_CATEGORY_STATS+"*")
+}
+
 // CacheCategories stocke toutes les catégories
 func (s *CacheService) CacheCategories(ctx context.Context, categories []models.Category) error {
 	return s.cache.Set(ctx, CACHE_CATEGORIES_ALL, categories, TTL_VERY_LONG)
@@ -295,19 +336,60 @@ func (s *CacheService) CheckRateLimit(ctx context.Context, identifier string, li
 // buildListingsPageKey construit une clé pour la pagination
 func (s *CacheService) buildListingsPageKey(page, limit int, filters map[string]interface{}) string {
 	key := CACHE_LISTINGS_PAGE + strconv.Itoa(page) + ":" + strconv.Itoa(limit)
+	key += s.buildListingFiltersKey(filters)
 	
+	if sortBy, ok := filters["sort"].(string); ok && sortBy != "" {
+		key += ":sort:" + sortBy
+	}
+	
+	return key
+}
+
+// buildListingFacetsKey construit une clé pour les facettes d'un jeu de filtres
+func (s *CacheService) buildListingFacetsKey(filters map[string]interface{}) string {
+	return CACHE_LISTINGS_FACETS + s.buildListingFiltersKey(filters)
+}
+
+// buildListingFiltersKey suffixe de clé décrivant les filtres de liste
+func (s *CacheService) buildListingFiltersKey(filters map[string]interface{}) string {
+	key := ""
+
 	if categoryID, ok := filters["category_id"].(string); ok && categoryID != "" {
 		key += ":cat:" + categoryID
 	}
-	
+
 	if region, ok := filters["region"].(string); ok && region != "" {
 		key += ":reg:" + region
 	}
-	
-	if sortBy, ok := filters["sort_by"].(string); ok && sortBy != "" {
-		key += ":sort:" + sortBy
+
+	if commune, ok := filters["commune"].(string); ok && commune != "" {
+		key += ":com:" + strings.ToLower(commune)
 	}
-	
+
+	if point, radius := nearFilter(filters); point != nil {
+		key += geoCacheKey(*point, radius)
+	}
+
+	if attributes, ok := filters["attributes"].(map[string]string); ok {
+		for _, name := range sortedAttributeFilterKeys(attributes) {
+			key += ":attr:" + name + "=" + attributes[name]
+		}
+	}
+
+	if minPrice, ok := filters["min_price"].(float64); ok && minPrice > 0 {
+		key += ":min:" + strconv.FormatFloat(minPrice, 'f', -1, 64)
+	}
+
+	if maxPrice, ok := filters["max_price"].(float64); ok && maxPrice > 0 {
+		key += ":max:" + strconv.FormatFloat(maxPrice, 'f', -1, 64)
+	}
+
+	if search, ok := filters["search"].(string); ok {
+		if search = foldSearchText(normalizeSearchQuery(search)); search != "" {
+			key += ":q:" + search
+		}
+	}
+
 	return key
 }
 
@@ -324,11 +406,66 @@ func (s *CacheService) GetCacheInfo(ctx context.Context) (map[string]interface{}
 	}, nil
 }
 
-// IncrementSearchCount incrémente le compteur de recherche
+// IncrementSearchCount incrémente le score d'une recherche dans le classement du jour.
+// Chaque journée a son propre classement, qui expire après la fenêtre : une recherche
+// récente peut ainsi détrôner les anciennes au lieu d'être évincée dès son arrivée.
 func (s *CacheService) IncrementSearchCount(ctx context.Context, query string) error {
-	key := "search:count:" + query
-	_, err := s.cache.IncrEx(ctx, key, TTL_VERY_LONG)
-	return err
+	key := searchDayKey(time.Now())
+	if _, err := s.cache.ZIncrBy(ctx, key, 1, query); err != nil {
+		return err
+	}
+	if err := s.cache.Expire(ctx, key, (popularSearchesWindowDays+1)*24*time.Hour); err != nil {
+		return err
+	}
+	// Borner la taille du classement du jour
+	return s.cache.ZRemRangeByRank(ctx, key, 0, -popularSearchesDayMaxSize-1)
+}
+
+// GetPopularSearches récupère les recherches les plus fréquentes des derniers jours,
+// en ignorant celles qui n'ont pas atteint le nombre minimal d'occurrences
+func (s *CacheService) GetPopularSearches(ctx context.Context, limit int64) ([]string, error) {
+	exists, err := s.cache.Exists(ctx, CACHE_SEARCH_SCORES)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		// Agrégat recalculé au plus toutes les TTL_SHORT
+		if err := s.cache.ZUnionStore(ctx, CACHE_SEARCH_SCORES, searchWindowKeys(time.Now())...); err != nil {
+			return nil, err
+		}
+		if err := s.cache.Expire(ctx, CACHE_SEARCH_SCORES, TTL_SHORT); err != nil {
+			return nil, err
+		}
+	}
+	return s.cache.ZRevRangeByScore(ctx, CACHE_SEARCH_SCORES, popularSearchesMinCount, limit)
+}
+
+// searchDayKey clé du classement des recherches d'une journée (UTC)
+func searchDayKey(day time.Time) string {
+	return CACHE_SEARCH_DAY + day.UTC().Format("2006-01-02")
+}
+
+// searchWindowKeys clés des classements journaliers de la fenêtre glissante
+func searchWindowKeys(now time.Time) []string {
+	keys := make([]string, popularSearchesWindowDays)
+	for i := range keys {
+		keys[i] = searchDayKey(now.AddDate(0, 0, -i))
+	}
+	return keys
+}
+
+// CacheSearchSuggestions stocke les suggestions d'un préfixe
+func (s *CacheService) CacheSearchSuggestions(ctx context.Context, prefix string, suggestions []SearchSuggestion) error {
+	return s.cache.Set(ctx, CACHE_SEARCH_SUGGEST+prefix, suggestions, TTL_SHORT)
+}
+
+// GetCachedSearchSuggestions récupère les suggestions d'un préfixe
+func (s *CacheService) GetCachedSearchSuggestions(ctx context.Context, prefix string) ([]SearchSuggestion, error) {
+	var suggestions []SearchSuggestion
+	if err := s.cache.Get(ctx, CACHE_SEARCH_SUGGEST+prefix, &suggestions); err != nil {
+		return nil, err
+	}
+	return suggestions, nil
 }
 
 // CachePopularSearches stocke les recherches populaires
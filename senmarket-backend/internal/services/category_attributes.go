@@ -0,0 +1,407 @@
+// internal/services/category_attributes.go
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"senmarket/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrAttributeSchemaNotFound = errors.New("schéma d'attributs non trouvé")
+	ErrInvalidAttributeSchema  = errors.New("schéma d'attributs invalide")
+	ErrInvalidAttributes       = errors.New("attributs invalides")
+)
+
+const (
+	maxAttributeFields      = 30
+	maxAttributeStringLen   = 100
+	maxAttributeFilterTerms = 10
+)
+
+var attributeKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,39}$`)
+
+// AttributeSchemaRequest nouvelle version du schéma d'attributs d'une catégorie
+type AttributeSchemaRequest struct {
+	Fields []models.AttributeField `json:"fields" validate:"max=30"`
+}
+
+// GetAttributeSchema schéma d'attributs d'une catégorie (version 0 = version courante)
+func (s *CategoryService) GetAttributeSchema(categoryID string, version int) (*models.CategoryAttributeSchema, error) {
+	category, err := s.GetCategoryByID(categoryID)
+	if err != nil {
+		return nil, err
+	}
+	if version <= 0 {
+		version = category.AttributeSchemaVersion
+	}
+	if version == 0 {
+		return nil, ErrAttributeSchemaNotFound
+	}
+
+	ctx := context.Background()
+	cacheKey := fmt.Sprintf("%sattributes:%s:v%d", CACHE_CATEGORY_PREFIX, category.ID, version)
+
+	// Une version publiée n'est jamais modifiée : cache longue durée
+	var cached models.CategoryAttributeSchema
+	if err := s.cacheService.cache.Get(ctx, cacheKey, &cached); err == nil {
+		return &cached, nil
+	}
+
+	schema, err := loadAttributeSchema(s.db, category.ID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		if err := s.cacheService.cache.Set(ctx, cacheKey, schema, TTL_VERY_LONG); err != nil {
+			log.Printf("Erreur cache schéma attributs: %v", err)
+		}
+	}()
+
+	return schema, nil
+}
+
+// GetAttributeSchemaVersions historique des versions du schéma d'une catégorie
+func (s *CategoryService) GetAttributeSchemaVersions(categoryID string) ([]models.CategoryAttributeSchema, error) {
+	category, err := s.GetCategoryByID(categoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	var schemas []models.CategoryAttributeSchema
+	if err := s.db.Where("category_id = ?", category.ID).
+		Order("version DESC").
+		Find(&schemas).Error; err != nil {
+		return nil, fmt.Errorf("erreur récupération versions du schéma: %w", err)
+	}
+	return schemas, nil
+}
+
+// PublishAttributeSchema enregistre une nouvelle version du schéma et la rend courante.
+// Les annonces existantes conservent leur version ; elles sont revalidées à leur prochaine modification.
+func (s *CategoryService) PublishAttributeSchema(categoryID string, userID uuid.UUID, req *AttributeSchemaRequest) (*models.CategoryAttributeSchema, error) {
+	category, err := s.GetCategoryByID(categoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := normalizeAttributeSchema(req.Fields)
+	if err != nil {
+		return nil, err
+	}
+
+	schema := models.CategoryAttributeSchema{
+		CategoryID: category.ID,
+		Fields:     fields,
+	}
+	if userID != uuid.Nil {
+		schema.CreatedBy = &userID
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// Verrouiller la catégorie pour sérialiser les publications concurrentes
+		var current models.Category
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&current, "id = ?", category.ID).Error; err != nil {
+			return fmt.Errorf("erreur verrouillage catégorie: %w", err)
+		}
+
+		schema.Version = current.AttributeSchemaVersion + 1
+		if err := tx.Create(&schema).Error; err != nil {
+			return fmt.Errorf("erreur création schéma: %w", err)
+		}
+
+		return tx.Model(&models.Category{}).
+			Where("id = ?", category.ID).
+			Update("attribute_schema_version", schema.Version).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.cacheService.InvalidateCategoryCache(context.Background()); err != nil {
+		log.Printf("Erreur invalidation cache catégories: %v", err)
+	}
+
+	log.Printf("🧩 Schéma d'attributs v%d publié pour la catégorie %s", schema.Version, category.Slug)
+	return &schema, nil
+}
+
+// loadAttributeSchema charge une version donnée du schéma d'une catégorie
+func loadAttributeSchema(db *gorm.DB, categoryID uuid.UUID, version int) (*models.CategoryAttributeSchema, error) {
+	var schema models.CategoryAttributeSchema
+	if err := db.Where("category_id = ? AND version = ?", categoryID, version).First(&schema).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAttributeSchemaNotFound
+		}
+		return nil, fmt.Errorf("erreur récupération schéma d'attributs: %w", err)
+	}
+	return &schema, nil
+}
+
+// currentAttributeSchema schéma courant d'une catégorie (nil si la catégorie n'en définit pas)
+func currentAttributeSchema(db *gorm.DB, categoryID uuid.UUID) (*models.CategoryAttributeSchema, error) {
+	var category models.Category
+	if err := db.Select("id", "attribute_schema_version").First(&category, "id = ?", categoryID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCategoryNotFound
+		}
+		return nil, fmt.Errorf("erreur récupération catégorie: %w", err)
+	}
+	if category.AttributeSchemaVersion == 0 {
+		return nil, nil
+	}
+	return loadAttributeSchema(db, categoryID, category.AttributeSchemaVersion)
+}
+
+// normalizeAttributeSchema vérifie la cohérence d'un schéma soumis
+func normalizeAttributeSchema(fields []models.AttributeField) (models.AttributeFields, error) {
+	if len(fields) > maxAttributeFields {
+		return nil, fmt.Errorf("%w: %d attributs maximum", ErrInvalidAttributeSchema, maxAttributeFields)
+	}
+
+	seen := make(map[string]bool, len(fields))
+	normalized := make(models.AttributeFields, 0, len(fields))
+	for _, field := range fields {
+		field.Key = strings.TrimSpace(field.Key)
+		field.Label = strings.TrimSpace(field.Label)
+
+		if !attributeKeyPattern.MatchString(field.Key) {
+			return nil, fmt.Errorf("%w: clé %q (minuscules, chiffres et _)", ErrInvalidAttributeSchema, field.Key)
+		}
+		if seen[field.Key] {
+			return nil, fmt.Errorf("%w: clé %q en double", ErrInvalidAttributeSchema, field.Key)
+		}
+		seen[field.Key] = true
+
+		if field.Label == "" {
+			field.Label = field.Key
+		}
+
+		switch field.Type {
+		case models.AttributeTypeEnum:
+			if len(field.Options) == 0 {
+				return nil, fmt.Errorf("%w: %s doit lister ses options", ErrInvalidAttributeSchema, field.Key)
+			}
+		case models.AttributeTypeString, models.AttributeTypeNumber, models.AttributeTypeInteger, models.AttributeTypeBoolean:
+			field.Options = nil
+		default:
+			return nil, fmt.Errorf("%w: type %q inconnu pour %s", ErrInvalidAttributeSchema, field.Type, field.Key)
+		}
+
+		if field.Min != nil && field.Max != nil && *field.Min > *field.Max {
+			return nil, fmt.Errorf("%w: min > max pour %s", ErrInvalidAttributeSchema, field.Key)
+		}
+
+		normalized = append(normalized, field)
+	}
+
+	return normalized, nil
+}
+
+// validateListingAttributes contrôle et normalise les attributs d'une annonce selon le schéma.
+// Sans schéma, seuls des attributs vides sont acceptés.
+func validateListingAttributes(schema *models.CategoryAttributeSchema, attributes map[string]interface{}) (models.ListingAttributes, error) {
+	if schema == nil {
+		if len(attributes) > 0 {
+			return nil, fmt.Errorf("%w: cette catégorie n'accepte pas d'attributs", ErrInvalidAttributes)
+		}
+		return models.ListingAttributes{}, nil
+	}
+
+	for key := range attributes {
+		if _, ok := schema.Field(key); !ok {
+			return nil, fmt.Errorf("%w: attribut %q inconnu", ErrInvalidAttributes, key)
+		}
+	}
+
+	validated := make(models.ListingAttributes, len(attributes))
+	for _, field := range schema.Fields {
+		raw, ok := attributes[field.Key]
+		if !ok || raw == nil || raw == "" {
+			if field.Required {
+				return nil, fmt.Errorf("%w: %s est obligatoire", ErrInvalidAttributes, field.Label)
+			}
+			continue
+		}
+
+		value, err := coerceAttributeValue(&field, raw)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s %v", ErrInvalidAttributes, field.Label, err)
+		}
+		validated[field.Key] = value
+	}
+
+	return validated, nil
+}
+
+// coerceAttributeValue convertit une valeur JSON au type attendu et vérifie ses bornes
+func coerceAttributeValue(field *models.AttributeField, raw interface{}) (interface{}, error) {
+	switch field.Type {
+	case models.AttributeTypeString:
+		value, ok := raw.(string)
+		if !ok {
+			return nil, errors.New("doit être un texte")
+		}
+		value = strings.TrimSpace(value)
+		if len([]rune(value)) > maxAttributeStringLen {
+			return nil, fmt.Errorf("dépasse %d caractères", maxAttributeStringLen)
+		}
+		return value, nil
+
+	case models.AttributeTypeEnum:
+		value, ok := raw.(string)
+		if !ok {
+			return nil, errors.New("doit être un texte")
+		}
+		for _, option := range field.Options {
+			if strings.EqualFold(option, strings.TrimSpace(value)) {
+				return option, nil
+			}
+		}
+		return nil, fmt.Errorf("doit valoir %s", strings.Join(field.Options, ", "))
+
+	case models.AttributeTypeBoolean:
+		value, ok := raw.(bool)
+		if !ok {
+			return nil, errors.New("doit être vrai ou faux")
+		}
+		return value, nil
+
+	case models.AttributeTypeNumber, models.AttributeTypeInteger:
+		value, err := attributeNumber(raw)
+		if err != nil {
+			return nil, err
+		}
+		if field.Type == models.AttributeTypeInteger && value != math.Trunc(value) {
+			return nil, errors.New("doit être un nombre entier")
+		}
+		if field.Min != nil && value < *field.Min {
+			return nil, fmt.Errorf("doit être au moins %g", *field.Min)
+		}
+		if field.Max != nil && value > *field.Max {
+			return nil, fmt.Errorf("doit être au plus %g", *field.Max)
+		}
+		if field.Type == models.AttributeTypeInteger {
+			return int64(value), nil
+		}
+		return value, nil
+	}
+
+	return nil, fmt.Errorf("type %q inconnu", field.Type)
+}
+
+// attributeNumber accepte un nombre JSON ou une chaîne numérique (formulaires)
+func attributeNumber(raw interface{}) (float64, error) {
+	switch v := raw.(type) {
+	case float64:
+		return v, nil
+	case json.Number:
+		return v.Float64()
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case string:
+		value, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+			return 0, errors.New("doit être un nombre")
+		}
+		return value, nil
+	}
+	return 0, errors.New("doit être un nombre")
+}
+
+// applyAttributeFilters filtres attr.* : cle=valeur (ou v1,v2), cle_min / cle_max pour les bornes numériques
+func applyAttributeFilters(query *gorm.DB, filters map[string]string) *gorm.DB {
+	for _, key := range sortedAttributeFilterKeys(filters) {
+		value := strings.TrimSpace(filters[key])
+		if value == "" {
+			continue
+		}
+
+		if base, op := attributeRangeFilter(key); op != "" {
+			bound, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			// Les valeurs non numériques sont ignorées plutôt que de faire échouer le cast
+			query = query.Where(
+				fmt.Sprintf("(CASE WHEN jsonb_typeof(listings.attributes -> ?) = 'number' THEN (listings.attributes ->> ?)::numeric END) %s ?", op),
+				base, base, bound,
+			)
+			continue
+		}
+
+		if !attributeKeyPattern.MatchString(key) {
+			continue
+		}
+
+		// Égalité par inclusion JSONB (index GIN) ; le type de la valeur n'est pas connu ici
+		var conditions []string
+		var vars []interface{}
+		for i, term := range strings.Split(value, ",") {
+			if i >= maxAttributeFilterTerms {
+				break
+			}
+			for _, candidate := range attributeValueCandidates(strings.TrimSpace(term)) {
+				doc, _ := json.Marshal(map[string]interface{}{key: candidate})
+				conditions = append(conditions, "listings.attributes @> ?::jsonb")
+				vars = append(vars, string(doc))
+			}
+		}
+		if len(conditions) > 0 {
+			query = query.Where("("+strings.Join(conditions, " OR ")+")", vars...)
+		}
+	}
+	return query
+}
+
+// attributeRangeFilter reconnaît les suffixes _min et _max
+func attributeRangeFilter(key string) (string, string) {
+	for suffix, op := range map[string]string{"_min": ">=", "_max": "<="} {
+		if base := strings.TrimSuffix(key, suffix); base != key && attributeKeyPattern.MatchString(base) {
+			return base, op
+		}
+	}
+	return key, ""
+}
+
+// attributeValueCandidates représentations JSON possibles d'une valeur de filtre
+func attributeValueCandidates(term string) []interface{} {
+	if term == "" {
+		return nil
+	}
+	candidates := []interface{}{term}
+	if number, err := strconv.ParseFloat(term, 64); err == nil && !math.IsNaN(number) && !math.IsInf(number, 0) {
+		candidates = append(candidates, number)
+	}
+	if term == "true" || term == "false" {
+		candidates = append(candidates, term == "true")
+	}
+	return candidates
+}
+
+// sortedAttributeFilterKeys clés triées (requêtes et clés de cache déterministes)
+func sortedAttributeFilterKeys(filters map[string]string) []string {
+	keys := make([]string, 0, len(filters))
+	for key := range filters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,108 @@
+// internal/services/category_attributes_test.go
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"senmarket/internal/models"
+)
+
+func vehicleSchema() *models.CategoryAttributeSchema {
+	minYear, maxYear, minMileage := 1950.0, 2100.0, 0.0
+	return &models.CategoryAttributeSchema{
+		Version: 2,
+		Fields: models.AttributeFields{
+			{Key: "brand", Label: "Marque", Type: models.AttributeTypeString, Required: true},
+			{Key: "year", Label: "Année", Type: models.AttributeTypeInteger, Required: true, Min: &minYear, Max: &maxYear},
+			{Key: "mileage", Label: "Kilométrage", Type: models.AttributeTypeInteger, Min: &minMileage},
+			{Key: "fuel", Label: "Carburant", Type: models.AttributeTypeEnum, Options: []string{"essence", "diesel"}},
+			{Key: "automatic", Label: "Automatique", Type: models.AttributeTypeBoolean},
+		},
+	}
+}
+
+func TestValidateListingAttributes(t *testing.T) {
+	got, err := validateListingAttributes(vehicleSchema(), map[string]interface{}{
+		"brand":     " Toyota ",
+		"year":      2016.0,
+		"mileage":   "85000",
+		"fuel":      "Diesel",
+		"automatic": false,
+	})
+	if err != nil {
+		t.Fatalf("attributs valides rejetés: %v", err)
+	}
+	if got["brand"] != "Toyota" || got["year"] != int64(2016) || got["mileage"] != int64(85000) || got["fuel"] != "diesel" || got["automatic"] != false {
+		t.Errorf("attributs normalisés inattendus: %#v", got)
+	}
+
+	tests := map[string]map[string]interface{}{
+		"obligatoire manquant": {"brand": "Toyota"},
+		"attribut inconnu":     {"brand": "Toyota", "year": 2016.0, "color": "rouge"},
+		"entier décimal":       {"brand": "Toyota", "year": 2016.5},
+		"hors bornes":          {"brand": "Toyota", "year": 1900.0},
+		"option inconnue":      {"brand": "Toyota", "year": 2016.0, "fuel": "charbon"},
+		"mauvais type":         {"brand": 42.0, "year": 2016.0},
+		"booléen texte":        {"brand": "Toyota", "year": 2016.0, "automatic": "oui"},
+	}
+	for name, attributes := range tests {
+		if _, err := validateListingAttributes(vehicleSchema(), attributes); !errors.Is(err, ErrInvalidAttributes) {
+			t.Errorf("%s: erreur attendue ErrInvalidAttributes, obtenu %v", name, err)
+		}
+	}
+
+	if _, err := validateListingAttributes(nil, map[string]interface{}{"brand": "Toyota"}); !errors.Is(err, ErrInvalidAttributes) {
+		t.Errorf("catégorie sans schéma: erreur attendue, obtenu %v", err)
+	}
+}
+
+func TestNormalizeAttributeSchema(t *testing.T) {
+	fields, err := normalizeAttributeSchema([]models.AttributeField{
+		{Key: "rooms", Type: models.AttributeTypeInteger, Options: []string{"ignoré"}},
+		{Key: "transaction", Label: "Transaction", Type: models.AttributeTypeEnum, Options: []string{"location", "vente"}},
+	})
+	if err != nil {
+		t.Fatalf("schéma valide rejeté: %v", err)
+	}
+	if fields[0].Label != "rooms" || fields[0].Options != nil {
+		t.Errorf("champ normalisé inattendu: %+v", fields[0])
+	}
+
+	min, max := 10.0, 1.0
+	tests := map[string][]models.AttributeField{
+		"clé invalide":     {{Key: "Année", Type: models.AttributeTypeInteger}},
+		"clé en double":    {{Key: "year", Type: models.AttributeTypeInteger}, {Key: "year", Type: models.AttributeTypeString}},
+		"type inconnu":     {{Key: "year", Type: "date"}},
+		"enum sans liste":  {{Key: "fuel", Type: models.AttributeTypeEnum}},
+		"bornes inversées": {{Key: "year", Type: models.AttributeTypeNumber, Min: &min, Max: &max}},
+	}
+	for name, fields := range tests {
+		if _, err := normalizeAttributeSchema(fields); !errors.Is(err, ErrInvalidAttributeSchema) {
+			t.Errorf("%s: erreur attendue ErrInvalidAttributeSchema, obtenu %v", name, err)
+		}
+	}
+}
+
+func TestAttributeRangeFilter(t *testing.T) {
+	tests := []struct {
+		key, base, op string
+	}{
+		{"year_min", "year", ">="},
+		{"mileage_max", "mileage", "<="},
+		{"fuel", "fuel", ""},
+		{"_min", "_min", ""},
+	}
+	for _, tt := range tests {
+		if base, op := attributeRangeFilter(tt.key); base != tt.base || op != tt.op {
+			t.Errorf("attributeRangeFilter(%q) = %q, %q, attendu %q, %q", tt.key, base, op, tt.base, tt.op)
+		}
+	}
+
+	if got := attributeValueCandidates("3"); len(got) != 2 || got[1] != 3.0 {
+		t.Errorf("candidats pour 3 = %v", got)
+	}
+	if got := attributeValueCandidates("true"); len(got) != 2 || got[1] != true {
+		t.Errorf("candidats pour true = %v", got)
+	}
+}
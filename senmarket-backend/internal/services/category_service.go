@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"strings"
 
 	"senmarket/internal/models"
 	"senmarket/internal/repository/redis"
@@ -85,7 +86,7 @@ func (s *CategoryService) GetCategoriesWithStats() ([]CategoryWithStats, error)
 		Joins(`LEFT JOIN (
 			SELECT category_id, COUNT(*) as count 
 			FROM listings 
-			WHERE status = 'published' AND deleted_at IS NULL 
+			WHERE status = 'active' AND deleted_at IS NULL 
 			GROUP BY category_id
 		) as listing_counts ON categories.id = listing_counts.category_id`).
 		Where("categories.is_active = ?", true).
@@ -95,6 +96,9 @@ func (s *CategoryService) GetCategoriesWithStats() ([]CategoryWithStats, error)
 	if err != nil {
 		return nil, fmt.Errorf("erreur récupération catégories avec stats: %w", err)
 	}
+
+	// Remonter les comptages des sous-catégories vers leurs parents
+	aggregateCategoryCounts(results)
 	
 	// 🔴 3. Mettre en cache
 	go func() {
@@ -160,8 +164,9 @@ func (s *CategoryService) GetCategoryByID(id string) (*models.Category, error) {
 	return &category, nil
 }
 
-// GetCategoryBySlug récupère une catégorie par slug avec cache
+// GetCategoryBySlug récupère une catégorie par slug ou chemin ("vehicles/cars") avec cache
 func (s *CategoryService) GetCategoryBySlug(slug string) (*models.Category, error) {
+	slug = strings.Trim(slug, "/")
 	ctx := context.Background()
 	
 	// 🔴 1. Essayer le cache d'abord
@@ -175,13 +180,9 @@ func (s *CategoryService) GetCategoryBySlug(slug string) (*models.Category, erro
 	log.Printf("🔴 Cache MISS - Category slug depuis DB %s", slug)
 	
 	// 🔴 2. Récupérer depuis la base
-	var category models.Category
-	if err := s.db.Where("slug = ? AND is_active = ?", slug, true).
-		First(&category).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, ErrCategoryNotFound
-		}
-		return nil, fmt.Errorf("erreur récupération catégorie: %w", err)
+	category, err := findCategoryByPath(s.db, slug)
+	if err != nil {
+		return nil, err
 	}
 	
 	// 🔴 3. Mettre en cache
@@ -191,30 +192,51 @@ func (s *CategoryService) GetCategoryBySlug(slug string) (*models.Category, erro
 		}
 	}()
 	
-	return &category, nil
+	return category, nil
 }
 
-// GetListingsByCategory méthode simplifiée
+// GetListingsByCategory récupère les annonces actives d'une catégorie (filtres, tri, page ou curseur)
 func (s *CategoryService) GetListingsByCategory(categoryID string, query *ListingQuery) (*ListingResponse, error) {
 	// Vérifier que la catégorie existe
 	if _, err := s.GetCategoryByID(categoryID); err != nil {
 		return nil, err
 	}
 
-	// Pour l'instant, retourner une réponse simple
-	// TODO: Implémenter la logique complète quand ListingService sera mis à jour
-	var listings []models.Listing
-	
-	dbQuery := s.db.Model(&models.Listing{}).
-		Where("category_id = ? AND status = ? AND deleted_at IS NULL", categoryID, "published")
+	if query.Page <= 0 {
+		query.Page = 1
+	}
+	if query.Limit <= 0 || query.Limit > 50 {
+		query.Limit = 20
+	}
+
+	filters := map[string]interface{}{
+		"category_id": categoryID,
+		"region":      query.Region,
+		"min_price":   query.MinPrice,
+		"max_price":   query.MaxPrice,
+		"search":      query.Search,
+		"attributes":  query.Attributes,
+	}
+	dbQuery := applyListingFilters(s.db.Model(&models.Listing{}).Where("listings.status = ?", "active"), filters)
 	
 	// Compter le total
 	var total int64
-	dbQuery.Count(&total)
+	if err := dbQuery.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("erreur comptage listings: %w", err)
+	}
 	
-	// Pagination simple
-	offset := (query.Page - 1) * query.Limit
-	if err := dbQuery.Offset(offset).Limit(query.Limit).Find(&listings).Error; err != nil {
+	// Tri stable puis page ou curseur
+	sort := resolveListingSort(query.Sort, normalizeSearchQuery(query.Search), nil)
+	dbQuery, err := sort.apply(dbQuery, query.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	if query.Cursor == "" {
+		dbQuery = dbQuery.Offset((query.Page - 1) * query.Limit)
+	}
+
+	var listings []models.Listing
+	if err := dbQuery.Limit(query.Limit).Find(&listings).Error; err != nil {
 		return nil, fmt.Errorf("erreur récupération listings: %w", err)
 	}
 	
@@ -226,6 +248,7 @@ func (s *CategoryService) GetListingsByCategory(categoryID string, query *Listin
 			Limit:      query.Limit,
 			Total:      total,
 			TotalPages: int((total + int64(query.Limit) - 1) / int64(query.Limit)),
+			NextCursor: sort.nextCursor(listings, query.Limit),
 		},
 	}
 	
@@ -254,28 +277,34 @@ func (s *CategoryService) GetCategoryStats(categoryID string) (map[string]interf
 	stats := make(map[string]interface{})
 	stats["category"] = category
 
-	// Comptage total des annonces
+	// Comptage total des annonces (sous-catégories incluses)
 	var totalListings int64
-	s.db.Model(&models.Listing{}).
-		Where("category_id = ? AND deleted_at IS NULL", categoryID).
+	applyCategoryFilter(s.db.Model(&models.Listing{}), categoryID).
 		Count(&totalListings)
 	stats["total_listings"] = totalListings
 
 	// Comptage par statut
 	var activeListings int64
-	s.db.Model(&models.Listing{}).
-		Where("category_id = ? AND status = ? AND deleted_at IS NULL", categoryID, "published").
+	applyCategoryFilter(s.db.Model(&models.Listing{}), categoryID).
+		Where("listings.status = ?", "active").
 		Count(&activeListings)
 	stats["active_listings"] = activeListings
 
 	// Prix moyen
 	var avgPrice float64
-	s.db.Model(&models.Listing{}).
-		Select("AVG(price)").
-		Where("category_id = ? AND status = ? AND deleted_at IS NULL", categoryID, "published").
+	applyCategoryFilter(s.db.Model(&models.Listing{}), categoryID).
+		Select("COALESCE(AVG(listings.price), 0)").
+		Where("listings.status = ?", "active").
 		Scan(&avgPrice)
 	stats["average_price"] = avgPrice
 
+	// Résultats des ventes déclarées
+	if sales, err := salesOutcomeSummary(s.db, categoryID); err == nil {
+		stats["sales"] = sales
+	} else {
+		log.Printf("⚠️ %v", err)
+	}
+
 	// 🔴 3. Mettre en cache
 	go func() {
 		if err := s.cacheService.CacheCategoryStats(ctx, categoryID, stats); err != nil {
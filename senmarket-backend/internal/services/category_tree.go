@@ -0,0 +1,129 @@
+// internal/services/category_tree.go
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"senmarket/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// Profondeur maximale parcourue (protège contre des données incohérentes)
+	maxCategoryDepth = 10
+
+	// Sous-requête : la catégorie et toutes ses descendantes
+	categoryDescendantsSQL = `WITH RECURSIVE category_tree AS (
+		SELECT id FROM categories WHERE id = ?
+		UNION
+		SELECT c.id FROM categories c JOIN category_tree t ON c.parent_id = t.id WHERE c.deleted_at IS NULL
+	) SELECT id FROM category_tree`
+)
+
+// CategoryTreeNode catégorie et ses sous-catégories, avec comptages agrégés
+type CategoryTreeNode struct {
+	CategoryWithStats
+	Children []*CategoryTreeNode `json:"children"`
+}
+
+// GetCategoryTree arborescence des catégories actives, comptages remontés aux parents
+func (s *CategoryService) GetCategoryTree() ([]*CategoryTreeNode, error) {
+	stats, err := s.GetCategoriesWithStats()
+	if err != nil {
+		return nil, err
+	}
+	return buildCategoryTree(stats), nil
+}
+
+// applyCategoryFilter restreint aux annonces de la catégorie et de ses descendantes
+func applyCategoryFilter(query *gorm.DB, categoryID string) *gorm.DB {
+	return query.Where("listings.category_id IN ("+categoryDescendantsSQL+")", categoryID)
+}
+
+// aggregateCategoryCounts ajoute aux parents les annonces de leurs descendantes
+func aggregateCategoryCounts(stats []CategoryWithStats) {
+	index := make(map[string]int, len(stats))
+	for i := range stats {
+		index[stats[i].ID.String()] = i
+		stats[i].DirectListingCount = stats[i].ListingCount
+	}
+
+	for i := range stats {
+		parentID := stats[i].ParentID
+		for depth := 0; parentID != nil && depth < maxCategoryDepth; depth++ {
+			j, ok := index[parentID.String()]
+			if !ok {
+				break
+			}
+			stats[j].ListingCount += stats[i].DirectListingCount
+			parentID = stats[j].ParentID
+		}
+	}
+}
+
+// buildCategoryTree construit l'arbre en conservant l'ordre reçu ;
+// une catégorie dont le parent est absent (inactif) devient racine
+func buildCategoryTree(stats []CategoryWithStats) []*CategoryTreeNode {
+	nodes := make(map[string]*CategoryTreeNode, len(stats))
+	for i := range stats {
+		nodes[stats[i].ID.String()] = &CategoryTreeNode{
+			CategoryWithStats: stats[i],
+			Children:          []*CategoryTreeNode{},
+		}
+	}
+
+	roots := []*CategoryTreeNode{}
+	for i := range stats {
+		node := nodes[stats[i].ID.String()]
+		if stats[i].ParentID != nil {
+			if parent, ok := nodes[stats[i].ParentID.String()]; ok && parent != node {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+	return roots
+}
+
+// findCategoryByPath résout un slug simple ("cars") ou un chemin ("vehicles/cars")
+func findCategoryByPath(db *gorm.DB, path string) (*models.Category, error) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) == 0 || len(segments) > maxCategoryDepth {
+		return nil, ErrCategoryNotFound
+	}
+
+	// Les slugs sont uniques : la feuille identifie la catégorie, les ancêtres sont vérifiés
+	var category models.Category
+	if err := db.Where("slug = ? AND is_active = ?", segments[len(segments)-1], true).
+		First(&category).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCategoryNotFound
+		}
+		return nil, fmt.Errorf("erreur récupération catégorie: %w", err)
+	}
+
+	parentID := category.ParentID
+	for i := len(segments) - 2; i >= 0; i-- {
+		if parentID == nil {
+			return nil, ErrCategoryNotFound
+		}
+
+		var parent models.Category
+		if err := db.Select("id", "slug", "parent_id").First(&parent, "id = ?", *parentID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrCategoryNotFound
+			}
+			return nil, fmt.Errorf("erreur récupération catégorie parente: %w", err)
+		}
+		if parent.Slug != segments[i] {
+			return nil, ErrCategoryNotFound
+		}
+		parentID = parent.ParentID
+	}
+
+	return &category, nil
+}
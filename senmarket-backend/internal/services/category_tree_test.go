@@ -0,0 +1,56 @@
+// internal/services/category_tree_test.go
+package services
+
+import (
+	"testing"
+
+	"senmarket/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func categoryStat(id uuid.UUID, parent *uuid.UUID, slug string, count int64) CategoryWithStats {
+	return CategoryWithStats{
+		Category:     models.Category{ID: id, ParentID: parent, Slug: slug},
+		ListingCount: count,
+	}
+}
+
+func TestCategoryTreeAggregatesCounts(t *testing.T) {
+	vehicles, cars, electric, animals := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	orphanParent := uuid.New() // Parent inactif, absent de la liste
+
+	stats := []CategoryWithStats{
+		categoryStat(vehicles, nil, "vehicles", 2),
+		categoryStat(cars, &vehicles, "cars", 5),
+		categoryStat(electric, &cars, "electric-cars", 1),
+		categoryStat(animals, nil, "animals", 3),
+		categoryStat(uuid.New(), &orphanParent, "orphan", 4),
+	}
+
+	aggregateCategoryCounts(stats)
+
+	want := map[string][2]int64{
+		"vehicles":      {8, 2},
+		"cars":          {6, 5},
+		"electric-cars": {1, 1},
+		"animals":       {3, 3},
+		"orphan":        {4, 4},
+	}
+	for _, stat := range stats {
+		if got := [2]int64{stat.ListingCount, stat.DirectListingCount}; got != want[stat.Slug] {
+			t.Errorf("%s: total/direct = %v, attendu %v", stat.Slug, got, want[stat.Slug])
+		}
+	}
+
+	tree := buildCategoryTree(stats)
+	if len(tree) != 3 {
+		t.Fatalf("%d racines, attendu 3 (vehicles, animals, orphan)", len(tree))
+	}
+	if tree[0].Slug != "vehicles" || len(tree[0].Children) != 1 || tree[0].Children[0].Slug != "cars" {
+		t.Fatalf("arbre inattendu sous vehicles: %+v", tree[0])
+	}
+	if grandChildren := tree[0].Children[0].Children; len(grandChildren) != 1 || grandChildren[0].ListingCount != 1 {
+		t.Errorf("petits-enfants inattendus: %+v", grandChildren)
+	}
+}
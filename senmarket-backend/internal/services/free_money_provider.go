@@ -0,0 +1,82 @@
+// internal/services/free_money_provider.go
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"senmarket/internal/models"
+)
+
+// FreeMoneyProvider provider Free Money. Faute d'API marchand, il n'est pas enregistré
+// dans le registre : Initiate refuse tout paiement plutôt que de renvoyer un faux lien.
+type FreeMoneyProvider struct {
+	verifier *HMACWebhookVerifier
+}
+
+func NewFreeMoneyProvider(webhookSecret, environment string) *FreeMoneyProvider {
+	return &FreeMoneyProvider{
+		verifier: NewHMACWebhookVerifier(webhookSecret, environment),
+	}
+}
+
+func (p *FreeMoneyProvider) Name() string {
+	return "free_money"
+}
+
+// Initiate n'est pas disponible : Free Money n'expose pas encore d'API de paiement marchand
+func (p *FreeMoneyProvider) Initiate(payment *models.Payment, phone string) (*PaymentInitiation, error) {
+	return nil, fmt.Errorf("%w: paiement Free Money", ErrOperationNotSupported)
+}
+
+// VerifySignature vérifie la signature HMAC du secret webhook
+func (p *FreeMoneyProvider) VerifySignature(headers http.Header, body []byte) error {
+	return p.verifier.Verify(headers, body)
+}
+
+// ParseWebhook décode une notification Free Money (même format qu'Orange Money)
+func (p *FreeMoneyProvider) ParseWebhook(body []byte) (*PaymentNotification, error) {
+	var payload struct {
+		OrderID       string  `json:"order_id"`
+		Status        string  `json:"status"`
+		Amount        float64 `json:"amount"`
+		Currency      string  `json:"currency"`
+		TransactionID string  `json:"transaction_id"`
+		FailureReason string  `json:"failure_reason"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("erreur décodage webhook Free Money: %w", err)
+	}
+
+	if payload.OrderID == "" {
+		return nil, errors.New("order_id manquant")
+	}
+	if payload.Status == "" {
+		return nil, errors.New("status manquant")
+	}
+
+	notification := &PaymentNotification{
+		PaymentID:     payload.OrderID,
+		Status:        orangeMoneyPaymentStatus(payload.Status),
+		Amount:        payload.Amount,
+		Currency:      payload.Currency,
+		FailureReason: payload.FailureReason,
+	}
+	if len(payload.OrderID) >= 8 {
+		notification.Reference = "FREE-" + payload.OrderID[:8]
+	}
+
+	return notification, nil
+}
+
+// QueryStatus non disponible sans API marchand
+func (p *FreeMoneyProvider) QueryStatus(payment *models.Payment) (*PaymentNotification, error) {
+	return nil, fmt.Errorf("%w: statut Free Money", ErrOperationNotSupported)
+}
+
+// Refund non disponible sans API marchand
+func (p *FreeMoneyProvider) Refund(payment *models.Payment, amount float64) error {
+	return fmt.Errorf("%w: remboursement Free Money", ErrOperationNotSupported)
+}
@@ -0,0 +1,260 @@
+// internal/services/listing_boost.go
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"senmarket/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrListingNotBoostable = errors.New("seule une annonce active peut être mise en avant")
+
+// isListingOptionPurpose indique si le paiement achète une option premium d'annonce
+func isListingOptionPurpose(purpose string) bool {
+	return purpose == models.PaymentPurposeBoost || purpose == models.PaymentPurposeHighlight
+}
+
+// EnsureListingBoostable vérifie que l'annonce existe, appartient à l'utilisateur et est publiée
+func (s *ListingService) EnsureListingBoostable(userID, listingID uuid.UUID) error {
+	var listing models.Listing
+	if err := s.db.Select("id", "status").
+		Where("id = ? AND user_id = ?", listingID, userID).
+		First(&listing).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrListingNotFound
+		}
+		return fmt.Errorf("erreur récupération annonce: %w", err)
+	}
+
+	if listing.Status != "active" {
+		return ErrListingNotBoostable
+	}
+	return nil
+}
+
+// ApplyListingOptionTx active l'option payée dans la transaction du paiement.
+// Un nouvel achat prolonge une option encore en cours au lieu de la remplacer.
+func (s *ListingService) ApplyListingOptionTx(tx *gorm.DB, payment *models.Payment) error {
+	config, err := s.quotaService.GetGlobalConfig()
+	if err != nil {
+		return err
+	}
+	_, days, ok := config.GetListingOptionPrice(payment.Purpose)
+	if !ok {
+		return fmt.Errorf("option d'annonce inconnue: %s", payment.Purpose)
+	}
+
+	var listing models.Listing
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", *payment.ListingID).
+		First(&listing).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrListingNotFound
+		}
+		return fmt.Errorf("erreur récupération annonce: %w", err)
+	}
+
+	current := listing.BoostedUntil
+	if payment.Purpose == models.PaymentPurposeHighlight {
+		current = listing.HighlightedUntil
+	}
+
+	startsAt := time.Now()
+	if current != nil && current.After(startsAt) {
+		startsAt = *current
+	}
+	endsAt := startsAt.AddDate(0, 0, days)
+
+	boost := models.ListingBoost{
+		ListingID: listing.ID,
+		UserID:    payment.UserID,
+		PaymentID: &payment.ID,
+		Type:      payment.Purpose,
+		StartsAt:  startsAt,
+		EndsAt:    endsAt,
+	}
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&boost)
+	if result.Error != nil {
+		return fmt.Errorf("erreur enregistrement option: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		log.Printf("⚠️ Paiement %s: option déjà appliquée", payment.ID)
+		return nil
+	}
+
+	updates := map[string]interface{}{
+		"is_featured":   true,
+		"boosted_until": endsAt,
+	}
+	if payment.Purpose == models.PaymentPurposeHighlight {
+		updates = map[string]interface{}{
+			"is_highlighted":    true,
+			"highlighted_until": endsAt,
+		}
+	}
+	if err := tx.Model(&listing).UpdateColumns(updates).Error; err != nil {
+		return fmt.Errorf("erreur activation option: %w", err)
+	}
+
+	log.Printf("🚀 Annonce %s: %s actif jusqu'au %s", listing.ID, payment.Purpose, endsAt.Format("2006-01-02 15:04"))
+	return nil
+}
+
+// revokeListingOptionTx retire la fenêtre d'option achetée par un paiement remboursé : elle est close
+// immédiatement, les fenêtres achetées ensuite (enchaînées à sa fin) sont avancées d'autant
+// et l'échéance de l'annonce est recalculée. Retourne true si l'annonce a été modifiée.
+func revokeListingOptionTx(tx *gorm.DB, payment *models.Payment, now time.Time) (bool, error) {
+	var boost models.ListingBoost
+	if err := tx.Where("payment_id = ?", payment.ID).First(&boost).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil // Option jamais appliquée
+		}
+		return false, fmt.Errorf("erreur récupération option: %w", err)
+	}
+
+	var listing models.Listing
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", boost.ListingID).
+		First(&listing).Error; err != nil {
+		return false, fmt.Errorf("erreur récupération annonce: %w", err)
+	}
+
+	closedAt := revokedWindowEnd(boost.StartsAt, boost.EndsAt, now)
+	removed := boost.EndsAt.Sub(closedAt)
+	if removed <= 0 {
+		return false, nil // Fenêtre déjà écoulée
+	}
+
+	var later []models.ListingBoost
+	if err := tx.Where("listing_id = ? AND type = ? AND id <> ? AND starts_at >= ?",
+		boost.ListingID, boost.Type, boost.ID, boost.EndsAt).
+		Find(&later).Error; err != nil {
+		return false, fmt.Errorf("erreur récupération options suivantes: %w", err)
+	}
+	if err := tx.Model(&boost).UpdateColumn("ends_at", closedAt).Error; err != nil {
+		return false, fmt.Errorf("erreur retrait option: %w", err)
+	}
+	for i := range later {
+		if err := tx.Model(&later[i]).UpdateColumns(map[string]interface{}{
+			"starts_at": later[i].StartsAt.Add(-removed),
+			"ends_at":   later[i].EndsAt.Add(-removed),
+		}).Error; err != nil {
+			return false, fmt.Errorf("erreur décalage option: %w", err)
+		}
+	}
+
+	// Nouvelle échéance : la dernière fenêtre encore en cours, sinon fin de l'option
+	var until *time.Time
+	var last models.ListingBoost
+	err := tx.Where("listing_id = ? AND type = ? AND ends_at > ?", boost.ListingID, boost.Type, now).
+		Order("ends_at DESC").
+		First(&last).Error
+	if err == nil {
+		until = &last.EndsAt
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, fmt.Errorf("erreur recalcul option: %w", err)
+	}
+
+	updates := map[string]interface{}{
+		"is_featured":   until != nil,
+		"boosted_until": until,
+	}
+	if boost.Type == models.PaymentPurposeHighlight {
+		updates = map[string]interface{}{
+			"is_highlighted":    until != nil,
+			"highlighted_until": until,
+		}
+	}
+	if err := tx.Model(&listing).UpdateColumns(updates).Error; err != nil {
+		return false, fmt.Errorf("erreur retrait option: %w", err)
+	}
+
+	log.Printf("↩️ Annonce %s: %s remboursé, fenêtre retirée", listing.ID, boost.Type)
+	return true, nil
+}
+
+// revokedWindowEnd fin d'une fenêtre retirée à now : la partie déjà écoulée est conservée
+func revokedWindowEnd(startsAt, endsAt, now time.Time) time.Time {
+	if now.Before(startsAt) {
+		return startsAt
+	}
+	if now.After(endsAt) {
+		return endsAt
+	}
+	return now
+}
+
+// ExpireBoosts retire les mises en avant et mises en couleur arrivées à échéance
+func (s *ListingService) ExpireBoosts() (int64, error) {
+	now := time.Now()
+	var expired int64
+
+	result := s.db.Model(&models.Listing{}).
+		Where("is_featured = ? AND (boosted_until IS NULL OR boosted_until <= ?)", true, now).
+		UpdateColumn("is_featured", false)
+	if result.Error != nil {
+		return 0, fmt.Errorf("erreur expiration boosts: %w", result.Error)
+	}
+	expired += result.RowsAffected
+
+	result = s.db.Model(&models.Listing{}).
+		Where("is_highlighted = ? AND (highlighted_until IS NULL OR highlighted_until <= ?)", true, now).
+		UpdateColumn("is_highlighted", false)
+	if result.Error != nil {
+		return expired, fmt.Errorf("erreur expiration mises en couleur: %w", result.Error)
+	}
+	expired += result.RowsAffected
+
+	if expired > 0 {
+		if err := s.cacheService.InvalidateListingsCache(context.Background()); err != nil {
+			log.Printf("Erreur invalidation cache: %v", err)
+		}
+	}
+
+	return expired, nil
+}
+
+// ListingBoostScheduler retire périodiquement les options expirées
+type ListingBoostScheduler struct {
+	listingService *ListingService
+	interval       time.Duration
+}
+
+func NewListingBoostScheduler(listingService *ListingService, interval time.Duration) *ListingBoostScheduler {
+	return &ListingBoostScheduler{
+		listingService: listingService,
+		interval:       interval,
+	}
+}
+
+// Start lance la boucle périodique jusqu'à l'annulation du contexte
+func (s *ListingBoostScheduler) Start(ctx context.Context) {
+	log.Printf("⏱️ Expiration des boosts active (toutes les %s)", s.interval)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			expired, err := s.listingService.ExpireBoosts()
+			if err != nil {
+				log.Printf("❌ Expiration boosts: %v", err)
+				continue
+			}
+			if expired > 0 {
+				log.Printf("⏱️ %d options premium expirées retirées", expired)
+			}
+		}
+	}
+}
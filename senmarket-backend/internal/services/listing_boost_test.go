@@ -0,0 +1,72 @@
+// internal/services/listing_boost_test.go
+package services
+
+import (
+	"testing"
+	"time"
+
+	"senmarket/internal/models"
+)
+
+func TestListingOptionPrice(t *testing.T) {
+	config := models.GetDefaultPricingConfig()
+
+	price, days, ok := config.GetListingOptionPrice(models.PaymentPurposeBoost)
+	if !ok || price != config.PremiumBoostPrice || days != models.ListingBoostDurationDays {
+		t.Fatalf("boost: %.0f FCFA, %d jours, %v", price, days, ok)
+	}
+
+	price, days, ok = config.GetListingOptionPrice(models.PaymentPurposeHighlight)
+	if !ok || price != config.FeaturedColorPrice || days != models.ListingHighlightDurationDays {
+		t.Fatalf("mise en couleur: %.0f FCFA, %d jours, %v", price, days, ok)
+	}
+
+	if _, _, ok := config.GetListingOptionPrice(models.PaymentPurposePack5); ok {
+		t.Fatal("un pack n'est pas une option d'annonce")
+	}
+
+	if !isListingOptionPurpose(models.PaymentPurposeHighlight) || isListingOptionPurpose(models.PaymentPurposeListing) {
+		t.Fatal("détection des options incorrecte")
+	}
+}
+
+func TestListingIsBoosted(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		listing models.Listing
+		want    bool
+	}{
+		{models.Listing{IsFeatured: true, BoostedUntil: &future}, true},
+		{models.Listing{IsFeatured: true, BoostedUntil: &past}, false},
+		{models.Listing{IsFeatured: true}, false}, // mise en avant gratuite héritée
+		{models.Listing{IsFeatured: false, BoostedUntil: &future}, false},
+	}
+
+	for i, tt := range tests {
+		if got := tt.listing.IsBoosted(); got != tt.want {
+			t.Errorf("cas %d: IsBoosted = %v, attendu %v", i, got, tt.want)
+		}
+	}
+}
+
+func TestRevokedWindowEnd(t *testing.T) {
+	now := time.Now()
+	day := 24 * time.Hour
+
+	tests := []struct {
+		name             string
+		startsAt, endsAt time.Time
+		want             time.Time
+	}{
+		{"fenêtre en cours", now.Add(-day), now.Add(day), now},
+		{"fenêtre à venir (enchaînée)", now.Add(day), now.Add(2 * day), now.Add(day)},
+		{"fenêtre écoulée", now.Add(-2 * day), now.Add(-day), now.Add(-day)},
+	}
+	for _, tt := range tests {
+		if got := revokedWindowEnd(tt.startsAt, tt.endsAt, now); !got.Equal(tt.want) {
+			t.Errorf("%s: fin = %v, attendu %v", tt.name, got, tt.want)
+		}
+	}
+}
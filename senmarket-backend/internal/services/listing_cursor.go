@@ -0,0 +1,192 @@
+// internal/services/listing_cursor.go
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"senmarket/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrInvalidCursor = errors.New("curseur de pagination invalide")
+
+const (
+	ListingSortNewest    = "newest"
+	ListingSortOldest    = "oldest"
+	ListingSortPriceAsc  = "price_asc"
+	ListingSortPriceDesc = "price_desc"
+	ListingSortViews     = "views"
+	ListingSortRelevance = "relevance"
+	ListingSortDistance  = "distance"
+)
+
+// listingSort tri d'une liste d'annonces : colonne (ou expression) puis ID pour départager
+type listingSort struct {
+	Key    string
+	Column string
+	Vars   []interface{}
+	Desc   bool
+}
+
+// listingCursor position dans une liste triée, encodée de façon opaque pour le client
+type listingCursor struct {
+	Sort  string    `json:"s"`
+	Value string    `json:"v,omitempty"`
+	ID    uuid.UUID `json:"id"`
+}
+
+// resolveListingSort tri demandé ; pertinence par défaut si recherche plein texte,
+// tri par distance uniquement si un point de référence est fourni
+func resolveListingSort(sort, search string, near *GeoPoint) listingSort {
+	if sort == ListingSortDistance && near != nil {
+		return listingSort{
+			Key:    ListingSortDistance,
+			Column: listingDistanceSQL,
+			Vars:   []interface{}{near.Lat, near.Lng},
+		}
+	}
+
+	switch sort {
+	case ListingSortPriceAsc:
+		return listingSort{Key: ListingSortPriceAsc, Column: "listings.price"}
+	case ListingSortPriceDesc:
+		return listingSort{Key: ListingSortPriceDesc, Column: "listings.price", Desc: true}
+	case ListingSortViews:
+		return listingSort{Key: ListingSortViews, Column: "listings.views_count", Desc: true}
+	case ListingSortOldest:
+		return listingSort{Key: ListingSortOldest, Column: "listings.created_at"}
+	case ListingSortNewest, "date": // "date" : alias utilisé par le frontend
+		return listingSort{Key: ListingSortNewest, Column: "listings.created_at", Desc: true}
+	}
+
+	if search != "" {
+		return listingSort{
+			Key:    ListingSortRelevance,
+			Column: "ts_rank(listings.search_vector, websearch_to_tsquery(?, ?))",
+			Vars:   []interface{}{listingSearchConfig, search},
+			Desc:   true,
+		}
+	}
+	return listingSort{Key: ListingSortNewest, Column: "listings.created_at", Desc: true}
+}
+
+func (ls listingSort) direction() string {
+	if ls.Desc {
+		return "DESC"
+	}
+	return "ASC"
+}
+
+// orderBy clause ORDER BY stable (colonne puis ID)
+func (ls listingSort) orderBy() clause.OrderBy {
+	return clause.OrderBy{Expression: clause.Expr{
+		SQL:                fmt.Sprintf("%s %s, listings.id %s", ls.Column, ls.direction(), ls.direction()),
+		Vars:               ls.Vars,
+		WithoutParentheses: true,
+	}}
+}
+
+// apply trie la requête et, si un curseur est fourni, reprend après sa position
+func (ls listingSort) apply(query *gorm.DB, encoded string) (*gorm.DB, error) {
+	query = query.Order(ls.orderBy())
+	if encoded == "" {
+		return query, nil
+	}
+
+	cursor, err := decodeListingCursor(encoded, ls.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	op := ">"
+	if ls.Desc {
+		op = "<"
+	}
+
+	// Pertinence, distance : la valeur de l'annonce de référence est recalculée par PostgreSQL
+	if ls.computed() {
+		vars := append(append([]interface{}{}, ls.Vars...), ls.Vars...)
+		vars = append(vars, cursor.ID, cursor.ID)
+		return query.Where(
+			fmt.Sprintf("(%s, listings.id) %s ((SELECT %s FROM listings WHERE id = ?), ?)", ls.Column, op, ls.Column),
+			vars...,
+		), nil
+	}
+
+	value, err := ls.parseValue(cursor.Value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return query.Where(fmt.Sprintf("(%s, listings.id) %s (?, ?)", ls.Column, op), value, cursor.ID), nil
+}
+
+// computed tri sur une expression dépendant de la requête (non stockée dans le curseur)
+func (ls listingSort) computed() bool {
+	return ls.Key == ListingSortRelevance || ls.Key == ListingSortDistance
+}
+
+// parseValue convertit la valeur du curseur selon la colonne triée
+func (ls listingSort) parseValue(value string) (interface{}, error) {
+	switch ls.Key {
+	case ListingSortPriceAsc, ListingSortPriceDesc:
+		return strconv.ParseFloat(value, 64)
+	case ListingSortViews:
+		return strconv.Atoi(value)
+	default:
+		return time.Parse(time.RFC3339Nano, value)
+	}
+}
+
+// cursorFor curseur pointant sur une annonce
+func (ls listingSort) cursorFor(listing *models.Listing) string {
+	cursor := listingCursor{Sort: ls.Key, ID: listing.ID}
+	switch ls.Key {
+	case ListingSortPriceAsc, ListingSortPriceDesc:
+		cursor.Value = strconv.FormatFloat(listing.Price, 'f', -1, 64)
+	case ListingSortViews:
+		cursor.Value = strconv.Itoa(listing.ViewsCount)
+	case ListingSortRelevance, ListingSortDistance:
+		// Valeur recalculée à partir de l'ID
+	default:
+		cursor.Value = listing.CreatedAt.Format(time.RFC3339Nano)
+	}
+	return encodeListingCursor(cursor)
+}
+
+// nextCursor curseur de la page suivante, vide si la page n'est pas pleine
+func (ls listingSort) nextCursor(listings []models.Listing, limit int) string {
+	if limit <= 0 || len(listings) < limit {
+		return ""
+	}
+	return ls.cursorFor(&listings[len(listings)-1])
+}
+
+func encodeListingCursor(cursor listingCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeListingCursor décode un curseur et vérifie qu'il correspond au tri courant
+func decodeListingCursor(encoded, sortKey string) (*listingCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor listingCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if cursor.Sort != sortKey || cursor.ID == uuid.Nil {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
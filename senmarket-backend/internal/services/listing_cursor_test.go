@@ -0,0 +1,125 @@
+// internal/services/listing_cursor_test.go
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"senmarket/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func TestResolveListingSort(t *testing.T) {
+	tests := []struct {
+		sort, search string
+		key          string
+		desc         bool
+	}{
+		{"", "", ListingSortNewest, true},
+		{"date", "", ListingSortNewest, true},
+		{"oldest", "", ListingSortOldest, false},
+		{"price_asc", "villa", ListingSortPriceAsc, false},
+		{"price_desc", "", ListingSortPriceDesc, true},
+		{"views", "", ListingSortViews, true},
+		{"", "villa", ListingSortRelevance, true},
+		{"relevance", "", ListingSortNewest, true},
+		{"inconnu", "", ListingSortNewest, true},
+		{"distance", "", ListingSortNewest, true},
+	}
+	for _, tt := range tests {
+		got := resolveListingSort(tt.sort, tt.search, nil)
+		if got.Key != tt.key || got.Desc != tt.desc {
+			t.Errorf("resolveListingSort(%q, %q) = %s/%v, attendu %s/%v", tt.sort, tt.search, got.Key, got.Desc, tt.key, tt.desc)
+		}
+	}
+}
+
+func TestListingCursorRoundTrip(t *testing.T) {
+	listing := models.Listing{
+		ID:         uuid.New(),
+		Price:      125000.5,
+		ViewsCount: 42,
+		CreatedAt:  time.Date(2025, 3, 14, 9, 26, 53, 589793000, time.UTC),
+	}
+
+	for _, key := range []string{ListingSortNewest, ListingSortOldest, ListingSortPriceAsc, ListingSortPriceDesc, ListingSortViews} {
+		sort := resolveListingSort(key, "", nil)
+		encoded := sort.cursorFor(&listing)
+
+		cursor, err := decodeListingCursor(encoded, key)
+		if err != nil {
+			t.Fatalf("%s: décodage: %v", key, err)
+		}
+		if cursor.ID != listing.ID {
+			t.Errorf("%s: ID = %s, attendu %s", key, cursor.ID, listing.ID)
+		}
+
+		value, err := sort.parseValue(cursor.Value)
+		if err != nil {
+			t.Fatalf("%s: valeur %q illisible: %v", key, cursor.Value, err)
+		}
+		switch v := value.(type) {
+		case time.Time:
+			if !v.Equal(listing.CreatedAt) {
+				t.Errorf("%s: date = %s, attendu %s", key, v, listing.CreatedAt)
+			}
+		case float64:
+			if v != listing.Price {
+				t.Errorf("%s: prix = %v, attendu %v", key, v, listing.Price)
+			}
+		case int:
+			if v != listing.ViewsCount {
+				t.Errorf("%s: vues = %d, attendu %d", key, v, listing.ViewsCount)
+			}
+		}
+	}
+}
+
+func TestDecodeListingCursorRejectsInvalid(t *testing.T) {
+	listing := models.Listing{ID: uuid.New(), Price: 1000}
+	priceCursor := resolveListingSort(ListingSortPriceAsc, "", nil).cursorFor(&listing)
+
+	tests := map[string]string{
+		"tri différent":   priceCursor,
+		"base64 invalide": "%%%",
+		"json invalide":   "bm9uLWpzb24",
+		"sans ID":         encodeListingCursor(listingCursor{Sort: ListingSortNewest}),
+	}
+	for name, encoded := range tests {
+		if _, err := decodeListingCursor(encoded, ListingSortNewest); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%s: erreur attendue ErrInvalidCursor, obtenu %v", name, err)
+		}
+	}
+}
+
+func TestListingSortNextCursor(t *testing.T) {
+	sort := resolveListingSort(ListingSortNewest, "", nil)
+	listings := []models.Listing{{ID: uuid.New()}, {ID: uuid.New()}}
+
+	if got := sort.nextCursor(listings, 3); got != "" {
+		t.Errorf("page incomplète: curseur %q inattendu", got)
+	}
+
+	next := sort.nextCursor(listings, 2)
+	cursor, err := decodeListingCursor(next, ListingSortNewest)
+	if err != nil || cursor.ID != listings[1].ID {
+		t.Errorf("curseur suivant = %+v (%v), attendu ID %s", cursor, err, listings[1].ID)
+	}
+}
+
+func TestResolveListingSortDistance(t *testing.T) {
+	near := &GeoPoint{Lat: 14.6928, Lng: -17.4467}
+
+	sort := resolveListingSort(ListingSortDistance, "villa", near)
+	if sort.Key != ListingSortDistance || sort.Desc || !sort.computed() {
+		t.Fatalf("tri distance inattendu: %+v", sort)
+	}
+
+	listing := models.Listing{ID: uuid.New()}
+	cursor, err := decodeListingCursor(sort.cursorFor(&listing), ListingSortDistance)
+	if err != nil || cursor.Value != "" || cursor.ID != listing.ID {
+		t.Errorf("curseur distance = %+v (%v)", cursor, err)
+	}
+}
@@ -0,0 +1,369 @@
+// internal/services/listing_expiry.go
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"senmarket/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrListingNotRenewable    = errors.New("seule une annonce active ou expirée peut être renouvelée")
+	ErrListingRenewalTooEarly = errors.New("renouvellement possible uniquement dans les 7 jours précédant l'expiration")
+	ErrRenewalPaymentRequired = errors.New("renouvellement payant : moyen de paiement requis")
+	ErrRenewalNotPayable      = errors.New("renouvellement gratuit pendant la phase en cours")
+)
+
+const (
+	listingRenewalWindow    = 7 * 24 * time.Hour // Renouvellement ouvert avant l'expiration
+	listingExpiryBatchSize  = 200
+	listingExpiryMessageFmt = "02/01/2006"
+)
+
+// ListingRenewalQuote conditions de renouvellement d'une annonce selon la phase en cours
+type ListingRenewalQuote struct {
+	ListingID    uuid.UUID  `json:"listing_id"`
+	Phase        string     `json:"phase"`
+	Free         bool       `json:"free"`
+	Price        float64    `json:"price"`
+	Currency     string     `json:"currency"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+	NewExpiresAt time.Time  `json:"new_expires_at"`
+}
+
+// GetRenewalQuote vérifie que l'annonce est renouvelable et retourne son prix
+func (s *ListingService) GetRenewalQuote(userID, listingID uuid.UUID) (*ListingRenewalQuote, error) {
+	listing, err := s.findRenewableListing(s.db, userID, listingID)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := s.quotaService.GetGlobalConfig()
+	if err != nil {
+		return nil, err
+	}
+	price, free := config.GetListingRenewalPrice()
+
+	return &ListingRenewalQuote{
+		ListingID:    listing.ID,
+		Phase:        config.GetCurrentPhase(),
+		Free:         free,
+		Price:        price,
+		Currency:     config.Currency,
+		ExpiresAt:    listing.ExpiresAt,
+		NewExpiresAt: renewedExpiry(listing.ExpiresAt, time.Now()),
+	}, nil
+}
+
+// EnsureListingRenewable vérifie l'annonce avant l'initiation d'un renouvellement payant
+func (s *ListingService) EnsureListingRenewable(userID, listingID uuid.UUID) error {
+	_, err := s.findRenewableListing(s.db, userID, listingID)
+	return err
+}
+
+// RenewListing renouvelle gratuitement une annonce (phases de lancement et de crédits)
+func (s *ListingService) RenewListing(userID, listingID uuid.UUID) (*models.Listing, error) {
+	config, err := s.quotaService.GetGlobalConfig()
+	if err != nil {
+		return nil, err
+	}
+	if _, free := config.GetListingRenewalPrice(); !free {
+		return nil, ErrRenewalPaymentRequired
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		listing, err := s.findRenewableListing(tx.Clauses(clause.Locking{Strength: "UPDATE"}), userID, listingID)
+		if err != nil {
+			return err
+		}
+		return renewListingTx(tx, listing, nil, config.GetCurrentPhase(), 0)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.InvalidateListingCache(listingID)
+
+	var listing models.Listing
+	if err := s.db.Preload("User").Preload("Category").First(&listing, "id = ?", listingID).Error; err != nil {
+		return nil, fmt.Errorf("erreur rechargement annonce: %w", err)
+	}
+	return &listing, nil
+}
+
+// RenewListingAfterPaymentTx applique un renouvellement payé dans la transaction du paiement
+func (s *ListingService) RenewListingAfterPaymentTx(tx *gorm.DB, payment *models.Payment) error {
+	var listing models.Listing
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND user_id = ?", *payment.ListingID, payment.UserID).
+		First(&listing).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrListingNotFound
+		}
+		return fmt.Errorf("erreur récupération annonce: %w", err)
+	}
+
+	// L'annonce a pu être vendue, supprimée ou dépubliée depuis l'initiation du paiement :
+	// l'erreur fait rembourser le paiement
+	if !isRenewableStatus(listing.Status) {
+		return fmt.Errorf("%w (statut %s)", ErrListingNotRenewable, listing.Status)
+	}
+
+	return renewListingTx(tx, &listing, payment, "paid_system", payment.Amount)
+}
+
+// isRenewableStatus seules les annonces actives ou expirées peuvent être renouvelées
+func isRenewableStatus(status string) bool {
+	return status == "active" || status == "expired"
+}
+
+// findRenewableListing annonce du vendeur, active proche de l'expiration ou déjà expirée
+func (s *ListingService) findRenewableListing(db *gorm.DB, userID, listingID uuid.UUID) (*models.Listing, error) {
+	var listing models.Listing
+	if err := db.Where("id = ? AND user_id = ?", listingID, userID).First(&listing).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrListingNotFound
+		}
+		return nil, fmt.Errorf("erreur récupération annonce: %w", err)
+	}
+
+	switch listing.Status {
+	case "expired":
+		return &listing, nil
+	case "active":
+		if listing.ExpiresAt != nil && time.Until(*listing.ExpiresAt) > listingRenewalWindow {
+			return nil, ErrListingRenewalTooEarly
+		}
+		return &listing, nil
+	}
+	return nil, ErrListingNotRenewable
+}
+
+// renewListingTx prolonge l'annonce et historise le renouvellement (une seule fois par paiement)
+func renewListingTx(tx *gorm.DB, listing *models.Listing, payment *models.Payment, phase string, price float64) error {
+	expiresAt := renewedExpiry(listing.ExpiresAt, time.Now())
+
+	renewal := models.ListingRenewal{
+		ListingID:         listing.ID,
+		UserID:            listing.UserID,
+		Phase:             phase,
+		Price:             price,
+		PreviousExpiresAt: listing.ExpiresAt,
+		ExpiresAt:         expiresAt,
+	}
+	if payment != nil {
+		renewal.PaymentID = &payment.ID
+	}
+
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&renewal)
+	if result.Error != nil {
+		return fmt.Errorf("erreur enregistrement renouvellement: %w", result.Error)
+	}
+	if result.RowsAffected == 0 && payment != nil {
+		log.Printf("⚠️ Paiement %s: renouvellement déjà appliqué", payment.ID)
+		return nil
+	}
+
+	updates := map[string]interface{}{
+		"expires_at":       expiresAt,
+		"expiry_warned_at": nil,
+		"renewal_count":    gorm.Expr("renewal_count + 1"),
+	}
+	if listing.Status == "expired" {
+		updates["status"] = "active"
+	}
+	if err := tx.Model(listing).UpdateColumns(updates).Error; err != nil {
+		return fmt.Errorf("erreur renouvellement annonce: %w", err)
+	}
+
+	log.Printf("🔄 Annonce %s renouvelée jusqu'au %s (%s)", listing.ID, expiresAt.Format("2006-01-02"), phase)
+	return nil
+}
+
+// revokeListingRenewalTx annule la prolongation achetée par un paiement remboursé.
+// La durée ajoutée est retirée de l'échéance en cours (renouvellements suivants conservés) ;
+// une annonce active dont l'échéance est passée est expirée. Retourne true si l'annonce a été modifiée.
+func revokeListingRenewalTx(tx *gorm.DB, payment *models.Payment, now time.Time) (bool, error) {
+	var renewal models.ListingRenewal
+	if err := tx.Where("payment_id = ?", payment.ID).First(&renewal).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil // Renouvellement jamais appliqué
+		}
+		return false, fmt.Errorf("erreur récupération renouvellement: %w", err)
+	}
+
+	var listing models.Listing
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", renewal.ListingID).
+		First(&listing).Error; err != nil {
+		return false, fmt.Errorf("erreur récupération annonce: %w", err)
+	}
+	if listing.ExpiresAt == nil {
+		return false, nil
+	}
+
+	start := renewal.CreatedAt
+	if renewal.PreviousExpiresAt != nil && renewal.PreviousExpiresAt.After(start) {
+		start = *renewal.PreviousExpiresAt
+	}
+	expiresAt := listing.ExpiresAt.Add(-renewal.ExpiresAt.Sub(start))
+
+	updates := map[string]interface{}{
+		"expires_at": expiresAt,
+	}
+	if listing.Status == "active" && !expiresAt.After(now) {
+		updates["status"] = "expired"
+	}
+	if err := tx.Model(&listing).UpdateColumns(updates).Error; err != nil {
+		return false, fmt.Errorf("erreur annulation renouvellement: %w", err)
+	}
+
+	log.Printf("↩️ Annonce %s: renouvellement remboursé, échéance ramenée au %s", listing.ID, expiresAt.Format("2006-01-02"))
+	return true, nil
+}
+
+// renewedExpiry nouvelle échéance : la durée s'ajoute à l'échéance en cours si elle n'est pas passée
+func renewedExpiry(current *time.Time, now time.Time) time.Time {
+	start := now
+	if current != nil && current.After(now) {
+		start = *current
+	}
+	return start.AddDate(0, 0, models.ListingDurationDays)
+}
+
+// ExpireListings passe en "expired" les annonces actives arrivées à échéance
+func (s *ListingService) ExpireListings(now time.Time) (int64, error) {
+	result := s.db.Model(&models.Listing{}).
+		Where("status = ? AND expires_at <= ?", "active", now).
+		UpdateColumn("status", "expired")
+	if result.Error != nil {
+		return 0, fmt.Errorf("erreur expiration annonces: %w", result.Error)
+	}
+
+	if result.RowsAffected > 0 {
+		if err := s.cacheService.InvalidateListingsCache(context.Background()); err != nil {
+			log.Printf("Erreur invalidation cache: %v", err)
+		}
+	}
+	return result.RowsAffected, nil
+}
+
+// ListingsExpiringSoon annonces actives expirant avant now+within, vendeur pas encore averti
+func (s *ListingService) ListingsExpiringSoon(now time.Time, within time.Duration) ([]models.Listing, error) {
+	var listings []models.Listing
+	if err := s.db.Preload("User").
+		Where("status = ? AND expires_at > ? AND expires_at <= ? AND expiry_warned_at IS NULL", "active", now, now.Add(within)).
+		Order("expires_at ASC").
+		Limit(listingExpiryBatchSize).
+		Find(&listings).Error; err != nil {
+		return nil, fmt.Errorf("erreur récupération annonces à expirer: %w", err)
+	}
+	return listings, nil
+}
+
+// MarkExpiryWarned enregistre l'envoi de l'avertissement
+func (s *ListingService) MarkExpiryWarned(listingIDs []uuid.UUID, now time.Time) error {
+	if len(listingIDs) == 0 {
+		return nil
+	}
+	return s.db.Model(&models.Listing{}).
+		Where("id IN ?", listingIDs).
+		UpdateColumn("expiry_warned_at", now).Error
+}
+
+// buildExpiryWarningMessage SMS d'avertissement avant expiration
+func buildExpiryWarningMessage(listing *models.Listing, free bool, price float64) string {
+	renewal := "gratuit"
+	if !free {
+		renewal = fmt.Sprintf("%.0f FCFA", price)
+	}
+	return fmt.Sprintf("SenMarket ⏳ Votre annonce « %s » expire le %s. Renouvelez-la (%s) depuis Mes annonces : %s",
+		listing.Title, listing.ExpiresAt.Format(listingExpiryMessageFmt), renewal, listingURL(listing))
+}
+
+// ListingExpiryScheduler expire les annonces échues et avertit les vendeurs par SMS
+type ListingExpiryScheduler struct {
+	listingService *ListingService
+	smsService     *TwilioSMSService
+	interval       time.Duration
+	warnBefore     time.Duration
+}
+
+func NewListingExpiryScheduler(listingService *ListingService, smsService *TwilioSMSService, interval time.Duration, warningDays int) *ListingExpiryScheduler {
+	return &ListingExpiryScheduler{
+		listingService: listingService,
+		smsService:     smsService,
+		interval:       interval,
+		warnBefore:     time.Duration(warningDays) * 24 * time.Hour,
+	}
+}
+
+// Start lance la boucle périodique jusqu'à l'annulation du contexte
+func (s *ListingExpiryScheduler) Start(ctx context.Context) {
+	log.Printf("⏳ Expiration des annonces active (toutes les %s, avertissement %s avant)", s.interval, s.warnBefore)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Run(time.Now())
+		}
+	}
+}
+
+// Run exécute une passe : expiration puis avertissements
+func (s *ListingExpiryScheduler) Run(now time.Time) {
+	expired, err := s.listingService.ExpireListings(now)
+	if err != nil {
+		log.Printf("❌ Expiration annonces: %v", err)
+	} else if expired > 0 {
+		log.Printf("⏳ %d annonces expirées", expired)
+	}
+
+	if s.warnBefore <= 0 || s.smsService == nil {
+		return
+	}
+
+	listings, err := s.listingService.ListingsExpiringSoon(now, s.warnBefore)
+	if err != nil {
+		log.Printf("❌ Avertissements expiration: %v", err)
+		return
+	}
+	if len(listings) == 0 {
+		return
+	}
+
+	config, err := s.listingService.quotaService.GetGlobalConfig()
+	if err != nil {
+		log.Printf("❌ Avertissements expiration: %v", err)
+		return
+	}
+	price, free := config.GetListingRenewalPrice()
+
+	warned := make([]uuid.UUID, 0, len(listings))
+	for i := range listings {
+		listing := &listings[i]
+		if err := s.smsService.SendSMS(listing.User.Phone, buildExpiryWarningMessage(listing, free, price)); err != nil {
+			log.Printf("⚠️ SMS expiration annonce %s: %v", listing.ID, err)
+			continue
+		}
+		warned = append(warned, listing.ID)
+	}
+
+	if err := s.listingService.MarkExpiryWarned(warned, now); err != nil {
+		log.Printf("⚠️ Erreur marquage avertissements: %v", err)
+	}
+	log.Printf("⏳ %d vendeurs avertis d'une expiration prochaine", len(warned))
+}
@@ -0,0 +1,98 @@
+// internal/services/listing_expiry_test.go
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"senmarket/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func TestRenewedExpiry(t *testing.T) {
+	now := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	soon := now.Add(48 * time.Hour)
+	past := now.Add(-72 * time.Hour)
+
+	tests := []struct {
+		name    string
+		current *time.Time
+		want    time.Time
+	}{
+		{"sans échéance", nil, now.AddDate(0, 0, models.ListingDurationDays)},
+		{"échéance à venir prolongée", &soon, soon.AddDate(0, 0, models.ListingDurationDays)},
+		{"annonce expirée repart de maintenant", &past, now.AddDate(0, 0, models.ListingDurationDays)},
+	}
+	for _, tt := range tests {
+		if got := renewedExpiry(tt.current, now); !got.Equal(tt.want) {
+			t.Errorf("%s: %s, attendu %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestListingRenewalPriceByPhase(t *testing.T) {
+	config := models.PricingConfig{
+		IsLaunchPhaseActive:  true,
+		LaunchPhaseEndDate:   time.Now().Add(24 * time.Hour),
+		StandardListingPrice: 200,
+	}
+	if price, free := config.GetListingRenewalPrice(); !free || price != 0 {
+		t.Errorf("lancement: %v/%v, attendu gratuit", price, free)
+	}
+
+	config.IsLaunchPhaseActive = false
+	config.CreditSystemActive = true
+	if _, free := config.GetListingRenewalPrice(); !free {
+		t.Errorf("phase crédits: renouvellement attendu gratuit")
+	}
+
+	config.PaidSystemActive = true
+	if price, free := config.GetListingRenewalPrice(); free || price != 200 {
+		t.Errorf("phase payante: %v/%v, attendu 200 FCFA", price, free)
+	}
+}
+
+func TestBuildExpiryWarningMessage(t *testing.T) {
+	expiresAt := time.Date(2025, 6, 4, 9, 0, 0, 0, time.UTC)
+	listing := models.Listing{ID: uuid.New(), Title: "Toyota Corolla 2016", ExpiresAt: &expiresAt}
+
+	free := buildExpiryWarningMessage(&listing, true, 0)
+	if !strings.Contains(free, "Toyota Corolla 2016") || !strings.Contains(free, "04/06/2025") || !strings.Contains(free, "gratuit") {
+		t.Errorf("message inattendu: %s", free)
+	}
+	if paid := buildExpiryWarningMessage(&listing, false, 200); !strings.Contains(paid, "200 FCFA") {
+		t.Errorf("prix absent: %s", paid)
+	}
+}
+
+func TestRenewListingAfterPaymentTxChecksStatus(t *testing.T) {
+	for status, want := range map[string]bool{
+		"active":  true,
+		"expired": true,
+		"draft":   false,
+		"sold":    false,
+		"deleted": false,
+	} {
+		if got := isRenewableStatus(status); got != want {
+			t.Errorf("statut %s: renouvelable = %v, attendu %v", status, got, want)
+		}
+	}
+
+	// En dry-run l'annonce relue est vide (sans statut) : le renouvellement doit être refusé
+	db, queries := dryRunDB(t)
+	service := NewListingService(db, nil, NewQuotaService(db))
+	listingID := uuid.New()
+	payment := &models.Payment{ID: uuid.New(), UserID: uuid.New(), ListingID: &listingID, Purpose: models.PaymentPurposeRenewal}
+
+	if err := service.RenewListingAfterPaymentTx(db, payment); !errors.Is(err, ErrListingNotRenewable) {
+		t.Fatalf("erreur = %v, attendu ErrListingNotRenewable", err)
+	}
+	for _, query := range queries() {
+		if strings.HasPrefix(query, `UPDATE "listings"`) {
+			t.Fatalf("annonce modifiée malgré son statut: %s", query)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+// internal/services/listing_facets.go
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"senmarket/internal/domain/valueobjects"
+	"senmarket/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// FacetCount nombre d'annonces pour une valeur de facette
+type FacetCount struct {
+	Value string `json:"value"`
+	Label string `json:"label"`
+	Count int64  `json:"count"`
+}
+
+// PriceBucket tranche de prix (FCFA), bornes [Min, Max[
+type PriceBucket struct {
+	Label string   `json:"label"`
+	Min   float64  `json:"min"`
+	Max   *float64 `json:"max,omitempty"`
+	Count int64    `json:"count"`
+}
+
+// ListingFacets compteurs affichés à côté des résultats
+type ListingFacets struct {
+	Total      int64         `json:"total"`
+	Categories []FacetCount  `json:"categories"`
+	Regions    []FacetCount  `json:"regions"`
+	Prices     []PriceBucket `json:"prices"`
+	WithImages int64         `json:"with_images"`
+}
+
+// listingPriceBuckets tranches de l'histogramme des prix
+var listingPriceBuckets = []PriceBucket{
+	{Label: "Moins de 10 000 FCFA", Min: 0, Max: priceBound(10000)},
+	{Label: "10 000 - 50 000 FCFA", Min: 10000, Max: priceBound(50000)},
+	{Label: "50 000 - 100 000 FCFA", Min: 50000, Max: priceBound(100000)},
+	{Label: "100 000 - 500 000 FCFA", Min: 100000, Max: priceBound(500000)},
+	{Label: "500 000 - 1 000 000 FCFA", Min: 500000, Max: priceBound(1000000)},
+	{Label: "1 000 000 - 5 000 000 FCFA", Min: 1000000, Max: priceBound(5000000)},
+	{Label: "Plus de 5 000 000 FCFA", Min: 5000000},
+}
+
+func priceBound(value float64) *float64 {
+	return &value
+}
+
+// priceBucketsSelect expression SQL comptant chaque tranche (bucket_0, bucket_1...)
+func priceBucketsSelect(buckets []PriceBucket) (string, []interface{}) {
+	columns := make([]string, 0, len(buckets))
+	args := make([]interface{}, 0, len(buckets)*2)
+	for i, bucket := range buckets {
+		if bucket.Max != nil {
+			columns = append(columns, fmt.Sprintf("COUNT(*) FILTER (WHERE listings.price >= ? AND listings.price < ?) AS bucket_%d", i))
+			args = append(args, bucket.Min, *bucket.Max)
+		} else {
+			columns = append(columns, fmt.Sprintf("COUNT(*) FILTER (WHERE listings.price >= ?) AS bucket_%d", i))
+			args = append(args, bucket.Min)
+		}
+	}
+	return strings.Join(columns, ", "), args
+}
+
+// GetListingFacets calcule les facettes pour les filtres courants.
+// Chaque facette ignore son propre filtre pour que l'utilisateur puisse changer de valeur.
+func (s *ListingService) GetListingFacets(filters map[string]interface{}) (*ListingFacets, error) {
+	ctx := context.Background()
+
+	if cached, err := s.cacheService.GetCachedListingFacets(ctx, filters); err == nil {
+		return cached, nil
+	}
+
+	base := func(skip ...string) *gorm.DB {
+		return applyListingFilters(s.db.Model(&models.Listing{}).Where("listings.status = ?", "active"), filters, skip...)
+	}
+
+	facets := &ListingFacets{}
+
+	// Total et annonces avec photos (tous filtres appliqués)
+	var totals struct {
+		Total      int64
+		WithImages int64
+	}
+	if err := base().
+		Select("COUNT(*) AS total, COUNT(*) FILTER (WHERE cardinality(listings.images) > 0) AS with_images").
+		Scan(&totals).Error; err != nil {
+		return nil, fmt.Errorf("erreur facettes totaux: %w", err)
+	}
+	facets.Total = totals.Total
+	facets.WithImages = totals.WithImages
+
+	// Catégories
+	var categoryRows []struct {
+		ID    string
+		Name  string
+		Count int64
+	}
+	if err := base("category_id").
+		Select("categories.id, categories.name, COUNT(*) AS count").
+		Joins("JOIN categories ON categories.id = listings.category_id").
+		Group("categories.id, categories.name").
+		Order("count DESC, categories.name ASC").
+		Scan(&categoryRows).Error; err != nil {
+		return nil, fmt.Errorf("erreur facettes catégories: %w", err)
+	}
+	facets.Categories = make([]FacetCount, 0, len(categoryRows))
+	for _, row := range categoryRows {
+		facets.Categories = append(facets.Categories, FacetCount{Value: row.ID, Label: row.Name, Count: row.Count})
+	}
+
+	// Régions : les 14 régions, y compris celles sans annonce
+	var regionRows []struct {
+		Region string
+		Count  int64
+	}
+	if err := base("region").
+		Select("listings.region, COUNT(*) AS count").
+		Group("listings.region").
+		Scan(&regionRows).Error; err != nil {
+		return nil, fmt.Errorf("erreur facettes régions: %w", err)
+	}
+	regionCounts := make(map[string]int64, len(regionRows))
+	for _, row := range regionRows {
+		regionCounts[row.Region] = row.Count
+	}
+	for _, region := range valueobjects.GetAllRegions() {
+		facets.Regions = append(facets.Regions, FacetCount{
+			Value: region.String(),
+			Label: region.String(),
+			Count: regionCounts[region.String()],
+		})
+	}
+
+	// Histogramme des prix
+	selectSQL, args := priceBucketsSelect(listingPriceBuckets)
+	bucketCounts := map[string]interface{}{}
+	if err := base("min_price", "max_price").
+		Select(selectSQL, args...).
+		Scan(&bucketCounts).Error; err != nil {
+		return nil, fmt.Errorf("erreur facettes prix: %w", err)
+	}
+	facets.Prices = make([]PriceBucket, len(listingPriceBuckets))
+	for i, bucket := range listingPriceBuckets {
+		bucket.Count = toInt64(bucketCounts[fmt.Sprintf("bucket_%d", i)])
+		facets.Prices[i] = bucket
+	}
+
+	go func() {
+		if err := s.cacheService.CacheListingFacets(ctx, filters, facets); err != nil {
+			log.Printf("Erreur cache facettes: %v", err)
+		}
+	}()
+
+	return facets, nil
+}
+
+// toInt64 convertit un compteur renvoyé par le driver
+func toInt64(value interface{}) int64 {
+	switch v := value.(type) {
+	case int64:
+		return v
+	case int32:
+		return int64(v)
+	case int:
+		return int64(v)
+	case float64:
+		return int64(v)
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,53 @@
+// internal/services/listing_facets_test.go
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPriceBucketsSelect(t *testing.T) {
+	sql, args := priceBucketsSelect(listingPriceBuckets)
+
+	if got := strings.Count(sql, "COUNT(*) FILTER"); got != len(listingPriceBuckets) {
+		t.Fatalf("attendu %d colonnes, obtenu %d: %s", len(listingPriceBuckets), got, sql)
+	}
+	if got := strings.Count(sql, "?"); got != len(args) {
+		t.Fatalf("%d paramètres pour %d arguments", got, len(args))
+	}
+	// Tranches contiguës, la dernière sans borne supérieure
+	for i := 1; i < len(listingPriceBuckets); i++ {
+		prev := listingPriceBuckets[i-1]
+		if prev.Max == nil || *prev.Max != listingPriceBuckets[i].Min {
+			t.Errorf("tranche %d non contiguë avec la précédente", i)
+		}
+	}
+	if listingPriceBuckets[len(listingPriceBuckets)-1].Max != nil {
+		t.Error("la dernière tranche doit être ouverte")
+	}
+}
+
+func TestBuildListingFacetsKey(t *testing.T) {
+	s := &CacheService{}
+
+	base := map[string]interface{}{"category_id": "cat-1", "region": "Thiès"}
+	withSearch := map[string]interface{}{"category_id": "cat-1", "region": "Thiès", "search": "  Téléphone "}
+	withPrice := map[string]interface{}{"category_id": "cat-1", "region": "Thiès", "min_price": 5000.0}
+
+	keys := map[string]bool{}
+	for _, filters := range []map[string]interface{}{base, withSearch, withPrice} {
+		keys[s.buildListingFacetsKey(filters)] = true
+	}
+	if len(keys) != 3 {
+		t.Fatalf("clés de facettes non distinctes: %v", keys)
+	}
+
+	// La pagination et le tri n'influencent pas les facettes
+	sorted := map[string]interface{}{"category_id": "cat-1", "region": "Thiès", "sort": "price_asc"}
+	if s.buildListingFacetsKey(sorted) != s.buildListingFacetsKey(base) {
+		t.Error("le tri ne doit pas changer la clé des facettes")
+	}
+	if got := s.buildListingFacetsKey(withSearch); !strings.HasSuffix(got, ":q:telephone") {
+		t.Errorf("recherche non normalisée dans la clé: %s", got)
+	}
+}
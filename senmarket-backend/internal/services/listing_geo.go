@@ -0,0 +1,125 @@
+// internal/services/listing_geo.go
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"senmarket/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var ErrInvalidLocation = errors.New("coordonnées invalides (latitude et longitude requises ensemble)")
+
+const (
+	DefaultNearRadiusKm = 10.0
+	MaxNearRadiusKm     = 200.0
+	earthRadiusKm       = 6371.0
+
+	// Distance en mètres calculée par l'extension earthdistance
+	listingDistanceSQL = "earth_distance(ll_to_earth(?, ?), ll_to_earth(listings.latitude, listings.longitude))"
+)
+
+// GeoPoint coordonnées WGS84
+type GeoPoint struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// ParseGeoPoint lit un point au format "lat,lng"
+func ParseGeoPoint(value string) (*GeoPoint, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 2 {
+		return nil, ErrInvalidLocation
+	}
+
+	lat, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil {
+		return nil, ErrInvalidLocation
+	}
+	lng, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil {
+		return nil, ErrInvalidLocation
+	}
+
+	if err := validateCoordinates(&lat, &lng); err != nil {
+		return nil, err
+	}
+	return &GeoPoint{Lat: lat, Lng: lng}, nil
+}
+
+// validateCoordinates latitude et longitude fournies ensemble et dans les bornes
+func validateCoordinates(lat, lng *float64) error {
+	if lat == nil && lng == nil {
+		return nil
+	}
+	if lat == nil || lng == nil {
+		return ErrInvalidLocation
+	}
+	if math.IsNaN(*lat) || math.IsNaN(*lng) || *lat < -90 || *lat > 90 || *lng < -180 || *lng > 180 {
+		return ErrInvalidLocation
+	}
+	return nil
+}
+
+// clampNearRadius rayon de recherche borné (km)
+func clampNearRadius(radiusKm float64) float64 {
+	if radiusKm <= 0 {
+		return DefaultNearRadiusKm
+	}
+	return math.Min(radiusKm, MaxNearRadiusKm)
+}
+
+// applyNearFilter annonces géolocalisées à moins de radiusKm du point
+func applyNearFilter(query *gorm.DB, point GeoPoint, radiusKm float64) *gorm.DB {
+	radiusMeters := clampNearRadius(radiusKm) * 1000
+	// earth_box (index GiST) pré-filtre, earth_distance élimine les coins de la boîte
+	return query.
+		Where("listings.latitude IS NOT NULL").
+		Where("earth_box(ll_to_earth(?, ?), ?) @> ll_to_earth(listings.latitude, listings.longitude)", point.Lat, point.Lng, radiusMeters).
+		Where(listingDistanceSQL+" <= ?", point.Lat, point.Lng, radiusMeters)
+}
+
+// haversineKm distance orthodromique entre deux points (km)
+func haversineKm(a, b GeoPoint) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRad(b.Lat - a.Lat)
+	dLng := toRad(b.Lng - a.Lng)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(a.Lat))*math.Cos(toRad(b.Lat))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// setListingDistances renseigne la distance (km, arrondie à 100 m) de chaque annonce géolocalisée
+func setListingDistances(listings []models.Listing, point *GeoPoint) {
+	if point == nil {
+		return
+	}
+	for i := range listings {
+		if listings[i].Latitude == nil || listings[i].Longitude == nil {
+			continue
+		}
+		distance := math.Round(haversineKm(*point, GeoPoint{Lat: *listings[i].Latitude, Lng: *listings[i].Longitude})*10) / 10
+		listings[i].DistanceKm = &distance
+	}
+}
+
+// nearFilter point et rayon d'un jeu de filtres
+func nearFilter(filters map[string]interface{}) (*GeoPoint, float64) {
+	point, ok := filters["near"].(GeoPoint)
+	if !ok {
+		return nil, 0
+	}
+	radius, _ := filters["radius_km"].(float64)
+	return &point, clampNearRadius(radius)
+}
+
+// geoCacheKey suffixe de clé de cache pour un filtre de proximité
+func geoCacheKey(point GeoPoint, radiusKm float64) string {
+	return fmt.Sprintf(":near:%g,%g:%g", point.Lat, point.Lng, radiusKm)
+}
@@ -0,0 +1,64 @@
+// internal/services/listing_geo_test.go
+package services
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"senmarket/internal/models"
+)
+
+func TestParseGeoPoint(t *testing.T) {
+	point, err := ParseGeoPoint(" 14.6928 , -17.4467 ")
+	if err != nil {
+		t.Fatalf("point valide rejeté: %v", err)
+	}
+	if point.Lat != 14.6928 || point.Lng != -17.4467 {
+		t.Errorf("point = %+v", point)
+	}
+
+	for _, value := range []string{"", "14.69", "14.69,-17.44,3", "abc,-17.44", "91,0", "0,181", "NaN,0"} {
+		if _, err := ParseGeoPoint(value); !errors.Is(err, ErrInvalidLocation) {
+			t.Errorf("%q: erreur attendue ErrInvalidLocation, obtenu %v", value, err)
+		}
+	}
+}
+
+func TestValidateCoordinates(t *testing.T) {
+	lat, lng := 14.7167, -17.4677
+	if err := validateCoordinates(nil, nil); err != nil {
+		t.Errorf("sans coordonnées: %v", err)
+	}
+	if err := validateCoordinates(&lat, &lng); err != nil {
+		t.Errorf("coordonnées valides: %v", err)
+	}
+	if err := validateCoordinates(&lat, nil); !errors.Is(err, ErrInvalidLocation) {
+		t.Errorf("latitude seule acceptée: %v", err)
+	}
+}
+
+func TestSetListingDistances(t *testing.T) {
+	// Plateau -> Almadies : environ 11,6 km
+	plateau := GeoPoint{Lat: 14.6708, Lng: -17.4381}
+	lat, lng := 14.7453, -17.5131
+	listings := []models.Listing{{Latitude: &lat, Longitude: &lng}, {}}
+
+	setListingDistances(listings, &plateau)
+
+	if listings[0].DistanceKm == nil || math.Abs(*listings[0].DistanceKm-11.6) > 0.5 {
+		t.Errorf("distance = %v, attendu ~11.6 km", listings[0].DistanceKm)
+	}
+	if listings[1].DistanceKm != nil {
+		t.Errorf("annonce sans coordonnées: distance %v inattendue", *listings[1].DistanceKm)
+	}
+}
+
+func TestClampNearRadius(t *testing.T) {
+	tests := map[float64]float64{0: DefaultNearRadiusKm, -5: DefaultNearRadiusKm, 3.5: 3.5, 1000: MaxNearRadiusKm}
+	for radius, want := range tests {
+		if got := clampNearRadius(radius); got != want {
+			t.Errorf("clampNearRadius(%v) = %v, attendu %v", radius, got, want)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+// internal/services/listing_sales.go
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"senmarket/internal/domain/valueobjects"
+	"senmarket/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrListingNotSellable     = errors.New("seule une annonce active ou expirée peut être marquée comme vendue")
+	ErrListingAlreadySold     = errors.New("annonce déjà marquée comme vendue")
+	ErrBuyerContactNotFound   = errors.New("contact acheteur introuvable pour cette annonce")
+	ErrBuyerRequiresPlatform  = errors.New("un acheteur issu des contacts implique une vente sur SenMarket")
+	ErrSoldStatusRequiresSale = errors.New("utilisez POST /listings/:id/sold pour marquer une annonce comme vendue")
+	ErrInvalidSalesStatsGroup = errors.New("regroupement invalide (category ou region)")
+)
+
+const (
+	SalesStatsGroupCategory = "category"
+	SalesStatsGroupRegion   = "region"
+)
+
+// MarkListingSoldRequest déclaration de vente par le vendeur
+type MarkListingSoldRequest struct {
+	SoldOnPlatform *bool    `json:"sold_on_platform" validate:"required"`
+	FinalPrice     *float64 `json:"final_price,omitempty" validate:"omitempty,min=0"`
+	BuyerContactID string   `json:"buyer_contact_id,omitempty" validate:"omitempty,uuid"`
+}
+
+// SalesOutcomeStats résultats de vente agrégés (catégorie, région ou global)
+type SalesOutcomeStats struct {
+	Key               string  `json:"key,omitempty"`
+	Name              string  `json:"name,omitempty"`
+	TotalSales        int64   `json:"total_sales"`
+	PlatformSales     int64   `json:"platform_sales"`
+	PlatformRate      float64 `json:"platform_rate"` // Part des ventes conclues sur SenMarket (0-1)
+	AverageFinalPrice float64 `json:"average_final_price"`
+	AveragePriceRatio float64 `json:"average_price_ratio"` // Prix final / prix affiché
+	AverageDaysToSell float64 `json:"average_days_to_sell"`
+}
+
+// MarkListingSold marque l'annonce comme vendue, la retire des fils et historise le résultat
+func (s *ListingService) MarkListingSold(userID, listingID uuid.UUID, req *MarkListingSoldRequest) (*models.ListingSale, error) {
+	var sale *models.ListingSale
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var listing models.Listing
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND user_id = ?", listingID, userID).
+			First(&listing).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrListingNotFound
+			}
+			return fmt.Errorf("erreur récupération annonce: %w", err)
+		}
+
+		if err := ensureListingSellable(listing.Status); err != nil {
+			return err
+		}
+
+		var err error
+		sale, err = newListingSale(&listing, req, time.Now())
+		if err != nil {
+			return err
+		}
+
+		if sale.BuyerContactID != nil {
+			var contact models.Contact
+			if err := tx.Where("id = ? AND listing_id = ?", *sale.BuyerContactID, listing.ID).
+				First(&contact).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return ErrBuyerContactNotFound
+				}
+				return fmt.Errorf("erreur récupération contact: %w", err)
+			}
+			sale.BuyerUserID = contact.SenderID
+		}
+
+		if err := tx.Create(sale).Error; err != nil {
+			return fmt.Errorf("erreur enregistrement vente: %w", err)
+		}
+
+		if err := tx.Model(&listing).UpdateColumns(map[string]interface{}{
+			"status":     valueobjects.ListingStatusSold.String(),
+			"sold_at":    sale.SoldAt,
+			"updated_at": sale.SoldAt,
+		}).Error; err != nil {
+			return fmt.Errorf("erreur mise à jour annonce: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("🤝 Annonce %s vendue (SenMarket: %t)", listingID, sale.SoldOnPlatform)
+
+	// Retrait des fils et rafraîchissement des statistiques
+	s.InvalidateListingCache(listingID)
+	if err := s.cacheService.InvalidateCategoryStatsCache(context.Background()); err != nil {
+		log.Printf("Erreur invalidation cache: %v", err)
+	}
+
+	return sale, nil
+}
+
+// GetSalesStats résultats de vente regroupés par catégorie ou par région
+func (s *ListingService) GetSalesStats(groupBy, categoryID, region string) ([]SalesOutcomeStats, error) {
+	query := salesOutcomeQuery(s.db, categoryID, region)
+
+	switch groupBy {
+	case SalesStatsGroupCategory:
+		query = query.Select("categories.id::text AS key, categories.name AS name, " + salesOutcomeColumns).
+			Joins("JOIN categories ON categories.id = listing_sales.category_id").
+			Group("categories.id, categories.name")
+	case SalesStatsGroupRegion:
+		query = query.Select("listing_sales.region AS key, listing_sales.region AS name, " + salesOutcomeColumns).
+			Group("listing_sales.region")
+	default:
+		return nil, ErrInvalidSalesStatsGroup
+	}
+
+	var stats []SalesOutcomeStats
+	if err := query.Order("total_sales DESC").Scan(&stats).Error; err != nil {
+		return nil, fmt.Errorf("erreur statistiques ventes: %w", err)
+	}
+
+	for i := range stats {
+		stats[i].round()
+	}
+	return stats, nil
+}
+
+// salesOutcomeSummary résultats de vente agrégés d'une catégorie (sous-catégories incluses)
+func salesOutcomeSummary(db *gorm.DB, categoryID string) (*SalesOutcomeStats, error) {
+	var summary SalesOutcomeStats
+	if err := salesOutcomeQuery(db, categoryID, "").
+		Select(salesOutcomeColumns).
+		Scan(&summary).Error; err != nil {
+		return nil, fmt.Errorf("erreur statistiques ventes: %w", err)
+	}
+	summary.round()
+	return &summary, nil
+}
+
+const salesOutcomeColumns = `COUNT(*) AS total_sales,
+	COUNT(*) FILTER (WHERE listing_sales.sold_on_platform) AS platform_sales,
+	COALESCE(AVG(listing_sales.final_price), 0) AS average_final_price,
+	COALESCE(AVG(listing_sales.final_price / NULLIF(listing_sales.listed_price, 0)), 0) AS average_price_ratio,
+	COALESCE(AVG(EXTRACT(EPOCH FROM listing_sales.sold_at - listing_sales.listed_at) / 86400), 0) AS average_days_to_sell`
+
+// salesOutcomeQuery ventes filtrées par catégorie (descendantes incluses) et région
+func salesOutcomeQuery(db *gorm.DB, categoryID, region string) *gorm.DB {
+	query := db.Table("listing_sales")
+	if categoryID != "" {
+		query = query.Where("listing_sales.category_id IN ("+categoryDescendantsSQL+")", categoryID)
+	}
+	if region != "" {
+		query = query.Where("listing_sales.region = ?", region)
+	}
+	return query
+}
+
+// ensureListingSellable seules les annonces publiées (actives ou expirées) peuvent être vendues
+func ensureListingSellable(status string) error {
+	switch valueobjects.ListingStatus(status) {
+	case valueobjects.ListingStatusActive, valueobjects.ListingStatusExpired:
+		return nil
+	case valueobjects.ListingStatusSold:
+		return ErrListingAlreadySold
+	}
+	return ErrListingNotSellable
+}
+
+// newListingSale construit le résultat de vente à partir de la déclaration du vendeur
+func newListingSale(listing *models.Listing, req *MarkListingSoldRequest, now time.Time) (*models.ListingSale, error) {
+	soldOnPlatform := req.SoldOnPlatform != nil && *req.SoldOnPlatform
+
+	sale := &models.ListingSale{
+		ListingID:      listing.ID,
+		UserID:         listing.UserID,
+		CategoryID:     listing.CategoryID,
+		Region:         listing.Region,
+		SoldOnPlatform: soldOnPlatform,
+		FinalPrice:     req.FinalPrice,
+		ListedPrice:    listing.Price,
+		Currency:       listing.Currency,
+		ListedAt:       listing.CreatedAt,
+		SoldAt:         now,
+	}
+	if sale.Currency == "" {
+		sale.Currency = "XOF"
+	}
+
+	if req.BuyerContactID != "" {
+		if !soldOnPlatform {
+			return nil, ErrBuyerRequiresPlatform
+		}
+		contactID, err := uuid.Parse(req.BuyerContactID)
+		if err != nil {
+			return nil, ErrBuyerContactNotFound
+		}
+		sale.BuyerContactID = &contactID
+	}
+
+	return sale, nil
+}
+
+// round taux et moyennes arrondis pour l'affichage
+func (stats *SalesOutcomeStats) round() {
+	if stats.TotalSales > 0 {
+		stats.PlatformRate = math.Round(float64(stats.PlatformSales)/float64(stats.TotalSales)*1000) / 1000
+	}
+	stats.AverageFinalPrice = math.Round(stats.AverageFinalPrice)
+	stats.AveragePriceRatio = math.Round(stats.AveragePriceRatio*1000) / 1000
+	stats.AverageDaysToSell = math.Round(stats.AverageDaysToSell*10) / 10
+}
@@ -0,0 +1,114 @@
+// internal/services/listing_sales_test.go
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"senmarket/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func TestEnsureListingSellable(t *testing.T) {
+	tests := map[string]error{
+		"active":  nil,
+		"expired": nil,
+		"sold":    ErrListingAlreadySold,
+		"draft":   ErrListingNotSellable,
+	}
+
+	for status, want := range tests {
+		if err := ensureListingSellable(status); err != want {
+			t.Errorf("%s: erreur %v, attendu %v", status, err, want)
+		}
+	}
+}
+
+func TestNewListingSale(t *testing.T) {
+	createdAt := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	listing := &models.Listing{
+		ID:         uuid.New(),
+		UserID:     uuid.New(),
+		CategoryID: uuid.New(),
+		Region:     "Dakar - Plateau",
+		Price:      150000,
+		CreatedAt:  createdAt,
+	}
+	yes, no := true, false
+	finalPrice := 140000.0
+	contactID := uuid.New()
+	now := createdAt.AddDate(0, 0, 12)
+
+	sale, err := newListingSale(listing, &MarkListingSoldRequest{
+		SoldOnPlatform: &yes,
+		FinalPrice:     &finalPrice,
+		BuyerContactID: contactID.String(),
+	}, now)
+	if err != nil {
+		t.Fatalf("vente rejetée: %v", err)
+	}
+	if !sale.SoldOnPlatform || sale.BuyerContactID == nil || *sale.BuyerContactID != contactID {
+		t.Fatalf("vente inattendue: %+v", sale)
+	}
+	if sale.ListedPrice != 150000 || *sale.FinalPrice != 140000 || sale.Currency != "XOF" {
+		t.Fatalf("prix inattendus: %+v", sale)
+	}
+	if sale.Region != listing.Region || sale.CategoryID != listing.CategoryID || !sale.ListedAt.Equal(createdAt) || !sale.SoldAt.Equal(now) {
+		t.Fatalf("contexte de vente inattendu: %+v", sale)
+	}
+
+	// Vendu ailleurs : pas d'acheteur issu des contacts
+	if _, err := newListingSale(listing, &MarkListingSoldRequest{SoldOnPlatform: &no, BuyerContactID: contactID.String()}, now); !errors.Is(err, ErrBuyerRequiresPlatform) {
+		t.Errorf("erreur attendue ErrBuyerRequiresPlatform, obtenu %v", err)
+	}
+
+	sale, err = newListingSale(listing, &MarkListingSoldRequest{SoldOnPlatform: &no}, now)
+	if err != nil || sale.SoldOnPlatform || sale.FinalPrice != nil || sale.BuyerContactID != nil {
+		t.Errorf("vente hors plateforme inattendue: %+v (%v)", sale, err)
+	}
+}
+
+func TestSalesOutcomeStatsRound(t *testing.T) {
+	stats := SalesOutcomeStats{
+		TotalSales:        3,
+		PlatformSales:     2,
+		AverageFinalPrice: 84999.6,
+		AveragePriceRatio: 0.91666,
+		AverageDaysToSell: 6.4321,
+	}
+	stats.round()
+
+	if stats.PlatformRate != 0.667 || stats.AverageFinalPrice != 85000 || stats.AveragePriceRatio != 0.917 || stats.AverageDaysToSell != 6.4 {
+		t.Errorf("arrondis inattendus: %+v", stats)
+	}
+
+	empty := SalesOutcomeStats{}
+	empty.round()
+	if empty.PlatformRate != 0 {
+		t.Errorf("taux attendu 0 sans vente, obtenu %v", empty.PlatformRate)
+	}
+}
+
+func TestUpdateListingRestrictsStatus(t *testing.T) {
+	tests := map[string]error{
+		"sold":     ErrSoldStatusRequiresSale,
+		"active":   ErrStatusNotWritable,
+		"expired":  ErrStatusNotWritable,
+		"rejected": ErrStatusNotWritable,
+	}
+	for status, want := range tests {
+		db, queries := dryRunDB(t)
+		service := NewListingService(db, nil, NewQuotaService(db))
+		if _, err := service.UpdateListing(uuid.NewString(), uuid.NewString(), &UpdateListingRequest{Status: &status}); !errors.Is(err, want) {
+			t.Errorf("%s: erreur = %v, attendu %v", status, err, want)
+		}
+		for _, query := range queries() {
+			if strings.HasPrefix(query, "UPDATE") {
+				t.Errorf("%s: aucune mise à jour attendue, obtenu %q", status, query)
+			}
+		}
+	}
+}
@@ -0,0 +1,170 @@
+// internal/services/listing_search.go
+package services
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	"senmarket/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// Configuration plein texte créée par la migration 021 (français + unaccent)
+	listingSearchConfig    = "french_unaccent"
+	listingSearchMaxLength = 200
+	// Marqueurs neutres posés par ts_headline, remplacés par <mark> après échappement HTML du texte vendeur
+	highlightStartSel            = "\x02"
+	highlightStopSel             = "\x03"
+	listingTitleHighlightOptions = "StartSel=\"" + highlightStartSel + "\", StopSel=\"" + highlightStopSel + "\", HighlightAll=true"
+	listingHighlightOptions      = "StartSel=\"" + highlightStartSel + "\", StopSel=\"" + highlightStopSel + "\", MaxWords=35, MinWords=15, MaxFragments=2, FragmentDelimiter=\" … \""
+)
+
+// highlightToHTML échappe l'extrait renvoyé par ts_headline puis pose les balises <mark>.
+// Titre et description sont saisis par le vendeur : sans échappement, l'extrait serait du HTML arbitraire.
+func highlightToHTML(headline string) string {
+	escaped := html.EscapeString(headline)
+	escaped = strings.ReplaceAll(escaped, highlightStartSel, "<mark>")
+	return strings.ReplaceAll(escaped, highlightStopSel, "</mark>")
+}
+
+// ListingSearchResult annonce trouvée avec sa pertinence et les extraits surlignés
+type ListingSearchResult struct {
+	models.Listing
+	Rank                 float64 `json:"rank"`
+	TitleHighlight       string  `json:"title_highlight"`
+	DescriptionHighlight string  `json:"description_highlight"`
+}
+
+// listingSearchHit ligne brute renvoyée par la requête de recherche
+type listingSearchHit struct {
+	ID                   uuid.UUID
+	Rank                 float64
+	TitleHighlight       string
+	DescriptionHighlight string
+}
+
+// normalizeSearchQuery nettoie la saisie utilisateur (espaces, longueur)
+func normalizeSearchQuery(query string) string {
+	query = strings.Join(strings.Fields(query), " ")
+	if utf8.RuneCountInString(query) > listingSearchMaxLength {
+		query = string([]rune(query)[:listingSearchMaxLength])
+	}
+	return query
+}
+
+// applyListingSearch filtre une requête sur le vecteur de recherche
+func applyListingSearch(query *gorm.DB, search string) *gorm.DB {
+	return query.Where("listings.search_vector @@ websearch_to_tsquery(?, ?)", listingSearchConfig, search)
+}
+
+// SearchListings recherche plein texte dans les annonces actives, triée par pertinence.
+// Un curseur non vide remplace la pagination par page.
+func (s *ListingService) SearchListings(query string, page, limit int, cursor string) ([]ListingSearchResult, int64, string, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 || limit > 50 {
+		limit = 20
+	}
+
+	query = normalizeSearchQuery(query)
+	results := []ListingSearchResult{}
+	if query == "" {
+		return results, 0, "", nil
+	}
+
+	var total int64
+	base := applyListingSearch(s.db.Model(&models.Listing{}).Where("listings.status = ?", "active"), query)
+	if err := base.Count(&total).Error; err != nil {
+		return nil, 0, "", fmt.Errorf("erreur comptage recherche: %w", err)
+	}
+	if total == 0 {
+		return results, 0, "", nil
+	}
+
+	// Alimenter les recherches populaires (suggestions)
+	if page == 1 {
+		go func() {
+			if err := s.cacheService.IncrementSearchCount(context.Background(), strings.ToLower(query)); err != nil {
+				log.Printf("Erreur compteur recherche: %v", err)
+			}
+		}()
+	}
+
+	// Tri par pertinence puis ID, reprise après le curseur éventuel
+	sort := resolveListingSort(ListingSortRelevance, query, nil)
+	hitsQuery, err := sort.apply(applyListingSearch(s.db.Table("listings").Where("listings.status = ?", "active"), query), cursor)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	if cursor == "" {
+		hitsQuery = hitsQuery.Offset((page - 1) * limit)
+	}
+
+	// Pertinence et extraits surlignés calculés par PostgreSQL
+	var hits []listingSearchHit
+	err = hitsQuery.
+		Select(
+			"listings.id, ts_rank(listings.search_vector, websearch_to_tsquery(@cfg, @q)) AS rank, "+
+				"ts_headline(@cfg, listings.title, websearch_to_tsquery(@cfg, @q), @title_opts) AS title_highlight, "+
+				"ts_headline(@cfg, listings.description, websearch_to_tsquery(@cfg, @q), @opts) AS description_highlight",
+			map[string]interface{}{"cfg": listingSearchConfig, "q": query, "opts": listingHighlightOptions, "title_opts": listingTitleHighlightOptions},
+		).
+		Limit(limit).
+		Scan(&hits).Error
+	if err != nil {
+		return nil, 0, "", fmt.Errorf("erreur recherche annonces: %w", err)
+	}
+	if len(hits) == 0 {
+		return results, total, "", nil
+	}
+
+	nextCursor := ""
+	if len(hits) == limit {
+		nextCursor = encodeListingCursor(listingCursor{Sort: sort.Key, ID: hits[len(hits)-1].ID})
+	}
+
+	ids := make([]uuid.UUID, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+
+	var listings []models.Listing
+	if err := s.db.
+		Preload("User", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id", "first_name", "last_name", "phone", "region", "is_verified")
+		}).
+		Preload("Category").
+		Where("id IN ?", ids).
+		Find(&listings).Error; err != nil {
+		return nil, 0, "", fmt.Errorf("erreur récupération annonces: %w", err)
+	}
+
+	byID := make(map[uuid.UUID]models.Listing, len(listings))
+	for _, listing := range listings {
+		byID[listing.ID] = listing
+	}
+
+	// Conserver l'ordre de pertinence
+	for _, hit := range hits {
+		listing, ok := byID[hit.ID]
+		if !ok {
+			continue
+		}
+		results = append(results, ListingSearchResult{
+			Listing:              listing,
+			Rank:                 hit.Rank,
+			TitleHighlight:       highlightToHTML(hit.TitleHighlight),
+			DescriptionHighlight: highlightToHTML(hit.DescriptionHighlight),
+		})
+	}
+
+	return results, total, nextCursor, nil
+}
@@ -0,0 +1,38 @@
+// internal/services/listing_search_test.go
+package services
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestNormalizeSearchQuery(t *testing.T) {
+	tests := map[string]string{
+		"  villa   Thiès ":        "villa Thiès",
+		"\tiphone\n13  -occasion": "iphone 13 -occasion",
+		`"terrain titré" dakar`:   `"terrain titré" dakar`,
+		"   ":                     "",
+	}
+	for input, want := range tests {
+		if got := normalizeSearchQuery(input); got != want {
+			t.Errorf("normalizeSearchQuery(%q) = %q, attendu %q", input, got, want)
+		}
+	}
+
+	long := normalizeSearchQuery(strings.Repeat("é", listingSearchMaxLength+50))
+	if utf8.RuneCountInString(long) != listingSearchMaxLength || !utf8.ValidString(long) {
+		t.Errorf("requête longue mal tronquée: %d caractères", utf8.RuneCountInString(long))
+	}
+}
+
+func TestHighlightToHTML(t *testing.T) {
+	headline := `<img src=x onerror=alert(1)> ` + highlightStartSel + "villa" + highlightStopSel + ` "Thiès" & co`
+	want := `&lt;img src=x onerror=alert(1)&gt; <mark>villa</mark> &#34;Thiès&#34; &amp; co`
+	if got := highlightToHTML(headline); got != want {
+		t.Fatalf("highlightToHTML = %q, attendu %q", got, want)
+	}
+	if strings.Contains(listingHighlightOptions, "<") || strings.Contains(listingTitleHighlightOptions, "<") {
+		t.Fatal("ts_headline ne doit poser que les marqueurs neutres, jamais de HTML")
+	}
+}
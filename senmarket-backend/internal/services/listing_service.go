@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"senmarket/internal/models"
@@ -13,6 +14,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/lib/pq"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ListingService struct {
@@ -23,10 +25,18 @@ type ListingService struct {
 
 // Constantes pour les erreurs
 var (
-	ErrListingNotFound = errors.New("annonce non trouvée")
-	ErrQuotaExceeded   = errors.New("quota d'annonces dépassé")
+	ErrListingNotFound   = errors.New("annonce non trouvée")
+	ErrQuotaExceeded     = errors.New("quota d'annonces dépassé")
+	ErrListingNotPayable = errors.New("seul un brouillon peut être publié par paiement")
+	ErrStatusNotWritable = errors.New("statut non modifiable directement (publication, paiement et renouvellement ont leurs propres routes)")
 )
 
+// sellerWritableStatuses statuts qu'un vendeur peut poser via UpdateListing.
+// "active" passe par la publication, le paiement ou le renouvellement ; "sold" par MarkListingSold.
+var sellerWritableStatuses = map[string]bool{
+	"draft": true,
+}
+
 func NewListingService(db *gorm.DB, cacheService *CacheService, quotaService *QuotaService) *ListingService {
 	return &ListingService{
 		db:           db,
@@ -37,28 +47,27 @@ func NewListingService(db *gorm.DB, cacheService *CacheService, quotaService *Qu
 
 // CreateListingWithQuota crée une nouvelle annonce avec vérification des quotas
 func (s *ListingService) CreateListingWithQuota(userID uuid.UUID, req *CreateListingRequest) (*models.Listing, error) {
-	// Vérifier si l'utilisateur peut créer une annonce
-	canCreate, reason, err := s.quotaService.CanCreateFreeListing(userID)
-	if err != nil {
-		return nil, fmt.Errorf("erreur vérification quota: %w", err)
+	if err := validateCoordinates(req.Latitude, req.Longitude); err != nil {
+		return nil, err
 	}
 
-	// 🔧 CHANGEMENT: Ne plus bloquer si canCreate = false, permettre création en draft
-	shouldPublishImmediately := canCreate
-
 	// Parser le CategoryID depuis string vers UUID
 	categoryUUID, err := uuid.Parse(req.CategoryID)
 	if err != nil {
 		return nil, fmt.Errorf("ID catégorie invalide: %w", err)
 	}
 
-	// 🔧 CORRECTION: Déterminer le statut selon la phase
-	status := "draft" // Par défaut
-	if shouldPublishImmediately {
-		status = "active" // Publication immédiate si gratuit
+	// Valider les attributs contre le schéma courant de la catégorie
+	schema, err := currentAttributeSchema(s.db, categoryUUID)
+	if err != nil {
+		return nil, err
+	}
+	attributes, err := validateListingAttributes(schema, req.Attributes)
+	if err != nil {
+		return nil, err
 	}
 
-	// Créer l'annonce
+	// Créer l'annonce (brouillon tant que le quota n'est pas consommé)
 	listing := models.Listing{
 		ID:          uuid.New(),
 		Title:       req.Title,
@@ -66,27 +75,46 @@ func (s *ListingService) CreateListingWithQuota(userID uuid.UUID, req *CreateLis
 		Price:       req.Price,
 		Currency:    "XOF", // Franc CFA par défaut
 		Region:      req.Region,
+		Commune:     strings.TrimSpace(req.Commune),
+		Latitude:    req.Latitude,
+		Longitude:   req.Longitude,
 		Images:      pq.StringArray(req.Images),
-		Status:      status, // 🔧 STATUT DYNAMIQUE
-		IsFeatured:  req.Featured,
+		Status:      "draft",
 		UserID:      userID,
 		CategoryID:  categoryUUID,
 		ViewsCount:  0,
-	}
 
-	// Sauvegarder en base
-	if err := s.db.Create(&listing).Error; err != nil {
-		return nil, fmt.Errorf("erreur création annonce: %w", err)
+		Attributes: attributes,
+	}
+	if schema != nil {
+		listing.AttributeSchemaVersion = schema.Version
 	}
 
-	// 🔧 CORRECTION: Compter seulement si annonce publiée gratuitement
-	if shouldPublishImmediately {
-		if err := s.quotaService.ConsumeFreeListing(userID); err != nil {
-			log.Printf("⚠️ Erreur comptage annonce gratuite: %v", err)
+	// Insertion et consommation du quota dans la même transaction : la publication
+	// gratuite n'a lieu que si l'annonce gratuite ou le crédit a bien été débité
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&listing).Error; err != nil {
+			return fmt.Errorf("erreur création annonce: %w", err)
 		}
+
+		err := s.quotaService.ConsumeFreeListingTx(tx, userID, listing.ID)
+		if errors.Is(err, ErrNoFreeListingsLeft) {
+			return nil // Reste en brouillon : paiement requis
+		}
+		if err != nil {
+			return fmt.Errorf("erreur comptage annonce gratuite: %w", err)
+		}
+
+		listing.Status = "active"
+		return tx.Model(&listing).UpdateColumn("status", listing.Status).Error
+	}); err != nil {
+		return nil, err
+	}
+
+	if listing.Status == "active" {
 		log.Printf("🎉 Annonce %s PUBLIÉE GRATUITEMENT pour utilisateur %s", listing.ID, userID)
 	} else {
-		log.Printf("📝 Annonce %s créée en BROUILLON (paiement requis) pour utilisateur %s - Raison: %s", listing.ID, userID, reason)
+		log.Printf("📝 Annonce %s créée en BROUILLON (paiement requis) pour utilisateur %s", listing.ID, userID)
 	}
 
 	// Preload les relations
@@ -99,8 +127,24 @@ func (s *ListingService) CreateListingWithQuota(userID uuid.UUID, req *CreateLis
 
 // PublishListingAfterPayment publie une annonce après paiement
 func (s *ListingService) PublishListingAfterPayment(userID uuid.UUID, listingID uuid.UUID) error {
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		return s.PublishListingAfterPaymentTx(tx, userID, listingID)
+	}); err != nil {
+		return err
+	}
+
+	// Invalider les caches une fois la transaction validée
+	s.InvalidateListingCache(listingID)
+
+	return nil
+}
+
+// PublishListingAfterPaymentTx publie l'annonce brouillon et compte l'annonce payée
+// dans la transaction fournie. Les caches sont à invalider après le commit.
+func (s *ListingService) PublishListingAfterPaymentTx(tx *gorm.DB, userID uuid.UUID, listingID uuid.UUID) error {
 	var listing models.Listing
-	if err := s.db.Where("id = ? AND user_id = ? AND status = ?", listingID, userID, "draft").
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND user_id = ? AND status = ?", listingID, userID, "draft").
 		First(&listing).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return ErrListingNotFound
@@ -109,28 +153,46 @@ func (s *ListingService) PublishListingAfterPayment(userID uuid.UUID, listingID
 	}
 
 	// Publier l'annonce
-	if err := s.db.Model(&listing).Update("status", "active").Error; err != nil {
+	if err := tx.Model(&listing).Update("status", "active").Error; err != nil {
 		return fmt.Errorf("erreur publication annonce: %w", err)
 	}
 
 	// Compter l'annonce payée
-	if err := s.quotaService.AddPaidListing(userID); err != nil {
-		log.Printf("⚠️ Erreur comptage annonce payée: %v", err)
+	if err := s.quotaService.AddPaidListingTx(tx, userID); err != nil {
+		return fmt.Errorf("erreur comptage annonce payée: %w", err)
 	}
 
 	log.Printf("💳 Annonce %s publiée après PAIEMENT pour utilisateur %s", listingID, userID)
 
-	// Invalider les caches
-	ctx := context.Background()
-	go func() {
-		if err := s.cacheService.InvalidateListingCache(ctx, listingID.String()); err != nil {
-			log.Printf("Erreur invalidation cache: %v", err)
+	return nil
+}
+
+// EnsureListingPayable vérifie, avant l'initiation d'un paiement de publication,
+// que l'annonce appartient à l'utilisateur et est encore un brouillon
+func (s *ListingService) EnsureListingPayable(userID, listingID uuid.UUID) error {
+	var listing models.Listing
+	if err := s.db.Select("id", "status").
+		Where("id = ? AND user_id = ?", listingID, userID).
+		First(&listing).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrListingNotFound
 		}
-	}()
+		return fmt.Errorf("erreur récupération annonce: %w", err)
+	}
 
+	if listing.Status != "draft" {
+		return ErrListingNotPayable
+	}
 	return nil
 }
 
+// InvalidateListingCache invalide les caches d'une annonce et des listes
+func (s *ListingService) InvalidateListingCache(listingID uuid.UUID) {
+	if err := s.cacheService.InvalidateListingCache(context.Background(), listingID.String()); err != nil {
+		log.Printf("Erreur invalidation cache: %v", err)
+	}
+}
+
 // GetUserQuotaStatus retourne le statut du quota d'un utilisateur
 func (s *ListingService) GetUserQuotaStatus(userID uuid.UUID) (map[string]interface{}, error) {
 	return s.quotaService.GetUserQuotaStatus(userID)
@@ -151,20 +213,33 @@ func (s *ListingService) CreateListing(userID string, req *CreateListingRequest)
 	return s.CreateListingWithQuota(userUUID, req)
 }
 
-// GetListings récupère les annonces avec pagination et cache
-func (s *ListingService) GetListings(page, limit int, filters map[string]interface{}) ([]models.Listing, int64, error) {
+// GetListings récupère les annonces avec pagination et cache.
+// filters["cursor"] (renvoyé en next_cursor) remplace la pagination par page.
+func (s *ListingService) GetListings(page, limit int, filters map[string]interface{}) ([]models.Listing, int64, string, error) {
 	ctx := context.Background()
+
+	search, _ := filters["search"].(string)
+	sortParam, _ := filters["sort"].(string)
+	near, _ := nearFilter(filters)
+	sort := resolveListingSort(sortParam, normalizeSearchQuery(search), near)
+	cursor, _ := filters["cursor"].(string)
 	
-	// Essayer le cache d'abord
-	cachedListings, err := s.cacheService.GetCachedListingsPage(ctx, page, limit, filters)
+	// Essayer le cache d'abord (pagination par page uniquement)
+	var cachedListings []models.Listing
+	var err error
+	if cursor == "" {
+		cachedListings, err = s.cacheService.GetCachedListingsPage(ctx, page, limit, filters)
+	}
 	if err == nil && len(cachedListings) > 0 {
 		log.Printf("🔴 Cache HIT - Listings page %d", page)
+		nextCursor := sort.nextCursor(cachedListings, limit)
+		setListingDistances(cachedListings, near)
 		
 		// Récupérer le total count depuis le cache aussi
 		var totalCount int64
 		if err := s.cacheService.cache.Get(ctx, CACHE_LISTINGS_COUNT, &totalCount); err == nil {
 			log.Printf("🔴 Cache HIT - Total count %d", totalCount)
-			return cachedListings, totalCount, nil
+			return cachedListings, totalCount, nextCursor, nil
 		}
 		
 		// 🆕 NOUVEAU: Si le total count n'est pas en cache, on le recalcule
@@ -174,31 +249,13 @@ func (s *ListingService) GetListings(page, limit int, filters map[string]interfa
 		query := s.db.Model(&models.Listing{}).Where("status = ?", "active")
 		
 		// Appliquer les mêmes filtres
-		if categoryID, ok := filters["category_id"].(string); ok && categoryID != "" {
-			query = query.Where("category_id = ?", categoryID)
-		}
-		
-		if region, ok := filters["region"].(string); ok && region != "" {
-			query = query.Where("region = ?", region)
-		}
-		
-		if minPrice, ok := filters["min_price"].(float64); ok && minPrice > 0 {
-			query = query.Where("price >= ?", minPrice)
-		}
-		
-		if maxPrice, ok := filters["max_price"].(float64); ok && maxPrice > 0 {
-			query = query.Where("price <= ?", maxPrice)
-		}
-		
-		if search, ok := filters["search"].(string); ok && search != "" {
-			query = query.Where("title ILIKE ? OR description ILIKE ?", "%"+search+"%", "%"+search+"%")
-		}
+		query = applyListingFilters(query, filters)
 		
 		// Compter le total
 		if err := query.Count(&totalCount).Error; err != nil {
 			log.Printf("❌ Erreur comptage depuis cache: %v", err)
 			// Fallback: utiliser les listings cachés mais avec un total approximatif
-			return cachedListings, int64(len(cachedListings)), nil
+			return cachedListings, int64(len(cachedListings)), nextCursor, nil
 		}
 		
 		// Remettre le total en cache
@@ -209,7 +266,7 @@ func (s *ListingService) GetListings(page, limit int, filters map[string]interfa
 		}()
 		
 		log.Printf("🔴 Cache HIT + DB Count - Listings: %d, Total: %d", len(cachedListings), totalCount)
-		return cachedListings, totalCount, nil
+		return cachedListings, totalCount, nextCursor, nil
 	}
 	
 	log.Printf("🔴 Cache MISS - Récupération depuis DB page %d", page)
@@ -223,71 +280,95 @@ func (s *ListingService) GetListings(page, limit int, filters map[string]interfa
 		Where("status = ?", "active")
 
 	// Appliquer les filtres
-	if categoryID, ok := filters["category_id"].(string); ok && categoryID != "" {
-		query = query.Where("category_id = ?", categoryID)
-	}
-	
-	if region, ok := filters["region"].(string); ok && region != "" {
-		query = query.Where("region = ?", region)
-	}
-	
-	if minPrice, ok := filters["min_price"].(float64); ok && minPrice > 0 {
-		query = query.Where("price >= ?", minPrice)
-	}
-	
-	if maxPrice, ok := filters["max_price"].(float64); ok && maxPrice > 0 {
-		query = query.Where("price <= ?", maxPrice)
-	}
-	
-	if search, ok := filters["search"].(string); ok && search != "" {
-		query = query.Where("title ILIKE ? OR description ILIKE ?", "%"+search+"%", "%"+search+"%")
-	}
+	query = applyListingFilters(query, filters)
 
 	// Compter le total
 	var total int64
 	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, fmt.Errorf("erreur comptage annonces: %w", err)
-	}
-
-	// Tri par défaut
-	sort := "created_at DESC"
-	if sortParam, ok := filters["sort"].(string); ok && sortParam != "" {
-		switch sortParam {
-		case "price_asc":
-			sort = "price ASC"
-		case "price_desc":
-			sort = "price DESC"
-		case "newest":
-			sort = "created_at DESC"
-		case "oldest":
-			sort = "created_at ASC"
-		case "views":
-			sort = "views_count DESC"
-		case "date": // Support pour le frontend qui utilise "date"
-			sort = "created_at DESC"
-		}
+		return nil, 0, "", fmt.Errorf("erreur comptage annonces: %w", err)
+	}
+
+	// Tri stable (colonne + ID) et reprise après le curseur éventuel
+	query, err = sort.apply(query, cursor)
+	if err != nil {
+		return nil, 0, "", err
 	}
 
 	// Pagination
-	offset := (page - 1) * limit
+	if cursor == "" {
+		query = query.Offset((page - 1) * limit)
+	}
 	var listings []models.Listing
 	
-	if err := query.Order(sort).Offset(offset).Limit(limit).Find(&listings).Error; err != nil {
-		return nil, 0, fmt.Errorf("erreur récupération annonces: %w", err)
+	if err := query.Limit(limit).Find(&listings).Error; err != nil {
+		return nil, 0, "", fmt.Errorf("erreur récupération annonces: %w", err)
 	}
 
 	// Mettre en cache
-	go func() {
-		if err := s.cacheService.CacheListingsPage(ctx, page, limit, filters, listings); err != nil {
-			log.Printf("Erreur cache listings: %v", err)
+	if cursor == "" {
+		go func() {
+			if err := s.cacheService.CacheListingsPage(ctx, page, limit, filters, listings); err != nil {
+				log.Printf("Erreur cache listings: %v", err)
+			}
+			if err := s.cacheService.cache.Set(ctx, "listings:count", total, time.Hour); err != nil {
+				log.Printf("Erreur cache count: %v", err)
+			}
+		}()
+	}
+
+	setListingDistances(listings, near)
+
+	log.Printf("🔴 DB Query - Listings: %d, Total: %d", len(listings), total)
+	return listings, total, sort.nextCursor(listings, limit), nil
+}
+
+// applyListingFilters applique les filtres de liste (catégorie, région, prix, recherche),
+// sauf ceux dont la clé figure dans skip
+func applyListingFilters(query *gorm.DB, filters map[string]interface{}, skip ...string) *gorm.DB {
+	skipped := func(key string) bool {
+		for _, k := range skip {
+			if k == key {
+				return true
+			}
 		}
-		if err := s.cacheService.cache.Set(ctx, "listings:count", total, time.Hour); err != nil {
-			log.Printf("Erreur cache count: %v", err)
+		return false
+	}
+
+	if categoryID, ok := filters["category_id"].(string); ok && categoryID != "" && !skipped("category_id") {
+		query = applyCategoryFilter(query, categoryID)
+	}
+
+	if region, ok := filters["region"].(string); ok && region != "" && !skipped("region") {
+		query = query.Where("listings.region = ?", region)
+	}
+
+	if commune, ok := filters["commune"].(string); ok && commune != "" && !skipped("commune") {
+		query = query.Where("LOWER(listings.commune) = LOWER(?)", commune)
+	}
+
+	if point, radius := nearFilter(filters); point != nil && !skipped("near") {
+		query = applyNearFilter(query, *point, radius)
+	}
+
+	if attributes, ok := filters["attributes"].(map[string]string); ok && len(attributes) > 0 && !skipped("attributes") {
+		query = applyAttributeFilters(query, attributes)
+	}
+
+	if minPrice, ok := filters["min_price"].(float64); ok && minPrice > 0 && !skipped("min_price") {
+		query = query.Where("listings.price >= ?", minPrice)
+	}
+
+	if maxPrice, ok := filters["max_price"].(float64); ok && maxPrice > 0 && !skipped("max_price") {
+		query = query.Where("listings.price <= ?", maxPrice)
+	}
+
+	if search, ok := filters["search"].(string); ok && !skipped("search") {
+		if search = normalizeSearchQuery(search); search != "" {
+			query = applyListingSearch(query, search)
 		}
-	}()
+	}
 
-	log.Printf("🔴 DB Query - Listings: %d, Total: %d", len(listings), total)
-	return listings, total, nil
+	return query
 }
 
 // GetListing récupère une annonce par ID avec cache
@@ -326,8 +407,9 @@ func (s *ListingService) GetListing(id string) (*models.Listing, error) {
 	return &listing, nil
 }
 
-// GetMyListings récupère les annonces d'un utilisateur (toutes, y compris drafts)
-func (s *ListingService) GetMyListings(userID string, page, limit int) ([]models.Listing, int64, error) {
+// GetMyListings récupère les annonces d'un utilisateur (toutes, y compris drafts).
+// Un curseur non vide remplace la pagination par page.
+func (s *ListingService) GetMyListings(userID string, page, limit int, cursor string) ([]models.Listing, int64, string, error) {
 	if page <= 0 {
 		page = 1
 	}
@@ -342,14 +424,20 @@ func (s *ListingService) GetMyListings(userID string, page, limit int) ([]models
 	s.db.Model(&models.Listing{}).Where("user_id = ?", userID).Count(&total)
 
 	// Récupérer avec pagination (TOUTES les annonces, y compris drafts)
-	offset := (page - 1) * limit
-	err := s.db.Preload("Category").
-		Where("user_id = ?", userID).
-		Order("created_at DESC").
-		Offset(offset).Limit(limit).
-		Find(&listings).Error
+	sort := resolveListingSort(ListingSortNewest, "", nil)
+	query, err := sort.apply(s.db.Preload("Category").Where("user_id = ?", userID), cursor)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	if cursor == "" {
+		query = query.Offset((page - 1) * limit)
+	}
+
+	if err := query.Limit(limit).Find(&listings).Error; err != nil {
+		return nil, 0, "", err
+	}
 
-	return listings, total, err
+	return listings, total, sort.nextCursor(listings, limit), nil
 }
 
 // UpdateListing met à jour une annonce
@@ -377,6 +465,16 @@ func (s *ListingService) UpdateListing(listingID string, userID string, req *Upd
 	if req.Region != nil {
 		updates["region"] = *req.Region
 	}
+	if req.Commune != nil {
+		updates["commune"] = strings.TrimSpace(*req.Commune)
+	}
+	if req.Latitude != nil || req.Longitude != nil {
+		if err := validateCoordinates(req.Latitude, req.Longitude); err != nil {
+			return nil, err
+		}
+		updates["latitude"] = *req.Latitude
+		updates["longitude"] = *req.Longitude
+	}
 	if req.Images != nil {
 		updates["images"] = pq.StringArray(req.Images)
 	}
@@ -384,8 +482,30 @@ func (s *ListingService) UpdateListing(listingID string, userID string, req *Upd
 		updates["phone"] = *req.Phone
 	}
 	if req.Status != nil {
+		// La vente passe par MarkListingSold (résultat historisé)
+		if *req.Status == "sold" {
+			return nil, ErrSoldStatusRequiresSale
+		}
+		if !sellerWritableStatuses[*req.Status] {
+			return nil, ErrStatusNotWritable
+		}
 		updates["status"] = *req.Status
 	}
+	if req.Attributes != nil {
+		schema, err := currentAttributeSchema(s.db, listing.CategoryID)
+		if err != nil {
+			return nil, err
+		}
+		attributes, err := validateListingAttributes(schema, req.Attributes)
+		if err != nil {
+			return nil, err
+		}
+		updates["attributes"] = attributes
+		updates["attribute_schema_version"] = 0
+		if schema != nil {
+			updates["attribute_schema_version"] = schema.Version
+		}
+	}
 
 	// Mettre à jour
 	if err := s.db.Model(&listing).Updates(updates).Error; err != nil {
@@ -446,7 +566,10 @@ func (s *ListingService) GetFeaturedListings(limit int) ([]models.Listing, error
 		return db.Select("id", "first_name", "last_name", "phone", "region", "is_verified")
 	}).
 		Preload("Category").
-		Where("status = ? AND is_featured = ?", "active", true).
+		Where("status = ? AND is_featured = ? AND boosted_until > ?", "active", true, time.Now()).
+		// Boosts actifs en premier : le plus récemment prolongé, puis les annonces en couleur
+		Order("boosted_until DESC").
+		Order("is_highlighted DESC").
 		Order("created_at DESC").
 		Limit(limit).
 		Find(&listings).Error
@@ -476,33 +599,3 @@ func (s *ListingService) IncrementViews(listingID string) error {
 
 	return nil
 }
-
-// SearchListings recherche dans les annonces
-func (s *ListingService) SearchListings(query string, page, limit int) ([]models.Listing, int64, error) {
-	if page <= 0 {
-		page = 1
-	}
-	if limit <= 0 || limit > 50 {
-		limit = 20
-	}
-
-	var listings []models.Listing
-	var total int64
-
-	// Construire la requête de recherche
-	searchQuery := s.db.Model(&models.Listing{}).
-		Preload("User", func(db *gorm.DB) *gorm.DB {
-			return db.Select("id", "first_name", "last_name", "phone", "region", "is_verified")
-		}).
-		Preload("Category").
-		Where("status = ? AND (title ILIKE ? OR description ILIKE ?)", "active", "%"+query+"%", "%"+query+"%")
-
-	// Compter le total
-	searchQuery.Count(&total)
-
-	// Récupérer avec pagination
-	offset := (page - 1) * limit
-	err := searchQuery.Order("created_at DESC").Offset(offset).Limit(limit).Find(&listings).Error
-
-	return listings, total, err
-}
\ No newline at end of file
@@ -0,0 +1,240 @@
+// internal/services/listing_suggest.go
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"senmarket/internal/models"
+
+	"gorm.io/gorm/clause"
+)
+
+const (
+	SuggestionTypePopular  = "popular"
+	SuggestionTypeCategory = "category"
+	SuggestionTypeListing  = "listing"
+
+	suggestMinLength          = 2
+	suggestDefaultLimit       = 8
+	suggestMaxLimit           = 15
+	suggestMaxPopular         = 5
+	suggestMaxCategories      = 3
+	popularSearchesScan       = 200
+	popularSearchesDayMaxSize = 5000
+	popularSearchesWindowDays = 7
+	popularSearchesMinCount   = 3
+	popularSearchMinLength    = 3
+	popularSearchMaxLength    = 60
+)
+
+// popularSearchBlocklist termes jamais proposés en autocomplétion (comparés sans accents, mot à mot)
+var popularSearchBlocklist = map[string]bool{
+	"pute": true, "putain": true, "salope": true, "connard": true, "connasse": true,
+	"encule": true, "nique": true, "batard": true, "pd": true,
+	"porno": true, "porn": true, "sexe": true, "escort": true,
+	"cocaine": true, "drogue": true, "cannabis": true, "yamba": true,
+}
+
+// Numéros, e-mails et liens : une recherche populaire ne doit pas exposer de coordonnées
+var popularSearchContactPattern = regexp.MustCompile(`\d{9,}|@|https?://|www\.`)
+
+// SearchSuggestion proposition d'autocomplétion
+type SearchSuggestion struct {
+	Text         string `json:"text"`
+	Type         string `json:"type"` // popular, category, listing
+	CategoryID   string `json:"category_id,omitempty"`
+	CategorySlug string `json:"category_slug,omitempty"`
+}
+
+var accentReplacer = strings.NewReplacer(
+	"à", "a", "â", "a", "ä", "a", "á", "a", "ã", "a", "å", "a",
+	"ç", "c",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ñ", "n",
+	"ó", "o", "ò", "o", "ô", "o", "ö", "o", "õ", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ý", "y", "ÿ", "y",
+	"œ", "oe", "æ", "ae",
+)
+
+// foldSearchText minuscules sans accents, comme search_normalize() côté PostgreSQL
+func foldSearchText(text string) string {
+	return accentReplacer.Replace(strings.ToLower(text))
+}
+
+// escapeLikePattern neutralise les jokers LIKE de la saisie utilisateur
+func escapeLikePattern(text string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(text)
+}
+
+// mergeSearchSuggestions fusionne les sources par priorité, sans doublons
+func mergeSearchSuggestions(limit int, groups ...[]SearchSuggestion) []SearchSuggestion {
+	merged := []SearchSuggestion{}
+	seen := map[string]bool{}
+	for _, group := range groups {
+		for _, suggestion := range group {
+			key := foldSearchText(strings.TrimSpace(suggestion.Text))
+			if key == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			merged = append(merged, suggestion)
+			if len(merged) >= limit {
+				return merged
+			}
+		}
+	}
+	return merged
+}
+
+// SuggestListings propose des complétions à partir des recherches populaires,
+// des catégories et des titres d'annonces (tolérant aux fautes de frappe)
+func (s *ListingService) SuggestListings(query string, limit int) ([]SearchSuggestion, error) {
+	ctx := context.Background()
+
+	if limit <= 0 || limit > suggestMaxLimit {
+		limit = suggestDefaultLimit
+	}
+
+	prefix := foldSearchText(normalizeSearchQuery(query))
+	if utf8.RuneCountInString(prefix) < suggestMinLength {
+		return []SearchSuggestion{}, nil
+	}
+
+	// Cache par préfixe (liste complète, tronquée à la demande)
+	if cached, err := s.cacheService.GetCachedSearchSuggestions(ctx, prefix); err == nil {
+		return truncateSuggestions(cached, limit), nil
+	}
+
+	popular := s.popularSearchSuggestions(ctx, prefix)
+
+	categories, err := s.categorySuggestions(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	titles, err := s.titleSuggestions(prefix, suggestMaxLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestions := mergeSearchSuggestions(suggestMaxLimit, popular, categories, titles)
+
+	go func() {
+		if err := s.cacheService.CacheSearchSuggestions(ctx, prefix, suggestions); err != nil {
+			log.Printf("Erreur cache suggestions: %v", err)
+		}
+	}()
+
+	return truncateSuggestions(suggestions, limit), nil
+}
+
+// popularSearchSuggestions recherches populaires (Redis) commençant par le préfixe
+func (s *ListingService) popularSearchSuggestions(ctx context.Context, prefix string) []SearchSuggestion {
+	searches, err := s.cacheService.GetPopularSearches(ctx, popularSearchesScan)
+	if err != nil {
+		log.Printf("⚠️ Recherches populaires indisponibles: %v", err)
+		return nil
+	}
+
+	var suggestions []SearchSuggestion
+	for _, search := range searches {
+		if !strings.HasPrefix(foldSearchText(search), prefix) || !isSuggestablePopularSearch(search) {
+			continue
+		}
+		suggestions = append(suggestions, SearchSuggestion{Text: search, Type: SuggestionTypePopular})
+		if len(suggestions) >= suggestMaxPopular {
+			break
+		}
+	}
+	return suggestions
+}
+
+// isSuggestablePopularSearch écarte les recherches trop courtes ou trop longues,
+// contenant des coordonnées ou un terme de la liste noire
+func isSuggestablePopularSearch(search string) bool {
+	length := utf8.RuneCountInString(strings.TrimSpace(search))
+	if length < popularSearchMinLength || length > popularSearchMaxLength {
+		return false
+	}
+	folded := foldSearchText(search)
+	if popularSearchContactPattern.MatchString(strings.ReplaceAll(folded, " ", "")) {
+		return false
+	}
+	for _, word := range strings.FieldsFunc(folded, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+		if popularSearchBlocklist[word] {
+			return false
+		}
+	}
+	return true
+}
+
+// categorySuggestions catégories actives proches du préfixe
+func (s *ListingService) categorySuggestions(prefix string) ([]SearchSuggestion, error) {
+	var categories []models.Category
+	err := s.db.Model(&models.Category{}).
+		Select("id", "name", "slug").
+		Where("is_active = ?", true).
+		Where("search_normalize(name) LIKE ? OR ? <% search_normalize(name)", escapeLikePattern(prefix)+"%", prefix).
+		Order(suggestionOrder("name", prefix)).
+		Limit(suggestMaxCategories).
+		Find(&categories).Error
+	if err != nil {
+		return nil, fmt.Errorf("erreur suggestions catégories: %w", err)
+	}
+
+	suggestions := make([]SearchSuggestion, 0, len(categories))
+	for _, category := range categories {
+		suggestions = append(suggestions, SearchSuggestion{
+			Text:         category.Name,
+			Type:         SuggestionTypeCategory,
+			CategoryID:   category.ID.String(),
+			CategorySlug: category.Slug,
+		})
+	}
+	return suggestions, nil
+}
+
+// titleSuggestions titres d'annonces actives contenant le préfixe ou s'en approchant
+func (s *ListingService) titleSuggestions(prefix string, limit int) ([]SearchSuggestion, error) {
+	var titles []string
+	err := s.db.Model(&models.Listing{}).
+		Where("status = ?", "active").
+		Where("search_normalize(title) LIKE ? OR ? <% search_normalize(title)", "%"+escapeLikePattern(prefix)+"%", prefix).
+		Group("title").
+		Order(suggestionOrder("title", prefix)).
+		Limit(limit).
+		Pluck("title", &titles).Error
+	if err != nil {
+		return nil, fmt.Errorf("erreur suggestions titres: %w", err)
+	}
+
+	suggestions := make([]SearchSuggestion, 0, len(titles))
+	for _, title := range titles {
+		suggestions = append(suggestions, SearchSuggestion{Text: title, Type: SuggestionTypeListing})
+	}
+	return suggestions, nil
+}
+
+// suggestionOrder préfixes exacts d'abord, puis similarité trigramme décroissante
+func suggestionOrder(column, prefix string) clause.OrderBy {
+	return clause.OrderBy{Expression: clause.Expr{
+		SQL:                fmt.Sprintf("search_normalize(%s) LIKE ? DESC, word_similarity(?, search_normalize(%s)) DESC, %s ASC", column, column, column),
+		Vars:               []interface{}{escapeLikePattern(prefix) + "%", prefix},
+		WithoutParentheses: true,
+	}}
+}
+
+func truncateSuggestions(suggestions []SearchSuggestion, limit int) []SearchSuggestion {
+	if len(suggestions) > limit {
+		return suggestions[:limit]
+	}
+	return suggestions
+}
@@ -0,0 +1,76 @@
+// internal/services/listing_suggest_test.go
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFoldSearchText(t *testing.T) {
+	tests := map[string]string{
+		"Thiès":              "thies",
+		"Véhicules":          "vehicules",
+		"MAÏS ÉCRASÉ":        "mais ecrase",
+		"cœur de Ziguinchor": "coeur de ziguinchor",
+	}
+	for input, want := range tests {
+		if got := foldSearchText(input); got != want {
+			t.Errorf("foldSearchText(%q) = %q, attendu %q", input, got, want)
+		}
+	}
+
+	if got := escapeLikePattern(`50%_off\`); got != `50\%\_off\\` {
+		t.Errorf("escapeLikePattern = %q", got)
+	}
+}
+
+func TestMergeSearchSuggestions(t *testing.T) {
+	popular := []SearchSuggestion{{Text: "voiture", Type: SuggestionTypePopular}}
+	categories := []SearchSuggestion{{Text: "Véhicules", Type: SuggestionTypeCategory, CategorySlug: "vehicules"}}
+	titles := []SearchSuggestion{
+		{Text: "Voiture", Type: SuggestionTypeListing},
+		{Text: "Vehicules", Type: SuggestionTypeListing},
+		{Text: "Voiture Toyota Corolla", Type: SuggestionTypeListing},
+		{Text: "Vélo", Type: SuggestionTypeListing},
+	}
+
+	merged := mergeSearchSuggestions(3, popular, categories, titles)
+	if len(merged) != 3 {
+		t.Fatalf("attendu 3 suggestions, obtenu %d: %+v", len(merged), merged)
+	}
+	if merged[0].Type != SuggestionTypePopular || merged[1].Type != SuggestionTypeCategory {
+		t.Errorf("priorité des sources non respectée: %+v", merged)
+	}
+	if merged[2].Text != "Voiture Toyota Corolla" {
+		t.Errorf("doublons non filtrés: %+v", merged)
+	}
+}
+
+func TestIsSuggestablePopularSearch(t *testing.T) {
+	tests := map[string]bool{
+		"villa thiès":             true,
+		"iphone 15 pro 256":       true,
+		"tv":                      false, // trop court
+		"appelez le 77 123 45 67": false,
+		"contact@exemple.sn":      false,
+		"www.arnaque.sn":          false,
+		"Salope à vendre":         false,
+		"ENCULÉ":                  false,
+	}
+	for search, want := range tests {
+		if got := isSuggestablePopularSearch(search); got != want {
+			t.Errorf("isSuggestablePopularSearch(%q) = %v, attendu %v", search, got, want)
+		}
+	}
+}
+
+func TestSearchWindowKeys(t *testing.T) {
+	now := time.Date(2026, 3, 2, 23, 30, 0, 0, time.UTC)
+	keys := searchWindowKeys(now)
+	if len(keys) != popularSearchesWindowDays {
+		t.Fatalf("%d clés, attendu %d", len(keys), popularSearchesWindowDays)
+	}
+	if keys[0] != CACHE_SEARCH_DAY+"2026-03-02" || keys[1] != CACHE_SEARCH_DAY+"2026-03-01" || keys[2] != CACHE_SEARCH_DAY+"2026-02-28" {
+		t.Fatalf("clés inattendues: %v", keys[:3])
+	}
+}
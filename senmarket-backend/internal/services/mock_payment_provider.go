@@ -0,0 +1,65 @@
+// internal/services/mock_payment_provider.go
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"senmarket/internal/models"
+)
+
+// MockPaymentProvider provider factice pour le développement et les tests.
+// Les paiements sont considérés réussis à la première vérification de statut.
+type MockPaymentProvider struct{}
+
+func NewMockPaymentProvider() *MockPaymentProvider {
+	return &MockPaymentProvider{}
+}
+
+func (p *MockPaymentProvider) Name() string {
+	return "mock"
+}
+
+func (p *MockPaymentProvider) Initiate(payment *models.Payment, phone string) (*PaymentInitiation, error) {
+	orderID := payment.ID.String()
+	return &PaymentInitiation{
+		Status:     "success",
+		PaymentURL: fmt.Sprintf("http://localhost:8080/mock-pay?order_id=%s", orderID),
+		OrderID:    orderID,
+		Reference:  fmt.Sprintf("MOCK-%s", orderID[:8]),
+		Message:    "Paiement simulé",
+	}, nil
+}
+
+func (p *MockPaymentProvider) VerifySignature(headers http.Header, body []byte) error {
+	return nil
+}
+
+// ParseWebhook attend {"payment_id": "...", "status": "completed|failed|..."}
+func (p *MockPaymentProvider) ParseWebhook(body []byte) (*PaymentNotification, error) {
+	var notification PaymentNotification
+	if err := json.Unmarshal(body, &notification); err != nil {
+		return nil, fmt.Errorf("erreur décodage webhook mock: %w", err)
+	}
+	if notification.PaymentID == "" && notification.Reference == "" {
+		return nil, errors.New("payment_id manquant")
+	}
+	if notification.Status == "" {
+		notification.Status = "completed"
+	}
+	return &notification, nil
+}
+
+func (p *MockPaymentProvider) QueryStatus(payment *models.Payment) (*PaymentNotification, error) {
+	return &PaymentNotification{
+		PaymentID: payment.ID.String(),
+		Reference: payment.TransactionID,
+		Status:    "completed",
+	}, nil
+}
+
+func (p *MockPaymentProvider) Refund(payment *models.Payment, amount float64) error {
+	return nil
+}
@@ -0,0 +1,264 @@
+// internal/services/orange_money_provider.go
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"senmarket/internal/models"
+)
+
+type OrangeMoneyPaymentRequest struct {
+	MerchantKey   string  `json:"merchant_key"`
+	Currency      string  `json:"currency"`
+	OrderID       string  `json:"order_id"`
+	Amount        float64 `json:"amount"`
+	ReturnURL     string  `json:"return_url"`
+	CancelURL     string  `json:"cancel_url"`
+	NotifURL      string  `json:"notif_url"`
+	Lang          string  `json:"lang"`
+	Reference     string  `json:"reference"`
+	CustomerPhone string  `json:"customer_phone"`
+	CustomerEmail string  `json:"customer_email,omitempty"`
+}
+
+type OrangeMoneyResponse struct {
+	Status     string `json:"status"`
+	PaymentURL string `json:"payment_url"`
+	OrderID    string `json:"order_id"`
+	Message    string `json:"message"`
+	Error      string `json:"error,omitempty"`
+}
+
+type orangeMoneyOperationRequest struct {
+	MerchantKey string  `json:"merchant_key"`
+	OrderID     string  `json:"order_id"`
+	Reference   string  `json:"reference"`
+	Amount      float64 `json:"amount,omitempty"`
+}
+
+// OrangeMoneyProvider provider Orange Money Web Payment
+type OrangeMoneyProvider struct {
+	apiURL         string
+	merchantKey    string
+	merchantSecret string
+	verifier       *HMACWebhookVerifier
+	httpClient     *http.Client
+}
+
+func NewOrangeMoneyProvider(apiURL, merchantKey, merchantSecret, environment string) *OrangeMoneyProvider {
+	return &OrangeMoneyProvider{
+		apiURL:         apiURL,
+		merchantKey:    merchantKey,
+		merchantSecret: merchantSecret,
+		verifier:       NewHMACWebhookVerifier(merchantSecret, environment),
+		httpClient:     &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (p *OrangeMoneyProvider) Name() string {
+	return "orange_money"
+}
+
+// isSandbox mode simulation (développement)
+func (p *OrangeMoneyProvider) isSandbox() bool {
+	return p.apiURL == "" || p.apiURL == "sandbox"
+}
+
+// Initiate démarre un paiement Orange Money
+func (p *OrangeMoneyProvider) Initiate(payment *models.Payment, phone string) (*PaymentInitiation, error) {
+	orderID := payment.ID.String()
+	reference := fmt.Sprintf("SENMARKET-%s", orderID[:8])
+
+	orangeReq := OrangeMoneyPaymentRequest{
+		MerchantKey:   p.merchantKey,
+		Currency:      "XOF",
+		OrderID:       orderID,
+		Amount:        payment.Amount,
+		ReturnURL:     "https://senmarket.sn/payment/success",
+		CancelURL:     "https://senmarket.sn/payment/cancel",
+		NotifURL:      "https://api.senmarket.sn/api/v1/payments/webhook/orange-money",
+		Lang:          "fr",
+		Reference:     reference,
+		CustomerPhone: phone,
+		CustomerEmail: payment.User.Email,
+	}
+
+	response, err := p.callAPI(orangeReq)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PaymentInitiation{
+		Status:     response.Status,
+		PaymentURL: response.PaymentURL,
+		OrderID:    orderID,
+		Reference:  reference,
+		Message:    response.Message,
+	}, nil
+}
+
+// callAPI appelle l'API Orange Money
+func (p *OrangeMoneyProvider) callAPI(req OrangeMoneyPaymentRequest) (*OrangeMoneyResponse, error) {
+	// En mode développement, simuler la réponse
+	if p.isSandbox() {
+		return &OrangeMoneyResponse{
+			Status:     "success",
+			PaymentURL: fmt.Sprintf("https://sandbox.orange.money/pay?order_id=%s", req.OrderID),
+			OrderID:    req.OrderID,
+			Message:    "Paiement initié avec succès",
+		}, nil
+	}
+
+	var orangeResp OrangeMoneyResponse
+	if err := p.post("/payment/init", req, &orangeResp); err != nil {
+		return nil, err
+	}
+
+	if orangeResp.Status != "success" {
+		return nil, fmt.Errorf("échec Orange Money: %s", orangeResp.Error)
+	}
+
+	return &orangeResp, nil
+}
+
+// VerifySignature vérifie la signature HMAC du secret marchand
+func (p *OrangeMoneyProvider) VerifySignature(headers http.Header, body []byte) error {
+	return p.verifier.Verify(headers, body)
+}
+
+// ParseWebhook décode une notification Orange Money
+func (p *OrangeMoneyProvider) ParseWebhook(body []byte) (*PaymentNotification, error) {
+	var payload map[string]interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("erreur décodage webhook Orange Money: %w", err)
+	}
+
+	orderID, ok := payload["order_id"].(string)
+	if !ok {
+		return nil, errors.New("order_id manquant")
+	}
+
+	status, ok := payload["status"].(string)
+	if !ok {
+		return nil, errors.New("status manquant")
+	}
+
+	notification := &PaymentNotification{
+		PaymentID: orderID,
+		Status:    orangeMoneyPaymentStatus(status),
+	}
+
+	// Accepter à la fois UUID complet et référence SENMARKET-xxxxxxxx
+	if len(orderID) >= 8 {
+		notification.Reference = "SENMARKET-" + orderID[:8]
+	}
+	if amount, ok := payload["amount"].(float64); ok {
+		notification.Amount = amount
+	}
+	if currency, ok := payload["currency"].(string); ok {
+		notification.Currency = currency
+	}
+	if reason, ok := payload["failure_reason"].(string); ok {
+		notification.FailureReason = reason
+	}
+
+	return notification, nil
+}
+
+// QueryStatus interroge Orange Money sur l'état d'une commande
+func (p *OrangeMoneyProvider) QueryStatus(payment *models.Payment) (*PaymentNotification, error) {
+	if p.isSandbox() {
+		return &PaymentNotification{
+			PaymentID: payment.ID.String(),
+			Reference: payment.TransactionID,
+			Status:    payment.Status,
+		}, nil
+	}
+
+	var resp struct {
+		Status        string  `json:"status"`
+		TransactionID string  `json:"transaction_id"`
+		Amount        float64 `json:"amount"`
+		Currency      string  `json:"currency"`
+		Error         string  `json:"error,omitempty"`
+	}
+	err := p.post("/payment/status", orangeMoneyOperationRequest{
+		MerchantKey: p.merchantKey,
+		OrderID:     payment.ID.String(),
+		Reference:   payment.TransactionID,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PaymentNotification{
+		EventID:       resp.TransactionID,
+		PaymentID:     payment.ID.String(),
+		Reference:     payment.TransactionID,
+		Status:        orangeMoneyPaymentStatus(resp.Status),
+		Amount:        resp.Amount,
+		Currency:      resp.Currency,
+		FailureReason: resp.Error,
+	}, nil
+}
+
+// Refund rembourse un paiement Orange Money
+func (p *OrangeMoneyProvider) Refund(payment *models.Payment, amount float64) error {
+	if p.isSandbox() {
+		return nil
+	}
+
+	var resp OrangeMoneyResponse
+	err := p.post("/payment/refund", orangeMoneyOperationRequest{
+		MerchantKey: p.merchantKey,
+		OrderID:     payment.ID.String(),
+		Reference:   payment.TransactionID,
+		Amount:      amount,
+	}, &resp)
+	if err != nil {
+		return err
+	}
+
+	if resp.Status != "success" {
+		return fmt.Errorf("échec remboursement Orange Money: %s", resp.Error)
+	}
+
+	return nil
+}
+
+// post envoie une requête JSON à l'API Orange Money
+func (p *OrangeMoneyProvider) post(path string, payload interface{}, dest interface{}) error {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("erreur sérialisation JSON: %w", err)
+	}
+
+	resp, err := p.httpClient.Post(p.apiURL+path, "application/json", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("erreur appel API Orange Money: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(dest); err != nil {
+		return fmt.Errorf("erreur décodage réponse: %w", err)
+	}
+
+	return nil
+}
+
+// orangeMoneyPaymentStatus convertit un statut Orange Money en statut models.Payment
+func orangeMoneyPaymentStatus(status string) string {
+	switch status {
+	case "completed", "success", "SUCCESS":
+		return "completed"
+	case "failed", "cancelled", "FAILED", "EXPIRED":
+		return "failed"
+	default:
+		return "pending"
+	}
+}
@@ -0,0 +1,127 @@
+// internal/services/payment_idempotency.go
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"senmarket/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrIdempotencyKeyConflict   = errors.New("clé d'idempotence déjà utilisée avec une requête différente")
+	ErrIdempotencyKeyInProgress = errors.New("paiement en cours d'initiation pour cette clé d'idempotence")
+	ErrIdempotencyKeyInvalid    = errors.New("clé d'idempotence invalide")
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	IdempotencyKeyTTL    = 24 * time.Hour
+	idempotencyKeyMaxLen = 255
+)
+
+// InitiatePaymentIdempotent initie un paiement une seule fois par clé Idempotency-Key.
+// Un rejeu avec le même corps renvoie le paiement d'origine (replayed = true) ;
+// un corps différent pour la même clé renvoie ErrIdempotencyKeyConflict.
+func (s *PaymentService) InitiatePaymentIdempotent(userID, key string, req *CreatePaymentRequest) (*models.Payment, *PaymentInitiation, bool, error) {
+	if key == "" || len(key) > idempotencyKeyMaxLen {
+		return nil, nil, false, ErrIdempotencyKeyInvalid
+	}
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("ID utilisateur invalide: %w", err)
+	}
+
+	requestHash, err := hashPaymentRequest(req)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	// Purger une éventuelle clé expirée avant de la réserver
+	s.db.Where("user_id = ? AND idempotency_key = ? AND expires_at <= ?", userUUID, key, time.Now()).
+		Delete(&models.PaymentIdempotencyKey{})
+
+	record := models.PaymentIdempotencyKey{
+		UserID:         userUUID,
+		IdempotencyKey: key,
+		RequestHash:    requestHash,
+		ExpiresAt:      time.Now().Add(IdempotencyKeyTTL),
+	}
+
+	// Réservation atomique de la clé : un seul appel concurrent l'obtient
+	result := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+	if result.Error != nil {
+		return nil, nil, false, fmt.Errorf("erreur réservation clé d'idempotence: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		payment, response, err := s.replayIdempotentPayment(userUUID, key, requestHash)
+		return payment, response, err == nil, err
+	}
+
+	payment, response, err := s.InitiatePayment(userID, req)
+	if err != nil {
+		// Libérer la clé pour permettre une nouvelle tentative
+		s.db.Delete(&record)
+		return payment, response, false, err
+	}
+
+	responseJSON, _ := json.Marshal(response)
+	if err := s.db.Model(&record).Updates(map[string]interface{}{
+		"payment_id": payment.ID,
+		"response":   string(responseJSON),
+	}).Error; err != nil {
+		log.Printf("⚠️ Erreur enregistrement clé d'idempotence %s: %v", key, err)
+	}
+
+	return payment, response, false, nil
+}
+
+// replayIdempotentPayment renvoie le résultat d'une initiation déjà effectuée
+func (s *PaymentService) replayIdempotentPayment(userID uuid.UUID, key, requestHash string) (*models.Payment, *PaymentInitiation, error) {
+	var record models.PaymentIdempotencyKey
+	if err := s.db.Where("user_id = ? AND idempotency_key = ?", userID, key).First(&record).Error; err != nil {
+		return nil, nil, fmt.Errorf("erreur lecture clé d'idempotence: %w", err)
+	}
+
+	if record.RequestHash != requestHash {
+		return nil, nil, ErrIdempotencyKeyConflict
+	}
+
+	if record.PaymentID == nil {
+		return nil, nil, ErrIdempotencyKeyInProgress
+	}
+
+	var payment models.Payment
+	if err := s.db.Preload("User").First(&payment, "id = ?", *record.PaymentID).Error; err != nil {
+		return nil, nil, fmt.Errorf("erreur récupération paiement: %w", err)
+	}
+
+	var response PaymentInitiation
+	if record.Response != "" {
+		if err := json.Unmarshal([]byte(record.Response), &response); err != nil {
+			return nil, nil, fmt.Errorf("erreur décodage réponse enregistrée: %w", err)
+		}
+	}
+
+	return &payment, &response, nil
+}
+
+// hashPaymentRequest empreinte SHA-256 du corps de la requête
+func hashPaymentRequest(req *CreatePaymentRequest) (string, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return "", fmt.Errorf("erreur sérialisation requête: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,41 @@
+// internal/services/payment_idempotency_test.go
+package services
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestHashPaymentRequest(t *testing.T) {
+	req := CreatePaymentRequest{
+		ListingID:     "4f0c1a52-6a3e-4b4c-9a55-3f0c8e2d1b7a",
+		Amount:        200,
+		PaymentMethod: "wave",
+		Phone:         "+221771234567",
+	}
+
+	first, err := hashPaymentRequest(&req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	retry := req
+	if second, _ := hashPaymentRequest(&retry); second != first {
+		t.Error("un rejeu identique doit produire la même empreinte")
+	}
+
+	retry.Amount = 1000
+	if changed, _ := hashPaymentRequest(&retry); changed == first {
+		t.Error("un corps différent doit produire une autre empreinte")
+	}
+}
+
+func TestInitiatePaymentIdempotentRejectsInvalidKey(t *testing.T) {
+	service := NewPaymentService(nil, NewPaymentProviderRegistry(), nil, nil, nil, nil)
+	req := &CreatePaymentRequest{Amount: 200, PaymentMethod: "mock", Phone: "+221771234567"}
+
+	for _, key := range []string{"", string(make([]byte, 256))} {
+		if _, _, _, err := service.InitiatePaymentIdempotent("4f0c1a52-6a3e-4b4c-9a55-3f0c8e2d1b7a", key, req); !errors.Is(err, ErrIdempotencyKeyInvalid) {
+			t.Errorf("clé de %d caractères: erreur attendue ErrIdempotencyKeyInvalid, obtenu %v", len(key), err)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// internal/services/payment_provider.go
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"senmarket/internal/models"
+)
+
+var (
+	ErrProviderNotSupported    = errors.New("provider non supporté")
+	ErrOperationNotSupported   = errors.New("opération non supportée par ce provider")
+	ErrInvalidWebhookSignature = errors.New("signature webhook invalide")
+)
+
+// PaymentProvider contrat commun des opérateurs de paiement (Orange Money, Wave, Free Money...)
+type PaymentProvider interface {
+	// Name identifiant du provider, identique à payment_method
+	Name() string
+	// Initiate démarre le paiement chez l'opérateur
+	Initiate(payment *models.Payment, phone string) (*PaymentInitiation, error)
+	// VerifySignature authentifie une notification entrante
+	VerifySignature(headers http.Header, body []byte) error
+	// ParseWebhook convertit une notification en événement normalisé
+	ParseWebhook(body []byte) (*PaymentNotification, error)
+	// QueryStatus interroge l'opérateur sur l'état d'un paiement
+	QueryStatus(payment *models.Payment) (*PaymentNotification, error)
+	// Refund rembourse tout ou partie d'un paiement terminé
+	Refund(payment *models.Payment, amount float64) error
+}
+
+// PaymentInitiation réponse normalisée à l'initiation d'un paiement
+type PaymentInitiation struct {
+	Status     string `json:"status"`
+	PaymentURL string `json:"payment_url"`
+	OrderID    string `json:"order_id"`
+	Reference  string `json:"reference"`
+	Message    string `json:"message"`
+}
+
+// PaymentNotification état d'un paiement tel que rapporté par l'opérateur
+type PaymentNotification struct {
+	EventID       string  `json:"event_id,omitempty"`
+	PaymentID     string  `json:"payment_id,omitempty"` // ID SenMarket (order_id / client_reference)
+	Reference     string  `json:"reference,omitempty"`  // Référence stockée dans transaction_id
+	Status        string  `json:"status"`               // pending, completed, failed, cancelled
+	Amount        float64 `json:"amount,omitempty"`   // 0 : montant non communiqué par le provider
+	Currency      string  `json:"currency,omitempty"` // Vide : devise non communiquée
+	FailureReason string  `json:"failure_reason,omitempty"`
+}
+
+// PaymentProviderRegistry registre des providers disponibles
+type PaymentProviderRegistry struct {
+	mu        sync.RWMutex
+	providers map[string]PaymentProvider
+}
+
+func NewPaymentProviderRegistry(providers ...PaymentProvider) *PaymentProviderRegistry {
+	registry := &PaymentProviderRegistry{
+		providers: make(map[string]PaymentProvider),
+	}
+	for _, provider := range providers {
+		registry.Register(provider)
+	}
+	return registry
+}
+
+// Register ajoute (ou remplace) un provider
+func (r *PaymentProviderRegistry) Register(provider PaymentProvider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.providers[provider.Name()] = provider
+}
+
+// Get récupère un provider par nom
+func (r *PaymentProviderRegistry) Get(name string) (PaymentProvider, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	provider, ok := r.providers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrProviderNotSupported, name)
+	}
+	return provider, nil
+}
+
+// Names liste les providers enregistrés
+func (r *PaymentProviderRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,80 @@
+// internal/services/payment_provider_test.go
+package services
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"senmarket/internal/models"
+)
+
+func TestPaymentProviderRegistry(t *testing.T) {
+	registry := NewPaymentProviderRegistry(
+		NewOrangeMoneyProvider("sandbox", "", "", "development"),
+		NewWaveProvider(NewWaveClient("", "", "", "development")),
+		NewFreeMoneyProvider("", "development"),
+	)
+	registry.Register(NewMockPaymentProvider())
+
+	want := []string{"free_money", "mock", "orange_money", "wave"}
+	if got := registry.Names(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Names() = %v, attendu %v", got, want)
+	}
+
+	provider, err := registry.Get("wave")
+	if err != nil || provider.Name() != "wave" {
+		t.Fatalf("Get(wave) = %v, %v", provider, err)
+	}
+
+	if _, err := registry.Get("card"); !errors.Is(err, ErrProviderNotSupported) {
+		t.Fatalf("erreur attendue ErrProviderNotSupported, obtenu %v", err)
+	}
+}
+
+func TestOrangeMoneyProviderParseWebhook(t *testing.T) {
+	provider := NewOrangeMoneyProvider("sandbox", "", "", "development")
+
+	tests := map[string]string{
+		`{"order_id":"4f0c1a52-6a3e-4b4c-9a55-3f0c8e2d1b7a","status":"success"}`:                             "completed",
+		`{"order_id":"4f0c1a52-6a3e-4b4c-9a55-3f0c8e2d1b7a","status":"cancelled","failure_reason":"annulé"}`: "failed",
+		`{"order_id":"4f0c1a52","status":"INITIATED"}`:                                                       "pending",
+	}
+	for body, want := range tests {
+		notification, err := provider.ParseWebhook([]byte(body))
+		if err != nil {
+			t.Fatalf("%s: %v", body, err)
+		}
+		if notification.Status != want {
+			t.Errorf("%s: statut = %q, attendu %q", body, notification.Status, want)
+		}
+		if notification.Reference != "SENMARKET-4f0c1a52" {
+			t.Errorf("%s: référence = %q", body, notification.Reference)
+		}
+	}
+
+	if _, err := provider.ParseWebhook([]byte(`{"status":"success"}`)); err == nil {
+		t.Fatal("order_id manquant accepté")
+	}
+}
+
+func TestWaveProviderRejectsUnsignedWebhook(t *testing.T) {
+	provider := NewWaveProvider(NewWaveClient("", "wave-test-key", "wave-webhook-secret", "production"))
+
+	headers := http.Header{}
+	headers.Set("Wave-Signature", "t=1639081943,v1=deadbeef")
+
+	err := provider.VerifySignature(headers, []byte(`{"id":"EV_1","type":"checkout.session.completed"}`))
+	if !errors.Is(err, ErrInvalidWebhookSignature) {
+		t.Fatalf("erreur attendue ErrInvalidWebhookSignature, obtenu %v", err)
+	}
+}
+
+func TestFreeMoneyProviderRefusesInitiation(t *testing.T) {
+	// Sans API marchand, aucun lien de paiement ne doit être fabriqué
+	initiation, err := NewFreeMoneyProvider("", "development").Initiate(&models.Payment{Amount: 200}, "+221761234567")
+	if initiation != nil || !errors.Is(err, ErrOperationNotSupported) {
+		t.Fatalf("Initiate = %v, %v ; attendu ErrOperationNotSupported", initiation, err)
+	}
+}
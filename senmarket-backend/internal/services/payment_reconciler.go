@@ -0,0 +1,203 @@
+// internal/services/payment_reconciler.go
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"senmarket/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	reconcileBatchSize  = 100
+	reconcileMaxReports = 20
+)
+
+// PaymentReconciler interroge les providers pour les paiements restés en attente
+// (webhook jamais reçu) et les finalise par le même chemin que les webhooks.
+type PaymentReconciler struct {
+	db             *gorm.DB
+	paymentService *PaymentService
+	interval       time.Duration
+	pendingAfter   time.Duration
+	pendingExpiry  time.Duration
+
+	runMu   sync.Mutex // Une seule exécution à la fois
+	mu      sync.RWMutex
+	reports []*ReconciliationReport
+}
+
+// ReconciliationReport rapport d'une exécution du réconciliateur
+type ReconciliationReport struct {
+	StartedAt    time.Time            `json:"started_at"`
+	FinishedAt   time.Time            `json:"finished_at"`
+	Duration     string               `json:"duration"`
+	Trigger      string               `json:"trigger"` // scheduler, manual
+	Checked      int                  `json:"checked"`
+	Completed    int                  `json:"completed"`
+	Failed       int                  `json:"failed"`
+	Expired      int                  `json:"expired"`
+	StillPending int                  `json:"still_pending"`
+	Unsupported  int                  `json:"unsupported"`
+	Errors       int                  `json:"errors"`
+	Items        []ReconciliationItem `json:"items"`
+}
+
+// ReconciliationItem résultat pour un paiement
+type ReconciliationItem struct {
+	PaymentID      string `json:"payment_id"`
+	Provider       string `json:"provider"`
+	PreviousStatus string `json:"previous_status"`
+	ProviderStatus string `json:"provider_status,omitempty"`
+	Status         string `json:"status"`
+	Error          string `json:"error,omitempty"`
+}
+
+func NewPaymentReconciler(db *gorm.DB, paymentService *PaymentService, interval, pendingAfter, pendingExpiry time.Duration) *PaymentReconciler {
+	return &PaymentReconciler{
+		db:             db,
+		paymentService: paymentService,
+		interval:       interval,
+		pendingAfter:   pendingAfter,
+		pendingExpiry:  pendingExpiry,
+	}
+}
+
+// Start lance la boucle périodique jusqu'à l'annulation du contexte
+func (r *PaymentReconciler) Start(ctx context.Context) {
+	log.Printf("🔁 Réconciliation paiements active (toutes les %s, pending > %s)", r.interval, r.pendingAfter)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report := r.Run("scheduler")
+			if report.Checked > 0 {
+				log.Printf("🔁 Réconciliation: %d vérifiés, %d complétés, %d échoués, %d expirés, %d en attente, %d erreurs",
+					report.Checked, report.Completed, report.Failed, report.Expired, report.StillPending, report.Errors)
+			}
+		}
+	}
+}
+
+// Run exécute une passe de réconciliation et conserve son rapport
+func (r *PaymentReconciler) Run(trigger string) *ReconciliationReport {
+	r.runMu.Lock()
+	defer r.runMu.Unlock()
+
+	report := &ReconciliationReport{
+		StartedAt: time.Now(),
+		Trigger:   trigger,
+		Items:     []ReconciliationItem{},
+	}
+
+	var payments []models.Payment
+	cutoff := time.Now().Add(-r.pendingAfter)
+	if err := r.db.Where("status = ? AND created_at <= ?", "pending", cutoff).
+		Order("created_at ASC").
+		Limit(reconcileBatchSize).
+		Find(&payments).Error; err != nil {
+		log.Printf("❌ Réconciliation: erreur récupération paiements: %v", err)
+		report.Errors++
+	}
+
+	for i := range payments {
+		report.add(r.reconcile(&payments[i]))
+	}
+
+	report.FinishedAt = time.Now()
+	report.Duration = report.FinishedAt.Sub(report.StartedAt).Round(time.Millisecond).String()
+	r.store(report)
+
+	return report
+}
+
+// reconcile synchronise un paiement avec son provider
+func (r *PaymentReconciler) reconcile(payment *models.Payment) ReconciliationItem {
+	item := ReconciliationItem{
+		PaymentID:      payment.ID.String(),
+		Provider:       payment.PaymentMethod,
+		PreviousStatus: payment.Status,
+	}
+
+	providerStatus, err := r.paymentService.syncPaymentWithProvider(payment)
+	item.ProviderStatus = providerStatus
+	item.Status = payment.Status
+
+	// Toujours en attente au-delà de la durée maximale : abandon, y compris quand le
+	// provider est en erreur ou n'est plus enregistré (sinon le paiement resterait
+	// indéfiniment en tête de file et bloquerait les plus récents)
+	if payment.Status == "pending" && time.Since(payment.CreatedAt) > r.pendingExpiry {
+		if expireErr := r.paymentService.applyNotification(payment, &PaymentNotification{
+			PaymentID:     payment.ID.String(),
+			Status:        "failed",
+			FailureReason: "paiement expiré sans confirmation du provider",
+		}); expireErr != nil {
+			item.Error = expireErr.Error()
+			return item
+		}
+		item.Status = "expired"
+		return item
+	}
+
+	if err != nil && !errors.Is(err, ErrOperationNotSupported) {
+		item.Error = err.Error()
+		return item
+	}
+
+	if err != nil {
+		item.Status = "unsupported"
+	}
+	return item
+}
+
+// add comptabilise un résultat
+func (report *ReconciliationReport) add(item ReconciliationItem) {
+	report.Checked++
+	report.Items = append(report.Items, item)
+
+	switch {
+	case item.Error != "":
+		report.Errors++
+	case item.Status == "expired":
+		report.Expired++
+	case item.Status == "unsupported":
+		report.Unsupported++
+	case item.Status == "completed":
+		report.Completed++
+	case item.Status == "failed" || item.Status == "cancelled":
+		report.Failed++
+	default:
+		report.StillPending++
+	}
+}
+
+// store conserve les derniers rapports en mémoire
+func (r *PaymentReconciler) store(report *ReconciliationReport) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.reports = append([]*ReconciliationReport{report}, r.reports...)
+	if len(r.reports) > reconcileMaxReports {
+		r.reports = r.reports[:reconcileMaxReports]
+	}
+}
+
+// Reports retourne les derniers rapports (le plus récent en premier)
+func (r *PaymentReconciler) Reports() []*ReconciliationReport {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	reports := make([]*ReconciliationReport, len(r.reports))
+	copy(reports, r.reports)
+	return reports
+}
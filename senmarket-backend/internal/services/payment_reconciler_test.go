@@ -0,0 +1,72 @@
+// internal/services/payment_reconciler_test.go
+package services
+
+import (
+	"testing"
+	"time"
+
+	"senmarket/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func TestReconciliationReportCounts(t *testing.T) {
+	report := &ReconciliationReport{}
+	for _, item := range []ReconciliationItem{
+		{PaymentID: "1", Status: "completed"},
+		{PaymentID: "2", Status: "failed"},
+		{PaymentID: "3", Status: "pending"},
+		{PaymentID: "4", Status: "expired"},
+		{PaymentID: "5", Status: "unsupported"},
+		{PaymentID: "6", Status: "pending", Error: "timeout"},
+	} {
+		report.add(item)
+	}
+
+	if report.Checked != 6 || report.Completed != 1 || report.Failed != 1 || report.StillPending != 1 ||
+		report.Expired != 1 || report.Unsupported != 1 || report.Errors != 1 {
+		t.Fatalf("compteurs inattendus: %+v", report)
+	}
+}
+
+func TestPaymentReconcilerKeepsLatestReports(t *testing.T) {
+	reconciler := NewPaymentReconciler(nil, nil, 0, 0, 0)
+	for i := 0; i < reconcileMaxReports+5; i++ {
+		reconciler.store(&ReconciliationReport{Checked: i})
+	}
+
+	reports := reconciler.Reports()
+	if len(reports) != reconcileMaxReports {
+		t.Fatalf("%d rapports conservés, attendu %d", len(reports), reconcileMaxReports)
+	}
+	if reports[0].Checked != reconcileMaxReports+4 {
+		t.Errorf("le rapport le plus récent doit être en tête, obtenu %d", reports[0].Checked)
+	}
+}
+
+func TestPaymentReconcilerExpiresPaymentsWithProviderErrors(t *testing.T) {
+	db, _ := dryRunDB(t)
+	// Aucun provider enregistré : la synchronisation échoue (ex. mock retiré)
+	paymentService := NewPaymentService(db, NewPaymentProviderRegistry(), nil, nil, nil, nil)
+	reconciler := NewPaymentReconciler(db, paymentService, time.Minute, 15*time.Minute, 24*time.Hour)
+
+	stale := &models.Payment{
+		ID:            uuid.New(),
+		PaymentMethod: "mock",
+		Status:        "pending",
+		CreatedAt:     time.Now().Add(-48 * time.Hour),
+	}
+	if item := reconciler.reconcile(stale); item.Status != "expired" || item.Error != "" {
+		t.Fatalf("paiement ancien en erreur: %+v, attendu expiré", item)
+	}
+
+	recent := &models.Payment{
+		ID:            uuid.New(),
+		PaymentMethod: "mock",
+		Status:        "pending",
+		CreatedAt:     time.Now().Add(-time.Hour),
+	}
+	if item := reconciler.reconcile(recent); item.Status != "pending" || item.Error == "" {
+		t.Fatalf("paiement récent en erreur: %+v, attendu en attente avec erreur", item)
+	}
+}
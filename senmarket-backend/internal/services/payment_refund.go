@@ -0,0 +1,242 @@
+// internal/services/payment_refund.go
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"senmarket/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrPaymentNotRefundable = errors.New("paiement non remboursable")
+	ErrInvalidRefundAmount  = errors.New("montant de remboursement invalide")
+	ErrRefundFailed         = errors.New("remboursement refusé par le provider")
+)
+
+// settledPaymentStatuses statuts qu'un webhook ou la réconciliation ne doivent plus modifier
+var settledPaymentStatuses = []string{"completed", "refunded", "partially_refunded"}
+
+type RefundPaymentRequest struct {
+	Amount           float64 `json:"amount" validate:"omitempty,gt=0"` // 0 = remboursement total du restant
+	Reason           string  `json:"reason" validate:"required,min=5,max=500"`
+	UnpublishListing bool    `json:"unpublish_listing"` // Retire ce que le paiement a acheté : publication, option ou renouvellement
+}
+
+// RefundPayment rembourse tout ou partie d'un paiement complété.
+// Le montant est réservé en base avant l'appel au provider puis libéré en cas d'échec,
+// ce qui empêche deux remboursements concurrents de dépasser le montant payé.
+func (s *PaymentService) RefundPayment(paymentID, requestedBy string, req *RefundPaymentRequest) (*models.Refund, error) {
+	var payment models.Payment
+	var refund models.Refund
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", paymentID).First(&payment).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrPaymentNotFound
+			}
+			return err
+		}
+
+		// Un rechargement déjà crédité sur le portefeuille n'est pas remboursable ici
+		if !payment.IsRefundable() || payment.Purpose == models.PaymentPurposeWalletTopUp {
+			return ErrPaymentNotRefundable
+		}
+
+		amount, err := refundAmount(&payment, req.Amount)
+		if err != nil {
+			return err
+		}
+
+		refund = models.Refund{
+			PaymentID:        payment.ID,
+			Amount:           amount,
+			Currency:         payment.Currency,
+			Reason:           req.Reason,
+			Status:           "pending",
+			UnpublishListing: req.UnpublishListing,
+		}
+		if adminID, err := uuid.Parse(requestedBy); err == nil {
+			refund.RequestedBy = &adminID
+		}
+		if err := tx.Create(&refund).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.Payment{}).Where("id = ?", payment.ID).
+			Update("refunded_amount", gorm.Expr("refunded_amount + ?", amount)).Error
+	})
+	if err != nil {
+		if errors.Is(err, ErrPaymentNotFound) || errors.Is(err, ErrPaymentNotRefundable) || errors.Is(err, ErrInvalidRefundAmount) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erreur préparation remboursement: %w", err)
+	}
+
+	// Appel au provider hors transaction pour ne pas garder le verrou pendant l'appel réseau
+	if err := s.callProviderRefund(&payment, &refund); err != nil {
+		s.failRefund(&refund, err)
+		return &refund, fmt.Errorf("%w: %v", ErrRefundFailed, err)
+	}
+
+	if err := s.completeRefund(&payment, &refund); err != nil {
+		return &refund, err
+	}
+
+	log.Printf("💸 Remboursement %s: %.0f %s sur le paiement %s (%s)",
+		refund.ID, refund.Amount, refund.Currency, payment.ID, payment.Status)
+
+	return &refund, nil
+}
+
+// callProviderRefund délègue le remboursement au provider du paiement
+// (ou recrédite le portefeuille pour un achat réglé par portefeuille)
+func (s *PaymentService) callProviderRefund(payment *models.Payment, refund *models.Refund) error {
+	if payment.PaymentMethod == models.PaymentMethodWallet {
+		if s.walletService == nil {
+			return ErrProviderNotSupported
+		}
+		return s.walletService.RefundToWallet(payment, refund)
+	}
+
+	provider, err := s.providers.Get(payment.PaymentMethod)
+	if err != nil {
+		return err
+	}
+	return provider.Refund(payment, refund.Amount)
+}
+
+// completeRefund finalise le remboursement et met à jour le statut du paiement
+func (s *PaymentService) completeRefund(payment *models.Payment, refund *models.Refund) error {
+	now := time.Now()
+	listingChanged := false
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(refund).Updates(map[string]interface{}{
+			"status":       "completed",
+			"completed_at": now,
+		}).Error; err != nil {
+			return err
+		}
+
+		// Relire le cumul : d'autres remboursements ont pu être réservés entre-temps
+		if err := tx.Select("amount", "refunded_amount").First(payment, "id = ?", payment.ID).Error; err != nil {
+			return err
+		}
+
+		status := "partially_refunded"
+		if payment.RefundableAmount() <= 0 {
+			status = "refunded"
+		}
+		if err := tx.Model(&models.Payment{}).Where("id = ?", payment.ID).Updates(map[string]interface{}{
+			"status":     status,
+			"updated_at": now,
+		}).Error; err != nil {
+			return err
+		}
+		payment.Status = status
+
+		// Pack intégralement remboursé : les crédits restants sont annulés
+		if status == "refunded" && isListingPackPurpose(payment.Purpose) && s.quotaService != nil {
+			if err := s.quotaService.RevokePackCreditsTx(tx, payment.ID); err != nil {
+				return err
+			}
+		}
+
+		if !refund.UnpublishListing || payment.ListingID == nil {
+			return nil
+		}
+
+		// Retirer ce que le paiement a acheté, sans toucher au reste de l'annonce
+		changed, err := s.revokePaymentEffectTx(tx, payment, now)
+		listingChanged = changed
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("erreur finalisation remboursement: %w", err)
+	}
+
+	refund.Status = "completed"
+	refund.CompletedAt = &now
+
+	if listingChanged && s.listingService != nil {
+		s.listingService.InvalidateListingCache(*payment.ListingID)
+	}
+
+	return nil
+}
+
+// revokePaymentEffectTx annule l'objet d'un paiement d'annonce remboursé :
+// fenêtre de boost ou de mise en couleur, prolongation d'un renouvellement, ou publication.
+// Retourne true si l'annonce a été modifiée (caches à invalider après le commit).
+func (s *PaymentService) revokePaymentEffectTx(tx *gorm.DB, payment *models.Payment, now time.Time) (bool, error) {
+	switch {
+	case isListingOptionPurpose(payment.Purpose):
+		return revokeListingOptionTx(tx, payment, now)
+
+	case payment.Purpose == models.PaymentPurposeRenewal:
+		return revokeListingRenewalTx(tx, payment, now)
+
+	case payment.Purpose == "" || payment.Purpose == models.PaymentPurposeListing:
+		// Repasser l'annonce en brouillon : elle ne doit plus être visible une fois remboursée
+		result := tx.Model(&models.Listing{}).
+			Where("id = ? AND status = ?", *payment.ListingID, "active").
+			Updates(map[string]interface{}{
+				"status":     "draft",
+				"updated_at": now,
+			})
+		return result.RowsAffected > 0, result.Error
+	}
+
+	return false, nil
+}
+
+// failRefund marque le remboursement comme échoué et libère le montant réservé
+func (s *PaymentService) failRefund(refund *models.Refund, cause error) {
+	refund.Status = "failed"
+	refund.FailureReason = cause.Error()
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(refund).Updates(map[string]interface{}{
+			"status":         "failed",
+			"failure_reason": refund.FailureReason,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Payment{}).Where("id = ?", refund.PaymentID).
+			Update("refunded_amount", gorm.Expr("GREATEST(refunded_amount - ?, 0)", refund.Amount)).Error
+	})
+	if err != nil {
+		log.Printf("❌ Erreur enregistrement échec remboursement %s: %v", refund.ID, err)
+	}
+}
+
+// refundAmount valide le montant demandé (0 = tout le restant remboursable)
+func refundAmount(payment *models.Payment, requested float64) (float64, error) {
+	remaining := payment.RefundableAmount()
+	if requested == 0 {
+		return remaining, nil
+	}
+	if requested < 0 || math.IsNaN(requested) || requested > remaining {
+		return 0, ErrInvalidRefundAmount
+	}
+	return requested, nil
+}
+
+// isSettledPaymentStatus indique si le paiement est finalisé
+func isSettledPaymentStatus(status string) bool {
+	for _, s := range settledPaymentStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+// internal/services/payment_refund_test.go
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"senmarket/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func TestRefundAmount(t *testing.T) {
+	payment := &models.Payment{Amount: 1000, RefundedAmount: 300, Status: "partially_refunded"}
+
+	tests := []struct {
+		name      string
+		requested float64
+		want      float64
+		wantErr   error
+	}{
+		{"total du restant", 0, 700, nil},
+		{"partiel", 200, 200, nil},
+		{"exactement le restant", 700, 700, nil},
+		{"au-delà du restant", 701, 0, ErrInvalidRefundAmount},
+		{"négatif", -50, 0, ErrInvalidRefundAmount},
+	}
+
+	for _, tt := range tests {
+		got, err := refundAmount(payment, tt.requested)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: erreur = %v, attendu %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: montant = %.0f, attendu %.0f", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPaymentIsRefundable(t *testing.T) {
+	tests := []struct {
+		payment models.Payment
+		want    bool
+	}{
+		{models.Payment{Amount: 1000, Status: "completed"}, true},
+		{models.Payment{Amount: 1000, RefundedAmount: 400, Status: "partially_refunded"}, true},
+		{models.Payment{Amount: 1000, RefundedAmount: 1000, Status: "refunded"}, false},
+		{models.Payment{Amount: 1000, Status: "pending"}, false},
+		{models.Payment{Amount: 1000, Status: "failed"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.payment.IsRefundable(); got != tt.want {
+			t.Errorf("%s (%.0f remboursés) = %v, attendu %v", tt.payment.Status, tt.payment.RefundedAmount, got, tt.want)
+		}
+	}
+
+	if !isSettledPaymentStatus("partially_refunded") || isSettledPaymentStatus("pending") {
+		t.Error("statuts finalisés incorrects")
+	}
+}
+
+func TestRevokePaymentEffectTxByPurpose(t *testing.T) {
+	tests := []struct {
+		purpose   string
+		wantTable string
+		unpublish bool
+	}{
+		{models.PaymentPurposeListing, `"listings"`, true},
+		{models.PaymentPurposeBoost, `"listing_boosts"`, false},
+		{models.PaymentPurposeHighlight, `"listing_boosts"`, false},
+		{models.PaymentPurposeRenewal, `"listing_renewals"`, false},
+	}
+
+	for _, tt := range tests {
+		db, queries := dryRunDB(t)
+		service := NewPaymentService(db, NewPaymentProviderRegistry(), nil, nil, nil, nil)
+		listingID := uuid.New()
+		payment := &models.Payment{ID: uuid.New(), ListingID: &listingID, Purpose: tt.purpose}
+
+		// En dry-run aucune ligne n'est lue : seules les requêtes construites sont vérifiées
+		_, _ = service.revokePaymentEffectTx(db, payment, time.Now())
+
+		touched, unpublished := false, false
+		for _, query := range queries() {
+			if strings.Contains(query, tt.wantTable) {
+				touched = true
+			}
+			if strings.HasPrefix(query, `UPDATE "listings"`) && strings.Contains(query, `"status"`) {
+				unpublished = true
+			}
+		}
+		if !touched {
+			t.Errorf("%s: %s non modifiée: %v", tt.purpose, tt.wantTable, queries())
+		}
+		if unpublished != tt.unpublish {
+			t.Errorf("%s: annonce dépubliée = %v, attendu %v", tt.purpose, unpublished, tt.unpublish)
+		}
+	}
+}
@@ -2,14 +2,18 @@
 package services
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
+	"math"
 	"net/http"
+	"strings"
 	"time"
 
 	"senmarket/internal/models"
+	"senmarket/internal/repository/redis"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -19,42 +23,27 @@ var (
 	ErrPaymentFailed    = errors.New("paiement échoué")
 	ErrPaymentNotFound  = errors.New("paiement non trouvé")
 	ErrInvalidAmount    = errors.New("montant invalide")
+	ErrInvalidListingID = errors.New("identifiant d'annonce invalide")
 )
 
+// DefaultStandardListingPrice prix d'une annonce en FCFA sans configuration (défaut de PricingConfig)
+const DefaultStandardListingPrice = 200.0
+
 type PaymentService struct {
-	db                 *gorm.DB
-	orangeMoneyAPIURL  string
-	orangeMoneyKey     string
-	orangeMoneySecret  string
+	db        *gorm.DB
+	providers      *PaymentProviderRegistry
+	cache          *redis.CacheRepository
+	listingService *ListingService
+	quotaService   *QuotaService
+	walletService  *WalletService
 }
 
 type CreatePaymentRequest struct {
-	ListingID     string  `json:"listing_id,omitempty"`
+	ListingID     string  `json:"listing_id,omitempty" validate:"omitempty,uuid"`
 	Amount        float64 `json:"amount" validate:"required,min=200"`
-	PaymentMethod string  `json:"payment_method" validate:"required,oneof=orange_money wave free_money"`
-	Phone         string  `json:"phone" validate:"required"`
-}
-
-type OrangeMoneyPaymentRequest struct {
-	MerchantKey    string  `json:"merchant_key"`
-	Currency       string  `json:"currency"`
-	OrderID        string  `json:"order_id"`
-	Amount         float64 `json:"amount"`
-	ReturnURL      string  `json:"return_url"`
-	CancelURL      string  `json:"cancel_url"`
-	NotifURL       string  `json:"notif_url"`
-	Lang           string  `json:"lang"`
-	Reference      string  `json:"reference"`
-	CustomerPhone  string  `json:"customer_phone"`
-	CustomerEmail  string  `json:"customer_email,omitempty"`
-}
-
-type OrangeMoneyResponse struct {
-	Status      string `json:"status"`
-	PaymentURL  string `json:"payment_url"`
-	OrderID     string `json:"order_id"`
-	Message     string `json:"message"`
-	Error       string `json:"error,omitempty"`
+	PaymentMethod string  `json:"payment_method" validate:"required,oneof=orange_money wave mock wallet"`
+	Phone         string  `json:"phone" validate:"required_unless=PaymentMethod wallet"`
+	Purpose       string  `json:"purpose,omitempty" validate:"omitempty,oneof=listing pack_5 pack_10 boost highlight renewal wallet_topup"`
 }
 
 type PaymentWebhook struct {
@@ -64,79 +53,56 @@ type PaymentWebhook struct {
 	TransactionID string  `json:"transaction_id"`
 	Reference     string  `json:"reference"`
 	Signature     string  `json:"signature"`
+	Timestamp     int64   `json:"timestamp"`
 }
 
-func NewPaymentService(db *gorm.DB, orangeAPIURL, orangeKey, orangeSecret string) *PaymentService {
+func NewPaymentService(db *gorm.DB, providers *PaymentProviderRegistry, cache *redis.CacheRepository, listingService *ListingService, quotaService *QuotaService, walletService *WalletService) *PaymentService {
 	return &PaymentService{
-		db:                db,
-		orangeMoneyAPIURL: orangeAPIURL,
-		orangeMoneyKey:    orangeKey,
-		orangeMoneySecret: orangeSecret,
+		db:             db,
+		providers:      providers,
+		cache:          cache,
+		listingService: listingService,
+		quotaService:   quotaService,
+		walletService:  walletService,
 	}
 }
 
-// InitiatePayment initie un paiement
-func (s *PaymentService) InitiatePayment(userID string, req *CreatePaymentRequest) (*models.Payment, *OrangeMoneyResponse, error) {
-	// Créer l'enregistrement de paiement
-	payment := models.Payment{
-		UserID:        uuid.MustParse(userID),
-		Amount:        req.Amount,
-		Currency:      "XOF",
-		PaymentMethod: req.PaymentMethod,
-		Status:        "pending",
+// SupportedMethods liste les moyens de paiement enregistrés
+func (s *PaymentService) SupportedMethods() []string {
+	methods := s.providers.Names()
+	if s.walletService != nil {
+		methods = append(methods, models.PaymentMethodWallet)
 	}
+	return methods
+}
 
-	// Si c'est pour une annonce
-	if req.ListingID != "" {
-		listingUUID := uuid.MustParse(req.ListingID)
-		payment.ListingID = &listingUUID
+// InitiatePayment initie un paiement
+func (s *PaymentService) InitiatePayment(userID string, req *CreatePaymentRequest) (*models.Payment, *PaymentInitiation, error) {
+	// Règlement interne : débit du portefeuille, sans provider
+	if req.PaymentMethod == models.PaymentMethodWallet {
+		return s.payWithWallet(userID, req)
 	}
 
-	if err := s.db.Create(&payment).Error; err != nil {
-		return nil, nil, fmt.Errorf("erreur création paiement: %w", err)
+	provider, err := s.providers.Get(req.PaymentMethod)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// Traitement selon le mode de paiement
-	switch req.PaymentMethod {
-	case "orange_money":
-		return s.processOrangeMoneyPayment(&payment, req.Phone)
-	case "wave":
-		return s.processWavePayment(&payment, req.Phone)
-	case "free_money":
-		return s.processFreeMoneyPayment(&payment, req.Phone)
-	default:
-		return nil, nil, errors.New("méthode de paiement non supportée")
+	payment, err := s.newPayment(userID, req, provider.Name())
+	if err != nil {
+		return nil, nil, err
 	}
-}
 
-// processOrangeMoneyPayment traite le paiement Orange Money
-func (s *PaymentService) processOrangeMoneyPayment(payment *models.Payment, phone string) (*models.Payment, *OrangeMoneyResponse, error) {
-	// Récupérer l'utilisateur pour l'email
-	var user models.User
-	if err := s.db.First(&user, payment.UserID).Error; err != nil {
-		return nil, nil, fmt.Errorf("erreur récupération utilisateur: %w", err)
+	if err := s.db.Create(payment).Error; err != nil {
+		return nil, nil, fmt.Errorf("erreur création paiement: %w", err)
 	}
 
-	// Préparer la requête Orange Money
-	orderID := payment.ID.String()
-	reference := fmt.Sprintf("SENMARKET-%s", orderID[:8])
-
-	orangeReq := OrangeMoneyPaymentRequest{
-		MerchantKey:   s.orangeMoneyKey,
-		Currency:      "XOF",
-		OrderID:       orderID,
-		Amount:        payment.Amount,
-		ReturnURL:     "https://senmarket.sn/payment/success",
-		CancelURL:     "https://senmarket.sn/payment/cancel",
-		NotifURL:      "https://api.senmarket.sn/api/v1/payments/webhook/orange-money",
-		Lang:          "fr",
-		Reference:     reference,
-		CustomerPhone: phone,
-		CustomerEmail: user.Email,
+	// Récupérer l'utilisateur (email, téléphone) pour le provider
+	if err := s.db.First(&payment.User, payment.UserID).Error; err != nil {
+		return nil, nil, fmt.Errorf("erreur récupération utilisateur: %w", err)
 	}
 
-	// Appel API Orange Money
-	response, err := s.callOrangeMoneyAPI(orangeReq)
+	response, err := provider.Initiate(payment, req.Phone)
 	if err != nil {
 		// Marquer le paiement comme échoué
 		s.db.Model(payment).Updates(map[string]interface{}{
@@ -146,172 +112,469 @@ func (s *PaymentService) processOrangeMoneyPayment(payment *models.Payment, phon
 		return payment, nil, err
 	}
 
-	// Mettre à jour le paiement avec la référence
-	s.db.Model(payment).Updates(map[string]interface{}{
-		"transaction_id":   reference,
-		"payment_provider": "orange_money",
-	})
+	// Mettre à jour le paiement avec la référence du provider
+	s.db.Model(payment).Update("transaction_id", response.Reference)
 
 	return payment, response, nil
 }
 
-// callOrangeMoneyAPI appelle l'API Orange Money
-func (s *PaymentService) callOrangeMoneyAPI(req OrangeMoneyPaymentRequest) (*OrangeMoneyResponse, error) {
-	// En mode développement, simuler la réponse
-	if s.orangeMoneyAPIURL == "" || s.orangeMoneyAPIURL == "sandbox" {
-		return &OrangeMoneyResponse{
-			Status:     "success",
-			PaymentURL: fmt.Sprintf("https://sandbox.orange.money/pay?order_id=%s", req.OrderID),
-			OrderID:    req.OrderID,
-			Message:    "Paiement initié avec succès",
-		}, nil
+// newPayment prépare l'enregistrement d'un paiement (objet, montant, annonce) sans le créer
+func (s *PaymentService) newPayment(userID string, req *CreatePaymentRequest, providerName string) (*models.Payment, error) {
+	purpose, amount, err := s.resolvePaymentPurpose(req)
+	if err != nil {
+		return nil, err
+	}
+
+	payment := &models.Payment{
+		UserID:          uuid.MustParse(userID),
+		Purpose:         purpose,
+		Amount:          amount,
+		Currency:        "XOF",
+		PaymentMethod:   req.PaymentMethod,
+		PaymentProvider: providerName,
+		Status:          "pending",
+	}
+
+	// Si c'est pour une annonce
+	if req.ListingID != "" && (purpose == models.PaymentPurposeListing || purpose == models.PaymentPurposeRenewal || isListingOptionPurpose(purpose)) {
+		listingUUID, err := uuid.Parse(req.ListingID)
+		if err != nil {
+			return nil, ErrInvalidListingID
+		}
+		payment.ListingID = &listingUUID
+	}
+
+	// Une publication payante ne concerne qu'un brouillon du vendeur
+	if purpose == models.PaymentPurposeListing {
+		if payment.ListingID == nil {
+			return nil, ErrInvalidListingID
+		}
+		if s.listingService == nil {
+			return nil, ErrListingNotPayable
+		}
+		if err := s.listingService.EnsureListingPayable(payment.UserID, *payment.ListingID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Une option premium ne s'achète que pour sa propre annonce publiée
+	if isListingOptionPurpose(purpose) {
+		if payment.ListingID == nil || s.listingService == nil {
+			return nil, ErrListingNotBoostable
+		}
+		if err := s.listingService.EnsureListingBoostable(payment.UserID, *payment.ListingID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Un renouvellement payant ne concerne qu'une annonce du vendeur proche de l'expiration
+	if purpose == models.PaymentPurposeRenewal {
+		if payment.ListingID == nil || s.listingService == nil {
+			return nil, ErrListingNotRenewable
+		}
+		if err := s.listingService.EnsureListingRenewable(payment.UserID, *payment.ListingID); err != nil {
+			return nil, err
+		}
 	}
 
-	// Appel API réel
-	jsonData, err := json.Marshal(req)
+	return payment, nil
+}
+
+// resolvePaymentPurpose détermine l'objet du paiement et son montant.
+// Le prix d'une annonce, d'un pack ou d'une option vient toujours de la configuration, jamais du client.
+func (s *PaymentService) resolvePaymentPurpose(req *CreatePaymentRequest) (string, float64, error) {
+	if req.Purpose == "" || req.Purpose == models.PaymentPurposeListing {
+		price, err := s.standardListingPrice()
+		if err != nil {
+			return "", 0, err
+		}
+		return models.PaymentPurposeListing, price, nil
+	}
+
+	if req.Purpose == models.PaymentPurposeWalletTopUp {
+		if s.walletService == nil || req.Amount < WalletTopUpMin || req.Amount > WalletTopUpMax {
+			return "", 0, ErrInvalidTopUpAmount
+		}
+		return req.Purpose, req.Amount, nil
+	}
+
+	if isListingOptionPurpose(req.Purpose) && s.quotaService != nil {
+		config, err := s.quotaService.GetGlobalConfig()
+		if err != nil {
+			return "", 0, err
+		}
+		price, _, _ := config.GetListingOptionPrice(req.Purpose)
+		return req.Purpose, price, nil
+	}
+
+	if req.Purpose == models.PaymentPurposeRenewal && s.quotaService != nil {
+		config, err := s.quotaService.GetGlobalConfig()
+		if err != nil {
+			return "", 0, err
+		}
+		price, free := config.GetListingRenewalPrice()
+		if free {
+			return "", 0, ErrRenewalNotPayable
+		}
+		return req.Purpose, price, nil
+	}
+
+	if !isListingPackPurpose(req.Purpose) || s.quotaService == nil {
+		return "", 0, ErrUnknownPack
+	}
+
+	pack, err := s.quotaService.GetListingPack(req.Purpose)
 	if err != nil {
-		return nil, fmt.Errorf("erreur sérialisation JSON: %w", err)
+		return "", 0, err
 	}
+	return pack.Code, pack.Price, nil
+}
 
-	resp, err := http.Post(s.orangeMoneyAPIURL+"/payment/init", "application/json", bytes.NewBuffer(jsonData))
+// standardListingPrice prix de publication d'une annonce (StandardListingPrice, défaut du modèle sans configuration)
+func (s *PaymentService) standardListingPrice() (float64, error) {
+	if s.quotaService == nil {
+		return DefaultStandardListingPrice, nil
+	}
+	config, err := s.quotaService.GetGlobalConfig()
 	if err != nil {
-		return nil, fmt.Errorf("erreur appel API Orange Money: %w", err)
+		return 0, err
+	}
+	if config.StandardListingPrice <= 0 {
+		return DefaultStandardListingPrice, nil
 	}
-	defer resp.Body.Close()
+	return config.StandardListingPrice, nil
+}
 
-	var orangeResp OrangeMoneyResponse
-	if err := json.NewDecoder(resp.Body).Decode(&orangeResp); err != nil {
-		return nil, fmt.Errorf("erreur décodage réponse: %w", err)
+// HandleWebhook authentifie puis applique une notification de paiement.
+// Les notifications refusées (signature, horodatage, rejeu, format) sont journalisées.
+func (s *PaymentService) HandleWebhook(providerName string, headers http.Header, body []byte, remoteIP string) error {
+	provider, err := s.providers.Get(providerName)
+	if err != nil {
+		return err
 	}
 
-	if orangeResp.Status != "success" {
-		return nil, fmt.Errorf("échec Orange Money: %s", orangeResp.Error)
+	if err := provider.VerifySignature(headers, body); err != nil {
+		s.recordRejectedWebhook(providerName, err, headers, body, remoteIP)
+		return err
 	}
 
-	return &orangeResp, nil
-}
+	notification, err := provider.ParseWebhook(body)
+	if err != nil {
+		s.recordRejectedWebhook(providerName, err, headers, body, remoteIP)
+		return err
+	}
 
-// processWavePayment traite le paiement Wave (placeholder)
-func (s *PaymentService) processWavePayment(payment *models.Payment, phone string) (*models.Payment, *OrangeMoneyResponse, error) {
-	// TODO: Implémenter Wave API
-	// Pour l'instant, simulation
-	response := &OrangeMoneyResponse{
-		Status:     "success",
-		PaymentURL: fmt.Sprintf("https://wave.sn/pay?amount=%.0f&phone=%s", payment.Amount, phone),
-		OrderID:    payment.ID.String(),
-		Message:    "Redirection vers Wave",
-	}
-	
-	s.db.Model(payment).Updates(map[string]interface{}{
-		"payment_provider": "wave",
-		"transaction_id":   fmt.Sprintf("WAVE-%s", payment.ID.String()[:8]),
-	})
+	// Protection anti-rejeu : une notification n'est traitée qu'une fois
+	ctx := context.Background()
+	nonceKey := CACHE_WEBHOOK_NONCE + providerName + ":" + webhookNonce(notification, body)
+	if !s.claimWebhookNonce(ctx, nonceKey) {
+		s.recordRejectedWebhook(providerName, ErrWebhookReplay, headers, body, remoteIP)
+		return ErrWebhookReplay
+	}
 
-	return payment, response, nil
+	payment, err := s.findPaymentForNotification(notification)
+	if err == nil {
+		err = s.applyNotification(payment, notification)
+	}
+	if err != nil {
+		// Libérer le nonce pour permettre au provider de réessayer
+		s.releaseWebhookNonce(ctx, nonceKey)
+		return err
+	}
+
+	return nil
 }
 
-// processFreeMoneyPayment traite le paiement Free Money (placeholder)
-func (s *PaymentService) processFreeMoneyPayment(payment *models.Payment, phone string) (*models.Payment, *OrangeMoneyResponse, error) {
-	// TODO: Implémenter Free Money API
-	response := &OrangeMoneyResponse{
-		Status:     "success",
-		PaymentURL: fmt.Sprintf("https://freemoney.sn/pay?amount=%.0f&phone=%s", payment.Amount, phone),
-		OrderID:    payment.ID.String(),
-		Message:    "Redirection vers Free Money",
-	}
-	
-	s.db.Model(payment).Updates(map[string]interface{}{
-		"payment_provider": "free_money",
-		"transaction_id":   fmt.Sprintf("FREE-%s", payment.ID.String()[:8]),
-	})
+// claimWebhookNonce réserve le nonce (SETNX). En cas d'indisponibilité de Redis,
+// le traitement continue : applyNotification ne revient jamais sur un paiement finalisé.
+func (s *PaymentService) claimWebhookNonce(ctx context.Context, key string) bool {
+	if s.cache == nil {
+		return true
+	}
 
-	return payment, response, nil
+	claimed, err := s.cache.SetNX(ctx, key, time.Now().Unix(), WebhookNonceTTL)
+	if err != nil {
+		log.Printf("⚠️ Erreur Redis nonce webhook %s: %v", key, err)
+		return true
+	}
+
+	return claimed
 }
 
-// HandleWebhook traite les webhooks de paiement
-func (s *PaymentService) HandleWebhook(provider string, payload map[string]interface{}) error {
-	switch provider {
-	case "orange_money":
-		return s.handleOrangeMoneyWebhook(payload)
-	case "wave":
-		return s.handleWaveWebhook(payload)
-	case "free_money":
-		return s.handleFreeMoneyWebhook(payload)
-	default:
-		return errors.New("provider non supporté")
+// releaseWebhookNonce supprime le nonce après un échec de traitement
+func (s *PaymentService) releaseWebhookNonce(ctx context.Context, key string) {
+	if s.cache == nil {
+		return
+	}
+	if err := s.cache.Del(ctx, key); err != nil {
+		log.Printf("⚠️ Erreur suppression nonce webhook %s: %v", key, err)
 	}
 }
 
-// handleOrangeMoneyWebhook traite les webhooks Orange Money - VERSION CORRIGÉE
-func (s *PaymentService) handleOrangeMoneyWebhook(payload map[string]interface{}) error {
-	orderID, ok := payload["order_id"].(string)
-	if !ok {
-		return errors.New("order_id manquant")
+// recordRejectedWebhook conserve un webhook refusé pour audit
+func (s *PaymentService) recordRejectedWebhook(provider string, cause error, headers http.Header, body []byte, remoteIP string) {
+	reason := webhookRejectionReason(cause)
+	log.Printf("🚫 Webhook %s rejeté (%s) depuis %s: %v", provider, reason, remoteIP, cause)
+
+	headersJSON, _ := json.Marshal(headers)
+	signature := headers.Get(WebhookSignatureHeader)
+	if signature == "" {
+		signature = headers.Get("Wave-Signature")
 	}
 
-	status, ok := payload["status"].(string)
-	if !ok {
-		return errors.New("status manquant")
+	payload := string(body)
+	if len(payload) > 65536 {
+		payload = payload[:65536]
 	}
 
-	// Récupérer le paiement - accepter à la fois UUID complet et transaction_id
+	rejected := models.RejectedWebhook{
+		Provider:     provider,
+		Reason:       reason,
+		ErrorMessage: cause.Error(),
+		Signature:    signature,
+		Headers:      string(headersJSON),
+		Payload:      payload,
+		RemoteIP:     remoteIP,
+	}
+	if err := s.db.Create(&rejected).Error; err != nil {
+		log.Printf("⚠️ Erreur enregistrement webhook rejeté: %v", err)
+	}
+}
+
+// QueryPaymentStatus interroge le provider puis synchronise le paiement
+func (s *PaymentService) QueryPaymentStatus(paymentID string) (*models.Payment, error) {
 	var payment models.Payment
-	err := s.db.Where("id = ?", orderID).First(&payment).Error
+	if err := s.db.Where("id = ?", paymentID).First(&payment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPaymentNotFound
+		}
+		return nil, fmt.Errorf("erreur récupération paiement: %w", err)
+	}
+
+	// Rien à synchroniser pour un paiement finalisé
+	if payment.Status != "pending" {
+		return &payment, nil
+	}
+
+	if _, err := s.syncPaymentWithProvider(&payment); err != nil {
+		return nil, err
+	}
+
+	return &payment, nil
+}
+
+// syncPaymentWithProvider interroge le provider et applique le statut obtenu
+// (même chemin que les webhooks). Retourne le statut rapporté par le provider.
+func (s *PaymentService) syncPaymentWithProvider(payment *models.Payment) (string, error) {
+	provider, err := s.providers.Get(payment.PaymentMethod)
+	if err != nil {
+		return "", err
+	}
+
+	notification, err := provider.QueryStatus(payment)
 	if err != nil {
-		// Si pas trouvé par ID, essayer par transaction_id
-		if len(orderID) >= 8 {
-			err = s.db.Where("transaction_id LIKE ?", "SENMARKET-"+orderID[:8]+"%").First(&payment).Error
+		return "", err
+	}
+
+	if err := s.applyNotification(payment, notification); err != nil {
+		return "", err
+	}
+
+	return notification.Status, nil
+}
+
+// findPaymentForNotification retrouve le paiement par ID ou par référence provider
+func (s *PaymentService) findPaymentForNotification(notification *PaymentNotification) (*models.Payment, error) {
+	var payment models.Payment
+
+	if _, err := uuid.Parse(notification.PaymentID); err == nil {
+		if err := s.db.Where("id = ?", notification.PaymentID).First(&payment).Error; err == nil {
+			return &payment, nil
 		}
-		if err != nil {
-			return fmt.Errorf("paiement non trouvé: %w", err)
+	}
+
+	if notification.Reference != "" {
+		if err := s.db.Where("transaction_id = ?", notification.Reference).First(&payment).Error; err == nil {
+			return &payment, nil
+		}
+	}
+
+	return nil, ErrPaymentNotFound
+}
+
+// applyNotification applique le statut rapporté par le provider.
+// Un paiement d'annonce complété publie l'annonce et compte l'annonce payée
+// dans la même transaction ; les caches sont invalidés après le commit.
+func (s *PaymentService) applyNotification(payment *models.Payment, notification *PaymentNotification) error {
+	status := notification.Status
+	failureReason := notification.FailureReason
+
+	// Ne jamais revenir sur un paiement déjà finalisé (ou remboursé)
+	if isSettledPaymentStatus(payment.Status) || payment.Status == status {
+		return nil
+	}
+
+	// Un paiement confirmé pour un autre montant ou une autre devise n'est pas honoré
+	if status == "completed" {
+		if reason := notificationAmountMismatch(payment, notification); reason != "" {
+			log.Printf("⚠️ Paiement %s refusé: %s", payment.ID, reason)
+			status = "failed"
+			failureReason = reason
 		}
 	}
 
-	// Mettre à jour selon le statut
+	now := time.Now()
 	updates := map[string]interface{}{
-		"updated_at": time.Now(),
+		"status":     status,
+		"updated_at": now,
 	}
 
 	switch status {
-	case "completed", "success":
-		updates["status"] = "completed"
-		updates["completed_at"] = time.Now()
-		
-		// Si c'est pour une annonce, l'activer
-		if payment.ListingID != nil {
-			s.db.Model(&models.Listing{}).Where("id = ?", payment.ListingID).
-				Update("status", "active")
-		}
+	case "completed":
+		updates["completed_at"] = now
 
 	case "failed", "cancelled":
-		updates["status"] = "failed"
-		if reason, ok := payload["failure_reason"].(string); ok {
-			updates["failure_reason"] = reason
+		if failureReason != "" {
+			updates["failure_reason"] = failureReason
 		}
 
 	case "pending":
-		updates["status"] = "pending"
+	default:
+		return fmt.Errorf("statut de paiement inconnu: %s", status)
 	}
 
-	return s.db.Model(&payment).Updates(updates).Error
+	listingChanged := false
+	var effectErr error
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// Mise à jour conditionnelle : un webhook concurrent ne peut compléter deux fois
+		result := tx.Model(&models.Payment{}).
+			Where("id = ? AND status NOT IN ?", payment.ID, settledPaymentStatuses).
+			Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 || status != "completed" {
+			return nil
+		}
+
+		changed, err := s.applyPaymentEffectsTx(tx, payment)
+		if isUnappliedPaymentEffect(err) {
+			// L'argent est encaissé mais l'annonce n'est plus éligible : le paiement est
+			// enregistré avec la cause, puis remboursé après le commit
+			effectErr = err
+			return tx.Model(&models.Payment{}).Where("id = ?", payment.ID).
+				Update("failure_reason", unappliedEffectReason(err)).Error
+		}
+		listingChanged = changed
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("erreur finalisation paiement: %w", err)
+	}
+
+	payment.Status = status
+	if status == "completed" {
+		payment.CompletedAt = &now
+	}
+
+	if listingChanged {
+		s.listingService.InvalidateListingCache(*payment.ListingID)
+	}
+
+	if effectErr != nil {
+		payment.FailureReason = unappliedEffectReason(effectErr)
+		s.refundUnappliedPayment(payment, effectErr)
+	}
+
+	return nil
 }
 
-// handleWaveWebhook traite les webhooks Wave
-func (s *PaymentService) handleWaveWebhook(payload map[string]interface{}) error {
-	// TODO: Implémenter selon l'API Wave
-	return s.handleOrangeMoneyWebhook(payload) // Temporaire
+// notificationAmountMismatch compare le montant et la devise confirmés par le provider
+// à ceux du paiement ; renvoie la cause de l'écart, ou "" si tout concorde
+func notificationAmountMismatch(payment *models.Payment, notification *PaymentNotification) string {
+	if notification.Amount != 0 && math.Round(notification.Amount*100) != math.Round(payment.Amount*100) {
+		return fmt.Sprintf("montant confirmé (%.2f) différent du montant attendu (%.2f)", notification.Amount, payment.Amount)
+	}
+	if notification.Currency != "" && !strings.EqualFold(notification.Currency, payment.Currency) {
+		return fmt.Sprintf("devise confirmée (%s) différente de la devise attendue (%s)", notification.Currency, payment.Currency)
+	}
+	return ""
 }
 
-// handleFreeMoneyWebhook traite les webhooks Free Money
-func (s *PaymentService) handleFreeMoneyWebhook(payload map[string]interface{}) error {
-	// TODO: Implémenter selon l'API Free Money
-	return s.handleOrangeMoneyWebhook(payload) // Temporaire
+// isUnappliedPaymentEffect l'objet du paiement ne peut plus être appliqué
+// (annonce supprimée, déjà publiée ou plus éligible à l'option)
+func isUnappliedPaymentEffect(err error) bool {
+	return errors.Is(err, ErrListingNotFound) ||
+		errors.Is(err, ErrListingNotBoostable) ||
+		errors.Is(err, ErrListingNotRenewable)
+}
+
+func unappliedEffectReason(err error) string {
+	return "objet du paiement non appliqué: " + err.Error()
+}
+
+// refundUnappliedPayment rembourse un paiement encaissé dont l'objet n'a pas pu être appliqué.
+// En cas d'échec le paiement reste complété avec sa cause (failure_reason) pour un remboursement manuel.
+func (s *PaymentService) refundUnappliedPayment(payment *models.Payment, cause error) {
+	log.Printf("⚠️ Paiement %s (%s): %v, remboursement automatique", payment.ID, payment.Purpose, cause)
+
+	refund, err := s.RefundPayment(payment.ID.String(), "", &RefundPaymentRequest{
+		Reason: "Remboursement automatique : " + cause.Error(),
+	})
+	if err != nil {
+		log.Printf("❌ Paiement %s: remboursement automatique impossible, à traiter manuellement: %v", payment.ID, err)
+		return
+	}
+	payment.Status = "refunded"
+	payment.RefundedAmount += refund.Amount
+}
+
+// applyPaymentEffectsTx applique l'objet d'un paiement complété dans sa transaction :
+// crédits de pack, rechargement du portefeuille, option premium ou publication d'annonce.
+// Retourne true si une annonce a été modifiée (caches à invalider après le commit).
+func (s *PaymentService) applyPaymentEffectsTx(tx *gorm.DB, payment *models.Payment) (bool, error) {
+	// Achat de pack : créditer les annonces prépayées
+	if isListingPackPurpose(payment.Purpose) && s.quotaService != nil {
+		return false, s.quotaService.GrantPackCreditsTx(tx, payment)
+	}
+
+	// Rechargement du portefeuille
+	if payment.Purpose == models.PaymentPurposeWalletTopUp && s.walletService != nil {
+		return false, s.walletService.CreditTopUpTx(tx, payment)
+	}
+
+	if payment.ListingID == nil || s.listingService == nil {
+		return false, nil
+	}
+
+	// Boost ou mise en couleur d'une annonce publiée
+	if isListingOptionPurpose(payment.Purpose) {
+		if err := s.listingService.ApplyListingOptionTx(tx, payment); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	// Renouvellement payant : prolonger l'annonce
+	if payment.Purpose == models.PaymentPurposeRenewal {
+		if err := s.listingService.RenewListingAfterPaymentTx(tx, payment); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	// Si c'est pour une annonce, la publier (quota payé inclus)
+	if err := s.listingService.PublishListingAfterPaymentTx(tx, payment.UserID, *payment.ListingID); err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
 // GetPaymentByID récupère un paiement par ID
 func (s *PaymentService) GetPaymentByID(paymentID string) (*models.Payment, error) {
 	var payment models.Payment
 	if err := s.db.Preload("User").Preload("Listing").
+		Preload("Refunds", func(db *gorm.DB) *gorm.DB { return db.Order("created_at DESC") }).
 		Where("id = ?", paymentID).First(&payment).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrPaymentNotFound
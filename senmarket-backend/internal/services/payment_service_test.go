@@ -0,0 +1,249 @@
+// internal/services/payment_service_test.go
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+
+	"senmarket/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func TestApplyNotificationLostRaceAppliesNoEffect(t *testing.T) {
+	db, queries := dryRunDB(t)
+	quotaService := NewQuotaService(db)
+	listingService := NewListingService(db, nil, quotaService)
+	service := NewPaymentService(db, NewPaymentProviderRegistry(), nil, listingService, quotaService, nil)
+
+	listingID := uuid.New()
+	payment := &models.Payment{
+		ID:        uuid.New(),
+		UserID:    uuid.New(),
+		ListingID: &listingID,
+		Purpose:   models.PaymentPurposeListing,
+		Status:    "pending",
+	}
+
+	// En dry-run la mise à jour conditionnelle n'affecte aucune ligne, comme un webhook concurrent déjà appliqué
+	if err := service.applyNotification(payment, &PaymentNotification{Status: "completed"}); err != nil {
+		t.Fatalf("applyNotification: %v", err)
+	}
+
+	var paymentUpdate string
+	for _, query := range queries() {
+		if strings.Contains(query, `FROM "listings"`) || strings.Contains(query, `UPDATE "listings"`) {
+			t.Fatalf("annonce modifiée sans paiement mis à jour: %s", query)
+		}
+		if strings.HasPrefix(query, `UPDATE "payments"`) {
+			paymentUpdate = query
+		}
+	}
+	if !strings.Contains(paymentUpdate, "status NOT IN") {
+		t.Fatalf("la mise à jour du paiement doit exclure les paiements finalisés: %q", paymentUpdate)
+	}
+
+	payment.Status = "pending"
+	if err := service.applyNotification(payment, &PaymentNotification{Status: "unknown"}); err == nil {
+		t.Fatal("statut inconnu accepté")
+	}
+}
+
+func TestIsUnappliedPaymentEffect(t *testing.T) {
+	for _, err := range []error{
+		ErrListingNotFound,
+		ErrListingNotBoostable,
+		fmt.Errorf("%w: statut sold", ErrListingNotRenewable),
+	} {
+		if !isUnappliedPaymentEffect(err) {
+			t.Errorf("%v: remboursement attendu", err)
+		}
+	}
+	for _, err := range []error{nil, ErrPaymentFailed, fmt.Errorf("erreur base")} {
+		if isUnappliedPaymentEffect(err) {
+			t.Errorf("%v: pas de remboursement attendu", err)
+		}
+	}
+}
+
+func TestPublishListingAfterPaymentTxLocksDraftListing(t *testing.T) {
+	db, queries := dryRunDB(t)
+	service := NewListingService(db, nil, NewQuotaService(db))
+
+	// En dry-run aucune ligne n'est lue : seules les requêtes construites sont vérifiées
+	_ = service.PublishListingAfterPaymentTx(db, uuid.New(), uuid.New())
+
+	var listingQuery string
+	for _, query := range queries() {
+		if strings.Contains(query, `FROM "listings"`) {
+			listingQuery = query
+			break
+		}
+	}
+	if !strings.Contains(listingQuery, "FOR UPDATE") {
+		t.Fatalf("l'annonce doit être verrouillée avant publication: %q", listingQuery)
+	}
+	if !strings.Contains(listingQuery, "user_id =") || !strings.Contains(listingQuery, "status =") {
+		t.Fatalf("seul un brouillon du payeur peut être publié: %q", listingQuery)
+	}
+}
+
+func TestApplyNotificationPublishesAndRefundsListingPayments(t *testing.T) {
+	tx := testTx(t)
+	quotaService := NewQuotaService(tx)
+	listingService := NewListingService(tx, nil, quotaService)
+	service := NewPaymentService(tx, NewPaymentProviderRegistry(), nil, listingService, quotaService, nil)
+
+	user := models.User{
+		Phone:        "+22177" + strconv.Itoa(1000000+rand.Intn(8999999)),
+		PasswordHash: "x",
+		FirstName:    "Test",
+		LastName:     "Paiement",
+		Region:       "Dakar",
+	}
+	if err := tx.Omit("email").Create(&user).Error; err != nil {
+		t.Fatalf("création utilisateur: %v", err)
+	}
+	var category models.Category
+	if err := tx.First(&category).Error; err != nil {
+		t.Skipf("aucune catégorie en base: %v", err)
+	}
+	listing := models.Listing{
+		UserID:      user.ID,
+		CategoryID:  category.ID,
+		Title:       "Annonce de test",
+		Description: "Description de l'annonce de test payée",
+		Price:       1000,
+		Region:      "Dakar",
+		Status:      "draft",
+	}
+	if err := tx.Create(&listing).Error; err != nil {
+		t.Fatalf("création annonce: %v", err)
+	}
+
+	newPayment := func() *models.Payment {
+		payment := &models.Payment{
+			UserID:        user.ID,
+			ListingID:     &listing.ID,
+			Purpose:       models.PaymentPurposeListing,
+			Amount:        DefaultStandardListingPrice,
+			Currency:      "XOF",
+			PaymentMethod: "mock",
+			TransactionID: "TEST-" + uuid.NewString(),
+			Status:        "pending",
+		}
+		if err := tx.Create(payment).Error; err != nil {
+			t.Fatalf("création paiement: %v", err)
+		}
+		return payment
+	}
+
+	// Premier paiement : le brouillon est publié
+	first := newPayment()
+	if err := service.applyNotification(first, &PaymentNotification{Status: "completed"}); err != nil {
+		t.Fatalf("premier paiement: %v", err)
+	}
+	if err := tx.First(&listing, "id = ?", listing.ID).Error; err != nil || listing.Status != "active" {
+		t.Fatalf("annonce = %q (%v), attendu active", listing.Status, err)
+	}
+
+	// Second paiement de la même annonce : déjà publiée, le paiement est conservé pour remboursement
+	second := newPayment()
+	if err := service.applyNotification(second, &PaymentNotification{Status: "completed"}); err != nil {
+		t.Fatalf("second paiement: %v", err)
+	}
+	var stored models.Payment
+	if err := tx.First(&stored, "id = ?", second.ID).Error; err != nil {
+		t.Fatalf("relecture paiement: %v", err)
+	}
+	if stored.Status != "completed" || stored.FailureReason == "" {
+		t.Fatalf("paiement = %q (%q), attendu complété avec la cause", stored.Status, stored.FailureReason)
+	}
+	var refunds int64
+	tx.Model(&models.Refund{}).Where("payment_id = ?", second.ID).Count(&refunds)
+	if refunds != 1 {
+		t.Fatalf("%d remboursement(s) tenté(s), attendu 1", refunds)
+	}
+}
+
+func TestNewPaymentChecksListingPurchase(t *testing.T) {
+	db, queries := dryRunDB(t)
+	quotaService := NewQuotaService(db)
+	service := NewPaymentService(db, NewPaymentProviderRegistry(), nil, NewListingService(db, nil, quotaService), quotaService, nil)
+	userID := uuid.NewString()
+
+	for _, listingID := range []string{"", "pas-un-uuid"} {
+		req := &CreatePaymentRequest{ListingID: listingID, Purpose: models.PaymentPurposeListing, PaymentMethod: "wave"}
+		if _, err := service.newPayment(userID, req, "wave"); !errors.Is(err, ErrInvalidListingID) {
+			t.Errorf("listing_id %q: erreur = %v, attendu ErrInvalidListingID", listingID, err)
+		}
+	}
+
+	// En dry-run l'annonce relue est vide (pas un brouillon) : le paiement est refusé dès l'initiation
+	req := &CreatePaymentRequest{ListingID: uuid.NewString(), PaymentMethod: "wave"}
+	if _, err := service.newPayment(userID, req, "wave"); !errors.Is(err, ErrListingNotPayable) {
+		t.Fatalf("annonce non brouillon: erreur = %v, attendu ErrListingNotPayable", err)
+	}
+
+	var listingQuery string
+	for _, query := range queries() {
+		if strings.Contains(query, `FROM "listings"`) {
+			listingQuery = query
+		}
+	}
+	if !strings.Contains(listingQuery, "user_id =") {
+		t.Fatalf("l'annonce doit appartenir au payeur: %q", listingQuery)
+	}
+}
+
+func TestNotificationAmountMismatch(t *testing.T) {
+	payment := &models.Payment{Amount: 200, Currency: "XOF"}
+	tests := []struct {
+		name         string
+		notification PaymentNotification
+		mismatch     bool
+	}{
+		{"montant et devise conformes", PaymentNotification{Amount: 200, Currency: "XOF"}, false},
+		{"non communiqués", PaymentNotification{}, false},
+		{"devise en minuscules", PaymentNotification{Amount: 200, Currency: "xof"}, false},
+		{"montant inférieur", PaymentNotification{Amount: 100, Currency: "XOF"}, true},
+		{"autre devise", PaymentNotification{Amount: 200, Currency: "EUR"}, true},
+	}
+	for _, tt := range tests {
+		if got := notificationAmountMismatch(payment, &tt.notification) != ""; got != tt.mismatch {
+			t.Errorf("%s: écart = %v, attendu %v", tt.name, got, tt.mismatch)
+		}
+	}
+}
+
+func TestApplyNotificationRejectsAmountMismatch(t *testing.T) {
+	db, queries := dryRunDB(t)
+	quotaService := NewQuotaService(db)
+	service := NewPaymentService(db, NewPaymentProviderRegistry(), nil, NewListingService(db, nil, quotaService), quotaService, nil)
+
+	listingID := uuid.New()
+	payment := &models.Payment{
+		ID:        uuid.New(),
+		UserID:    uuid.New(),
+		ListingID: &listingID,
+		Purpose:   models.PaymentPurposeListing,
+		Amount:    200,
+		Currency:  "XOF",
+		Status:    "pending",
+	}
+	if err := service.applyNotification(payment, &PaymentNotification{Status: "completed", Amount: 1, Currency: "XOF"}); err != nil {
+		t.Fatalf("applyNotification: %v", err)
+	}
+	if payment.Status != "failed" {
+		t.Fatalf("statut = %q, attendu failed", payment.Status)
+	}
+	for _, query := range queries() {
+		if strings.Contains(query, "listings") || strings.Contains(query, "completed_at") {
+			t.Fatalf("paiement sous-payé honoré: %s", query)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+// internal/services/payment_wallet.go
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"senmarket/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// payWithWallet règle un achat par débit du portefeuille. Le paiement, le débit et
+// l'effet de l'achat (publication, option, pack) sont validés dans une seule transaction :
+// le solde et l'état de l'annonce ne peuvent pas diverger.
+func (s *PaymentService) payWithWallet(userID string, req *CreatePaymentRequest) (*models.Payment, *PaymentInitiation, error) {
+	if s.walletService == nil {
+		return nil, nil, ErrProviderNotSupported
+	}
+	if req.Purpose == models.PaymentPurposeWalletTopUp {
+		return nil, nil, fmt.Errorf("%w: rechargement du portefeuille par le portefeuille", ErrOperationNotSupported)
+	}
+
+	payment, err := s.newPayment(userID, req, models.PaymentMethodWallet)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	payment.ID = uuid.New()
+	payment.Status = "completed"
+	payment.CompletedAt = &now
+	payment.TransactionID = "WALLET-" + payment.ID.String()
+
+	listingChanged := false
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(payment).Error; err != nil {
+			return fmt.Errorf("erreur création paiement: %w", err)
+		}
+		if err := s.walletService.DebitTx(tx, payment); err != nil {
+			return err
+		}
+
+		changed, err := s.applyPaymentEffectsTx(tx, payment)
+		listingChanged = changed
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if listingChanged {
+		s.listingService.InvalidateListingCache(*payment.ListingID)
+	}
+
+	if err := s.db.First(&payment.User, payment.UserID).Error; err != nil {
+		log.Printf("⚠️ Paiement portefeuille %s: erreur récupération utilisateur: %v", payment.ID, err)
+	}
+
+	log.Printf("👛 Paiement %s réglé par portefeuille: %.0f FCFA (%s)", payment.ID, payment.Amount, payment.Purpose)
+
+	return payment, &PaymentInitiation{
+		Status:    "success",
+		OrderID:   payment.ID.String(),
+		Reference: payment.TransactionID,
+		Message:   "Paiement réglé avec le portefeuille",
+	}, nil
+}
@@ -0,0 +1,234 @@
+// internal/services/quota_credits.go
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"senmarket/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrNoListingCredits = errors.New("aucun crédit d'annonce disponible")
+	ErrUnknownPack      = errors.New("pack d'annonces inconnu")
+)
+
+const listingCreditHistoryLimit = 50
+
+// ListingCreditsSummary solde et détail des crédits d'annonces d'un utilisateur
+type ListingCreditsSummary struct {
+	Available  int                               `json:"available"`
+	NextExpiry *time.Time                        `json:"next_expiry"`
+	Lots       []models.ListingCredit            `json:"lots"`
+	History    []models.ListingCreditTransaction `json:"history"`
+}
+
+// isListingPackPurpose indique si l'objet du paiement est un pack d'annonces
+func isListingPackPurpose(purpose string) bool {
+	return purpose == models.PaymentPurposePack5 || purpose == models.PaymentPurposePack10
+}
+
+// GetListingPack retourne le pack demandé aux prix de la configuration globale
+func (s *QuotaService) GetListingPack(code string) (*models.ListingPack, error) {
+	config, err := s.GetGlobalConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	pack, ok := config.GetListingPack(code)
+	if !ok {
+		return nil, ErrUnknownPack
+	}
+	return pack, nil
+}
+
+// GetListingPacks liste les packs en vente
+func (s *QuotaService) GetListingPacks() ([]models.ListingPack, error) {
+	config, err := s.GetGlobalConfig()
+	if err != nil {
+		return nil, err
+	}
+	return config.GetListingPacks(), nil
+}
+
+// GetAvailableCredits retourne le nombre de crédits non expirés
+func (s *QuotaService) GetAvailableCredits(userID uuid.UUID) (int, error) {
+	var available int64
+	if err := s.db.Model(&models.ListingCredit{}).
+		Where("user_id = ? AND credits_remaining > 0 AND expires_at > ?", userID, time.Now()).
+		Select("COALESCE(SUM(credits_remaining), 0)").
+		Scan(&available).Error; err != nil {
+		return 0, fmt.Errorf("erreur calcul crédits: %w", err)
+	}
+	return int(available), nil
+}
+
+// GrantPackCreditsTx crédite le pack payé dans la transaction du paiement.
+// Un paiement ne crédite qu'une seule fois (payment_id unique).
+func (s *QuotaService) GrantPackCreditsTx(tx *gorm.DB, payment *models.Payment) error {
+	pack, err := s.GetListingPack(payment.Purpose)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	credit := models.ListingCredit{
+		UserID:           payment.UserID,
+		PaymentID:        &payment.ID,
+		Pack:             pack.Code,
+		CreditsTotal:     pack.Credits,
+		CreditsRemaining: pack.Credits,
+		Price:            payment.Amount,
+		ExpiresAt:        now.AddDate(0, 0, pack.ValidityDays),
+	}
+
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&credit)
+	if result.Error != nil {
+		return fmt.Errorf("erreur création crédits: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		log.Printf("⚠️ Paiement %s: pack déjà crédité", payment.ID)
+		return nil
+	}
+
+	if err := tx.Create(&models.ListingCreditTransaction{
+		UserID:      payment.UserID,
+		CreditID:    credit.ID,
+		Type:        "purchase",
+		Quantity:    pack.Credits,
+		Description: fmt.Sprintf("Achat %s (%d annonces)", pack.Code, pack.Credits),
+	}).Error; err != nil {
+		return fmt.Errorf("erreur journal crédits: %w", err)
+	}
+
+	log.Printf("🎟️ %d crédits d'annonces ajoutés pour utilisateur %s (expiration %s)",
+		pack.Credits, payment.UserID, credit.ExpiresAt.Format("2006-01-02"))
+
+	return nil
+}
+
+// ConsumeListingCreditTx débite un crédit du lot qui expire le plus tôt
+func (s *QuotaService) ConsumeListingCreditTx(tx *gorm.DB, userID, listingID uuid.UUID) error {
+	var credit models.ListingCredit
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND credits_remaining > 0 AND expires_at > ?", userID, time.Now()).
+		Order("expires_at ASC").
+		First(&credit).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNoListingCredits
+		}
+		return fmt.Errorf("erreur récupération crédits: %w", err)
+	}
+
+	if err := tx.Model(&credit).Updates(map[string]interface{}{
+		"credits_remaining": gorm.Expr("credits_remaining - 1"),
+		"updated_at":        time.Now(),
+	}).Error; err != nil {
+		return fmt.Errorf("erreur débit crédit: %w", err)
+	}
+
+	return tx.Create(&models.ListingCreditTransaction{
+		UserID:      userID,
+		CreditID:    credit.ID,
+		ListingID:   &listingID,
+		Type:        "consume",
+		Quantity:    -1,
+		Description: "Publication d'annonce",
+	}).Error
+}
+
+// RevokePackCreditsTx annule les crédits restants d'un pack remboursé
+func (s *QuotaService) RevokePackCreditsTx(tx *gorm.DB, paymentID uuid.UUID) error {
+	var credit models.ListingCredit
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("payment_id = ?", paymentID).First(&credit).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	return s.zeroCreditLotTx(tx, &credit, "revoke", "Pack remboursé")
+}
+
+// ExpireListingCredits solde les lots expirés d'un utilisateur dans le journal
+func (s *QuotaService) ExpireListingCredits(userID uuid.UUID) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var credits []models.ListingCredit
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND credits_remaining > 0 AND expires_at <= ?", userID, time.Now()).
+			Find(&credits).Error; err != nil {
+			return err
+		}
+
+		for i := range credits {
+			if err := s.zeroCreditLotTx(tx, &credits[i], "expire", "Crédits expirés"); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// zeroCreditLotTx vide un lot et journalise le mouvement
+func (s *QuotaService) zeroCreditLotTx(tx *gorm.DB, credit *models.ListingCredit, kind, description string) error {
+	if credit.CreditsRemaining == 0 {
+		return nil
+	}
+
+	if err := tx.Create(&models.ListingCreditTransaction{
+		UserID:      credit.UserID,
+		CreditID:    credit.ID,
+		Type:        kind,
+		Quantity:    -credit.CreditsRemaining,
+		Description: description,
+	}).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(credit).Updates(map[string]interface{}{
+		"credits_remaining": 0,
+		"updated_at":        time.Now(),
+	}).Error
+}
+
+// GetListingCredits retourne le solde, les lots actifs et l'historique des crédits
+func (s *QuotaService) GetListingCredits(userID uuid.UUID) (*ListingCreditsSummary, error) {
+	if err := s.ExpireListingCredits(userID); err != nil {
+		log.Printf("⚠️ Erreur expiration crédits utilisateur %s: %v", userID, err)
+	}
+
+	summary := &ListingCreditsSummary{
+		Lots:    []models.ListingCredit{},
+		History: []models.ListingCreditTransaction{},
+	}
+
+	if err := s.db.Where("user_id = ? AND credits_remaining > 0 AND expires_at > ?", userID, time.Now()).
+		Order("expires_at ASC").
+		Find(&summary.Lots).Error; err != nil {
+		return nil, fmt.Errorf("erreur récupération crédits: %w", err)
+	}
+
+	for i, lot := range summary.Lots {
+		summary.Available += lot.CreditsRemaining
+		if i == 0 {
+			expiry := lot.ExpiresAt
+			summary.NextExpiry = &expiry
+		}
+	}
+
+	if err := s.db.Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(listingCreditHistoryLimit).
+		Find(&summary.History).Error; err != nil {
+		return nil, fmt.Errorf("erreur récupération historique crédits: %w", err)
+	}
+
+	return summary, nil
+}
@@ -0,0 +1,76 @@
+// internal/services/quota_credits_test.go
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"senmarket/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func TestPricingConfigListingPacks(t *testing.T) {
+	config := models.GetDefaultPricingConfig()
+
+	pack, ok := config.GetListingPack(models.PaymentPurposePack10)
+	if !ok {
+		t.Fatal("pack_10 introuvable")
+	}
+	if pack.Credits != 10 || pack.Price != config.Pack10ListingsPrice || pack.ValidityDays != models.ListingPackValidityDays {
+		t.Fatalf("pack inattendu: %+v", pack)
+	}
+
+	if _, ok := config.GetListingPack("pack_50"); ok {
+		t.Fatal("pack inconnu accepté")
+	}
+}
+
+func TestResolvePaymentPurpose(t *testing.T) {
+	service := NewPaymentService(nil, NewPaymentProviderRegistry(), nil, nil, nil, nil)
+
+	purpose, amount, err := service.resolvePaymentPurpose(&CreatePaymentRequest{Amount: 200})
+	if err != nil || purpose != models.PaymentPurposeListing || amount != 200 {
+		t.Fatalf("paiement d'annonce: %q %.0f %v", purpose, amount, err)
+	}
+
+	// Le montant envoyé par le client est ignoré
+	_, amount, err = service.resolvePaymentPurpose(&CreatePaymentRequest{Amount: 50000, Purpose: models.PaymentPurposeListing})
+	if err != nil || amount != DefaultStandardListingPrice {
+		t.Fatalf("prix d'annonce attendu %.0f, obtenu %.0f %v", DefaultStandardListingPrice, amount, err)
+	}
+
+	// Sans configuration de prix, un pack ne peut pas être vendu
+	if _, _, err := service.resolvePaymentPurpose(&CreatePaymentRequest{Amount: 200, Purpose: models.PaymentPurposePack5}); !errors.Is(err, ErrUnknownPack) {
+		t.Fatalf("erreur attendue ErrUnknownPack, obtenu %v", err)
+	}
+
+	if !isListingPackPurpose(models.PaymentPurposePack10) || isListingPackPurpose(models.PaymentPurposeListing) {
+		t.Fatal("détection des packs incorrecte")
+	}
+}
+
+func TestConsumeFreeListingTxLocksMonthlyQuota(t *testing.T) {
+	db, queries := dryRunDB(t)
+	service := NewQuotaService(db)
+
+	// En dry-run aucune ligne n'est lue : seules les requêtes construites sont vérifiées
+	_ = service.ConsumeFreeListingTx(db, uuid.New(), uuid.New())
+
+	var quotaQuery string
+	for _, query := range queries() {
+		if strings.Contains(query, `FROM "listing_quotas"`) {
+			quotaQuery = query
+		}
+	}
+	if quotaQuery == "" {
+		t.Fatalf("quota non relu: %v", queries())
+	}
+	if !strings.Contains(quotaQuery, "FOR UPDATE") {
+		t.Fatalf("le quota doit être verrouillé avant consommation: %s", quotaQuery)
+	}
+	if strings.Contains(quotaQuery, `"listing_quotas"."id" =`) {
+		t.Fatalf("le quota doit être relu par utilisateur et période: %s", quotaQuery)
+	}
+}
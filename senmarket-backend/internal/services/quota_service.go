@@ -10,6 +10,7 @@ import (
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var (
@@ -69,44 +70,85 @@ func (s *QuotaService) CanCreateFreeListing(userID uuid.UUID) (bool, *models.Lis
 		return false, nil, err
 	}
 	
-	return quota.CanCreateFreeListing(), quota, nil
+	if quota.CanCreateFreeListing() {
+		return true, quota, nil
+	}
+	
+	// Quota mensuel épuisé : les crédits de packs prépayés passent avant le paiement à l'unité
+	credits, err := s.GetAvailableCredits(userID)
+	if err != nil {
+		return false, nil, err
+	}
+	
+	return credits > 0, quota, nil
 }
 
-// ConsumeFreeListing consomme une annonce gratuite
-func (s *QuotaService) ConsumeFreeListing(userID uuid.UUID) error {
-	// Vérifier la phase globale
-	isLaunchActive, err := s.IsLaunchPhaseActive()
+// ConsumeFreeListing consomme une annonce gratuite, ou à défaut un crédit de pack
+func (s *QuotaService) ConsumeFreeListing(userID, listingID uuid.UUID) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return s.ConsumeFreeListingTx(tx, userID, listingID)
+	})
+}
+
+// ConsumeFreeListingTx consomme une annonce gratuite, ou à défaut un crédit de pack,
+// dans la transaction fournie. Le quota du mois est verrouillé : deux créations
+// simultanées ne peuvent pas utiliser la même annonce gratuite.
+// Retourne ErrNoFreeListingsLeft si rien ne peut être consommé.
+func (s *QuotaService) ConsumeFreeListingTx(tx *gorm.DB, userID, listingID uuid.UUID) error {
+	// Phase 1: Pas de consommation nécessaire (illimité)
+	config, err := models.GetOrCreateGlobalConfig(tx)
 	if err != nil {
 		return err
 	}
-	
-	// Phase 1: Pas de consommation nécessaire (illimité)
-	if isLaunchActive {
+	if config.IsFreeLaunchActive() {
 		return nil
 	}
-	
-	// Phase 2+: Consommer le quota mensuel
-	quota, err := s.GetOrCreateMonthlyQuota(userID)
-	if err != nil {
-		return err
+
+	// Phase 2+: Consommer le quota mensuel (création idempotente puis verrouillage)
+	now := time.Now()
+	quota := models.ListingQuota{
+		UserID:            userID,
+		Month:             int(now.Month()),
+		Year:              now.Year(),
+		FreeListingsLimit: 3,
 	}
-	
-	if !quota.CanCreateFreeListing() {
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&quota).Error; err != nil {
+		return fmt.Errorf("erreur création quota: %w", err)
+	}
+
+	var locked models.ListingQuota
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND month = ? AND year = ?", quota.UserID, quota.Month, quota.Year).
+		First(&locked).Error; err != nil {
+		return fmt.Errorf("erreur verrouillage quota: %w", err)
+	}
+
+	if locked.CanCreateFreeListing() {
+		return locked.ConsumeFreeListing(tx)
+	}
+
+	err = s.ConsumeListingCreditTx(tx, userID, listingID)
+	if errors.Is(err, ErrNoListingCredits) {
 		return ErrNoFreeListingsLeft
 	}
-	
-	return quota.ConsumeFreeListing(s.db)
+	return err
 }
 
 // AddPaidListing ajoute une annonce payée au compteur
 func (s *QuotaService) AddPaidListing(userID uuid.UUID) error {
+	return s.AddPaidListingTx(s.db, userID)
+}
+
+// AddPaidListingTx compte une annonce payée dans la transaction fournie
+func (s *QuotaService) AddPaidListingTx(tx *gorm.DB, userID uuid.UUID) error {
 	// Toujours compter les annonces payées, même en phase de lancement
-	quota, err := s.GetOrCreateMonthlyQuota(userID)
+	now := time.Now()
+	quota, err := models.GetOrCreateQuotaForPeriod(tx, userID, int(now.Month()), now.Year())
 	if err != nil {
 		return err
 	}
 	
-	return quota.AddPaidListing(s.db)
+	return quota.AddPaidListing(tx)
 }
 
 // GetUserQuotaStatus retourne le statut détaillé du quota utilisateur
@@ -143,13 +185,19 @@ func (s *QuotaService) GetUserQuotaStatus(userID uuid.UUID) (map[string]interfac
 	
 	remaining := quota.RemainingFreeListings()
 	
+	credits, err := s.GetAvailableCredits(userID)
+	if err != nil {
+		return nil, err
+	}
+	
 	status["unlimited_free"] = false
 	status["monthly_limit"] = quota.FreeListingsLimit
 	status["used_this_month"] = quota.FreeListingsUsed
 	status["remaining_free"] = remaining
 	status["paid_this_month"] = quota.PaidListings
 	status["total_this_month"] = quota.FreeListingsUsed + quota.PaidListings
-	status["can_create_free"] = quota.CanCreateFreeListing()
+	status["listing_credits"] = credits
+	status["can_create_free"] = quota.CanCreateFreeListing() || credits > 0
 	status["quota_progress"] = quota.GetProgress()
 	status["reset_date"] = quota.NextResetDate()
 	status["days_until_reset"] = quota.DaysUntilReset()
@@ -207,6 +255,12 @@ func (s *QuotaService) CheckListingEligibility(userID uuid.UUID) (map[string]int
 		result["days_until_reset"] = quota.DaysUntilReset()
 		result["used_this_month"] = quota.FreeListingsUsed
 		result["limit_this_month"] = quota.FreeListingsLimit
+		result["listing_packs"] = config.GetListingPacks()
+	}
+	
+	// Publication couverte par un crédit de pack
+	if canCreateFree && quota != nil && !quota.CanCreateFreeListing() {
+		result["uses_listing_credit"] = true
 	}
 	
 	// Informations sur les options premium (pour phase 3)
@@ -0,0 +1,598 @@
+// internal/services/saved_search_service.go
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"senmarket/internal/domain/valueobjects"
+	"senmarket/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrSavedSearchNotFound = errors.New("recherche sauvegardée non trouvée")
+	ErrSavedSearchLimit    = errors.New("nombre maximum de recherches sauvegardées atteint")
+	ErrSavedSearchEmpty    = errors.New("au moins un critère est requis (catégorie, région, prix ou mots-clés)")
+	ErrInvalidPriceRange   = errors.New("le prix minimum dépasse le prix maximum")
+	ErrInvalidRegion       = errors.New("région invalide")
+)
+
+const (
+	savedSearchMaxPerUser    = 20
+	savedSearchBatchSize     = 500
+	savedSearchMatchesPerRun = 10
+	savedSearchAlertPreview  = 3
+	savedSearchBaseURL       = "https://senmarket.sn"
+)
+
+// SavedSearchRequest création ou modification d'une recherche sauvegardée
+type SavedSearchRequest struct {
+	Name       string   `json:"name" validate:"required,min=2,max=100"`
+	CategoryID string   `json:"category_id" validate:"omitempty,uuid"`
+	Region     string   `json:"region" validate:"max=100"`
+	MinPrice   *float64 `json:"min_price" validate:"omitempty,min=0"`
+	MaxPrice   *float64 `json:"max_price" validate:"omitempty,min=0"`
+	Keywords   string   `json:"keywords" validate:"max=200"`
+	Channel    string   `json:"channel" validate:"omitempty,oneof=sms whatsapp"`
+	Frequency  string   `json:"frequency" validate:"omitempty,oneof=instant daily"`
+	IsActive   *bool    `json:"is_active"`
+}
+
+// SavedSearchAlertReport bilan d'une passe d'alertes
+type SavedSearchAlertReport struct {
+	Checked  int `json:"checked"`
+	Matched  int `json:"matched"`
+	Notified int `json:"notified"`
+	Errors   int `json:"errors"`
+}
+
+type SavedSearchService struct {
+	db              *gorm.DB
+	listingService  *ListingService
+	smsService      *TwilioSMSService
+	whatsappService *WhatsAppService
+}
+
+func NewSavedSearchService(db *gorm.DB, listingService *ListingService, smsService *TwilioSMSService, whatsappService *WhatsAppService) *SavedSearchService {
+	return &SavedSearchService{
+		db:              db,
+		listingService:  listingService,
+		smsService:      smsService,
+		whatsappService: whatsappService,
+	}
+}
+
+// CreateSavedSearch enregistre un jeu de filtres pour l'utilisateur
+func (s *SavedSearchService) CreateSavedSearch(userID uuid.UUID, req *SavedSearchRequest) (*models.SavedSearch, error) {
+	var count int64
+	s.db.Model(&models.SavedSearch{}).Where("user_id = ?", userID).Count(&count)
+	if count >= savedSearchMaxPerUser {
+		return nil, ErrSavedSearchLimit
+	}
+
+	token, err := generateUnsubscribeToken()
+	if err != nil {
+		return nil, err
+	}
+
+	search := &models.SavedSearch{
+		UserID:           userID,
+		Channel:          models.AlertChannelSMS,
+		Frequency:        models.AlertFrequencyInstant,
+		IsActive:         true,
+		UnsubscribeToken: token,
+		LastCheckedAt:    time.Now(),
+	}
+	if err := s.applyRequest(search, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Create(search).Error; err != nil {
+		return nil, fmt.Errorf("erreur création recherche sauvegardée: %w", err)
+	}
+
+	return search, nil
+}
+
+// GetSavedSearches liste les recherches sauvegardées de l'utilisateur
+func (s *SavedSearchService) GetSavedSearches(userID uuid.UUID) ([]models.SavedSearch, error) {
+	var searches []models.SavedSearch
+	if err := s.db.Preload("Category").
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&searches).Error; err != nil {
+		return nil, fmt.Errorf("erreur récupération recherches sauvegardées: %w", err)
+	}
+	return searches, nil
+}
+
+// GetSavedSearch récupère une recherche sauvegardée de l'utilisateur
+func (s *SavedSearchService) GetSavedSearch(userID, searchID uuid.UUID) (*models.SavedSearch, error) {
+	var search models.SavedSearch
+	if err := s.db.Preload("Category").
+		Where("id = ? AND user_id = ?", searchID, userID).
+		First(&search).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSavedSearchNotFound
+		}
+		return nil, fmt.Errorf("erreur récupération recherche sauvegardée: %w", err)
+	}
+	return &search, nil
+}
+
+// UpdateSavedSearch remplace les critères et préférences d'alerte
+func (s *SavedSearchService) UpdateSavedSearch(userID, searchID uuid.UUID, req *SavedSearchRequest) (*models.SavedSearch, error) {
+	search, err := s.GetSavedSearch(userID, searchID)
+	if err != nil {
+		return nil, err
+	}
+
+	wasActive := search.IsActive
+	if err := s.applyRequest(search, req); err != nil {
+		return nil, err
+	}
+
+	// Une réactivation ne rattrape pas les annonces publiées entre-temps
+	if search.IsActive && !wasActive {
+		search.LastCheckedAt = time.Now()
+	}
+
+	if err := s.db.Omit(clause.Associations).Save(search).Error; err != nil {
+		return nil, fmt.Errorf("erreur mise à jour recherche sauvegardée: %w", err)
+	}
+
+	return search, nil
+}
+
+// DeleteSavedSearch supprime une recherche sauvegardée
+func (s *SavedSearchService) DeleteSavedSearch(userID, searchID uuid.UUID) error {
+	result := s.db.Where("id = ? AND user_id = ?", searchID, userID).Delete(&models.SavedSearch{})
+	if result.Error != nil {
+		return fmt.Errorf("erreur suppression recherche sauvegardée: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrSavedSearchNotFound
+	}
+	return nil
+}
+
+// GetByUnsubscribeToken recherche liée au jeton de désabonnement (page de confirmation)
+func (s *SavedSearchService) GetByUnsubscribeToken(token string) (*models.SavedSearch, error) {
+	var search models.SavedSearch
+	if err := s.db.Where("unsubscribe_token = ?", token).First(&search).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSavedSearchNotFound
+		}
+		return nil, fmt.Errorf("erreur récupération recherche sauvegardée: %w", err)
+	}
+	return &search, nil
+}
+
+// Unsubscribe désactive l'alerte liée au jeton (ou toutes les alertes de l'utilisateur)
+func (s *SavedSearchService) Unsubscribe(token string, all bool) (int64, error) {
+	search, err := s.GetByUnsubscribeToken(token)
+	if err != nil {
+		return 0, err
+	}
+
+	query := s.db.Model(&models.SavedSearch{}).Where("id = ?", search.ID)
+	if all {
+		query = s.db.Model(&models.SavedSearch{}).Where("user_id = ?", search.UserID)
+	}
+
+	result := query.Where("is_active = ?", true).Update("is_active", false)
+	if result.Error != nil {
+		return 0, fmt.Errorf("erreur désabonnement: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// GetMatchingListings exécute la recherche sauvegardée avec les filtres de GetListings
+func (s *SavedSearchService) GetMatchingListings(userID, searchID uuid.UUID, page, limit int, cursor string) ([]models.Listing, int64, string, error) {
+	search, err := s.GetSavedSearch(userID, searchID)
+	if err != nil {
+		return nil, 0, "", err
+	}
+
+	filters := search.Filters()
+	if cursor != "" {
+		filters["cursor"] = cursor
+	}
+	return s.listingService.GetListings(page, limit, filters)
+}
+
+// applyRequest valide la requête et l'applique à la recherche
+func (s *SavedSearchService) applyRequest(search *models.SavedSearch, req *SavedSearchRequest) error {
+	req.Keywords = normalizeSearchQuery(req.Keywords)
+	req.Region = strings.TrimSpace(req.Region)
+
+	if req.CategoryID == "" && req.Region == "" && req.MinPrice == nil && req.MaxPrice == nil && req.Keywords == "" {
+		return ErrSavedSearchEmpty
+	}
+	if req.MinPrice != nil && req.MaxPrice != nil && *req.MinPrice > *req.MaxPrice {
+		return ErrInvalidPriceRange
+	}
+	if req.Region != "" && !valueobjects.Region(req.Region).IsValid() {
+		return ErrInvalidRegion
+	}
+
+	search.CategoryID = nil
+	if req.CategoryID != "" {
+		categoryID, err := uuid.Parse(req.CategoryID)
+		if err != nil {
+			return ErrCategoryNotFound
+		}
+		var count int64
+		s.db.Model(&models.Category{}).Where("id = ? AND is_active = ?", categoryID, true).Count(&count)
+		if count == 0 {
+			return ErrCategoryNotFound
+		}
+		search.CategoryID = &categoryID
+	}
+
+	search.Name = strings.TrimSpace(req.Name)
+	search.Region = req.Region
+	search.MinPrice = req.MinPrice
+	search.MaxPrice = req.MaxPrice
+	search.Keywords = req.Keywords
+	if req.Channel != "" {
+		search.Channel = req.Channel
+	}
+	if req.Frequency != "" {
+		search.Frequency = req.Frequency
+	}
+	if req.IsActive != nil {
+		search.IsActive = *req.IsActive
+	}
+	return nil
+}
+
+// ProcessAlerts rattache les nouvelles annonces actives aux recherches sauvegardées
+// et envoie immédiatement les alertes instantanées
+func (s *SavedSearchService) ProcessAlerts(now time.Time) *SavedSearchAlertReport {
+	report := &SavedSearchAlertReport{}
+
+	var searches []models.SavedSearch
+	if err := s.db.Preload("User").
+		Where("is_active = ? AND last_checked_at < ?", true, now).
+		Order("last_checked_at ASC").
+		Limit(savedSearchBatchSize).
+		Find(&searches).Error; err != nil {
+		log.Printf("❌ Alertes recherches: erreur récupération: %v", err)
+		report.Errors++
+		return report
+	}
+
+	for i := range searches {
+		search := &searches[i]
+		report.Checked++
+
+		matched, err := s.collectMatches(search, now)
+		if err != nil {
+			log.Printf("❌ Alertes recherche %s: %v", search.ID, err)
+			report.Errors++
+			continue
+		}
+		report.Matched += matched
+
+		if search.Frequency != models.AlertFrequencyInstant {
+			continue
+		}
+
+		pending, err := s.pendingMatches(search.ID)
+		if err != nil {
+			report.Errors++
+			continue
+		}
+		if len(pending) == 0 {
+			continue
+		}
+
+		if err := s.notify(search.Channel, search.User.Phone, buildInstantAlertMessage(search, pending)); err != nil {
+			log.Printf("❌ Envoi alerte recherche %s: %v", search.ID, err)
+			report.Errors++
+			continue
+		}
+		s.markNotified([]models.SavedSearch{*search}, pending, now)
+		report.Notified++
+	}
+
+	return report
+}
+
+// collectMatches enregistre les annonces publiées ou modifiées depuis le dernier passage.
+// Les annonces sont traitées de la plus ancienne à la plus récente : si le lot est plein,
+// last_checked_at n'avance que jusqu'à la dernière annonce enregistrée, la suite est reprise au passage suivant.
+func (s *SavedSearchService) collectMatches(search *models.SavedSearch, now time.Time) (int, error) {
+	// >= : les annonces de même updated_at que le curseur ne sont pas perdues (déjà enregistrées : exclues par NOT EXISTS)
+	query := s.db.Model(&models.Listing{}).
+		Where("listings.status = ? AND listings.user_id <> ?", "active", search.UserID).
+		Where("listings.updated_at >= ? AND listings.updated_at <= ?", search.LastCheckedAt, now).
+		Where("NOT EXISTS (SELECT 1 FROM saved_search_matches m WHERE m.saved_search_id = ? AND m.listing_id = listings.id)", search.ID)
+
+	var found []savedSearchCandidate
+	if err := applyListingFilters(query, search.Filters()).
+		Select("listings.id, listings.updated_at").
+		Order("listings.updated_at ASC, listings.id ASC").
+		Limit(savedSearchMatchesPerRun).
+		Scan(&found).Error; err != nil {
+		return 0, fmt.Errorf("erreur recherche correspondances: %w", err)
+	}
+
+	checkedUntil := matchesCheckpoint(found, savedSearchMatchesPerRun, now)
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for _, candidate := range found {
+			match := models.SavedSearchMatch{SavedSearchID: search.ID, ListingID: candidate.ID}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&match).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&models.SavedSearch{}).Where("id = ?", search.ID).
+			UpdateColumn("last_checked_at", checkedUntil).Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf("erreur enregistrement correspondances: %w", err)
+	}
+
+	return len(found), nil
+}
+
+// savedSearchCandidate annonce correspondant à une recherche sauvegardée
+type savedSearchCandidate struct {
+	ID        uuid.UUID
+	UpdatedAt time.Time
+}
+
+// matchesCheckpoint nouvelle valeur de last_checked_at : now si toutes les annonces de la fenêtre
+// ont été vues, sinon updated_at de la dernière annonce enregistrée
+func matchesCheckpoint(found []savedSearchCandidate, limit int, now time.Time) time.Time {
+	if len(found) < limit {
+		return now
+	}
+	return found[len(found)-1].UpdatedAt
+}
+
+// pendingMatches correspondances pas encore signalées, annonces toujours actives
+func (s *SavedSearchService) pendingMatches(searchID uuid.UUID) ([]models.SavedSearchMatch, error) {
+	var matches []models.SavedSearchMatch
+	err := s.db.Joins("Listing").
+		Where("saved_search_matches.saved_search_id = ? AND saved_search_matches.notified_at IS NULL", searchID).
+		Where(`"Listing".status = ?`, "active").
+		Order("saved_search_matches.created_at DESC").
+		Find(&matches).Error
+	return matches, err
+}
+
+// SendDailyDigests envoie un résumé par utilisateur et par canal des correspondances du jour
+func (s *SavedSearchService) SendDailyDigests(now time.Time) *SavedSearchAlertReport {
+	report := &SavedSearchAlertReport{}
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	var searches []models.SavedSearch
+	if err := s.db.Preload("User").
+		Where("is_active = ? AND frequency = ?", true, models.AlertFrequencyDaily).
+		Where("last_notified_at IS NULL OR last_notified_at < ?", startOfDay).
+		Where("EXISTS (SELECT 1 FROM saved_search_matches m WHERE m.saved_search_id = saved_searches.id AND m.notified_at IS NULL)").
+		Order("user_id, channel, created_at").
+		Find(&searches).Error; err != nil {
+		log.Printf("❌ Résumés recherches: erreur récupération: %v", err)
+		report.Errors++
+		return report
+	}
+
+	type digestKey struct {
+		userID  uuid.UUID
+		channel string
+	}
+	var order []digestKey
+	groups := map[digestKey][]savedSearchDigest{}
+	searchesByKey := map[digestKey][]models.SavedSearch{}
+	phones := map[digestKey]string{}
+
+	for _, search := range searches {
+		report.Checked++
+		pending, err := s.pendingMatches(search.ID)
+		if err != nil {
+			report.Errors++
+			continue
+		}
+		if len(pending) == 0 {
+			continue
+		}
+
+		key := digestKey{userID: search.UserID, channel: search.Channel}
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+			phones[key] = search.User.Phone
+		}
+		groups[key] = append(groups[key], savedSearchDigest{Search: search, Matches: pending})
+		searchesByKey[key] = append(searchesByKey[key], search)
+		report.Matched += len(pending)
+	}
+
+	for _, key := range order {
+		if err := s.notify(key.channel, phones[key], buildDigestMessage(groups[key])); err != nil {
+			log.Printf("❌ Envoi résumé utilisateur %s: %v", key.userID, err)
+			report.Errors++
+			continue
+		}
+
+		var matches []models.SavedSearchMatch
+		for _, digest := range groups[key] {
+			matches = append(matches, digest.Matches...)
+		}
+		s.markNotified(searchesByKey[key], matches, now)
+		report.Notified++
+	}
+
+	return report
+}
+
+// markNotified marque les correspondances comme signalées
+func (s *SavedSearchService) markNotified(searches []models.SavedSearch, matches []models.SavedSearchMatch, now time.Time) {
+	matchIDs := make([]uuid.UUID, len(matches))
+	for i, match := range matches {
+		matchIDs[i] = match.ID
+	}
+	searchIDs := make([]uuid.UUID, len(searches))
+	for i, search := range searches {
+		searchIDs[i] = search.ID
+	}
+
+	if err := s.db.Model(&models.SavedSearchMatch{}).Where("id IN ?", matchIDs).
+		UpdateColumn("notified_at", now).Error; err != nil {
+		log.Printf("⚠️ Erreur marquage correspondances: %v", err)
+	}
+	if err := s.db.Model(&models.SavedSearch{}).Where("id IN ?", searchIDs).
+		UpdateColumn("last_notified_at", now).Error; err != nil {
+		log.Printf("⚠️ Erreur marquage recherches: %v", err)
+	}
+}
+
+// notify envoie le message sur le canal choisi (repli SMS si WhatsApp échoue)
+func (s *SavedSearchService) notify(channel, phone, message string) error {
+	if channel == models.AlertChannelWhatsApp && s.whatsappService != nil {
+		err := s.whatsappService.SendMessage(phone, message)
+		if err == nil {
+			return nil
+		}
+		log.Printf("⚠️ Alerte WhatsApp vers %s échouée, repli SMS: %v", phone, err)
+	}
+	return s.smsService.SendSMS(phone, message)
+}
+
+// savedSearchDigest correspondances d'une recherche pour le résumé quotidien
+type savedSearchDigest struct {
+	Search  models.SavedSearch
+	Matches []models.SavedSearchMatch
+}
+
+// buildInstantAlertMessage message d'alerte pour une recherche
+func buildInstantAlertMessage(search *models.SavedSearch, matches []models.SavedSearchMatch) string {
+	var b strings.Builder
+
+	if len(matches) == 1 {
+		listing := matches[0].Listing
+		fmt.Fprintf(&b, "SenMarket 🔔 Nouvelle annonce pour « %s » :\n%s\n%s\n",
+			search.Name, formatAlertListing(&listing), listingURL(&listing))
+	} else {
+		fmt.Fprintf(&b, "SenMarket 🔔 %d nouvelles annonces pour « %s » :\n", len(matches), search.Name)
+		writeAlertPreview(&b, matches)
+	}
+
+	fmt.Fprintf(&b, "Se désabonner : %s", unsubscribeURL(search.UnsubscribeToken))
+	return b.String()
+}
+
+// buildDigestMessage résumé quotidien de plusieurs recherches
+func buildDigestMessage(digests []savedSearchDigest) string {
+	var b strings.Builder
+
+	total := 0
+	for _, digest := range digests {
+		total += len(digest.Matches)
+	}
+	fmt.Fprintf(&b, "SenMarket 📬 Résumé du jour : %d nouvelle(s) annonce(s)\n", total)
+
+	for _, digest := range digests {
+		fmt.Fprintf(&b, "\n« %s » (%d) :\n", digest.Search.Name, len(digest.Matches))
+		writeAlertPreview(&b, digest.Matches)
+	}
+
+	if len(digests) > 0 {
+		fmt.Fprintf(&b, "\nSe désabonner de tout : %s?all=true", unsubscribeURL(digests[0].Search.UnsubscribeToken))
+	}
+	return b.String()
+}
+
+// writeAlertPreview liste les premières annonces, puis le nombre restant
+func writeAlertPreview(b *strings.Builder, matches []models.SavedSearchMatch) {
+	for i, match := range matches {
+		if i == savedSearchAlertPreview {
+			fmt.Fprintf(b, "… et %d autre(s) sur senmarket.sn\n", len(matches)-savedSearchAlertPreview)
+			break
+		}
+		fmt.Fprintf(b, "• %s %s\n", formatAlertListing(&match.Listing), listingURL(&match.Listing))
+	}
+}
+
+func formatAlertListing(listing *models.Listing) string {
+	return fmt.Sprintf("%s - %.0f %s (%s)", listing.Title, listing.Price, listingCurrency(listing), listing.Region)
+}
+
+func listingCurrency(listing *models.Listing) string {
+	if listing.Currency == "" || listing.Currency == "XOF" {
+		return "FCFA"
+	}
+	return listing.Currency
+}
+
+func listingURL(listing *models.Listing) string {
+	return savedSearchBaseURL + "/listings/" + listing.ID.String()
+}
+
+func unsubscribeURL(token string) string {
+	return savedSearchBaseURL + "/api/v1/saved-searches/unsubscribe/" + token
+}
+
+// generateUnsubscribeToken jeton aléatoire de désabonnement
+func generateUnsubscribeToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("erreur génération jeton: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// SavedSearchAlerter job périodique des alertes et résumés quotidiens
+type SavedSearchAlerter struct {
+	savedSearchService *SavedSearchService
+	interval           time.Duration
+	digestHour         int
+}
+
+func NewSavedSearchAlerter(savedSearchService *SavedSearchService, interval time.Duration, digestHour int) *SavedSearchAlerter {
+	return &SavedSearchAlerter{
+		savedSearchService: savedSearchService,
+		interval:           interval,
+		digestHour:         digestHour,
+	}
+}
+
+// Start lance la boucle périodique jusqu'à l'annulation du contexte
+func (a *SavedSearchAlerter) Start(ctx context.Context) {
+	log.Printf("🔔 Alertes recherches sauvegardées actives (toutes les %s, résumé à %dh)", a.interval, a.digestHour)
+
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			report := a.savedSearchService.ProcessAlerts(now)
+			if report.Matched > 0 || report.Errors > 0 {
+				log.Printf("🔔 Alertes: %d recherches, %d correspondances, %d envois, %d erreurs",
+					report.Checked, report.Matched, report.Notified, report.Errors)
+			}
+
+			if now.Hour() >= a.digestHour {
+				digests := a.savedSearchService.SendDailyDigests(now)
+				if digests.Notified > 0 || digests.Errors > 0 {
+					log.Printf("📬 Résumés quotidiens: %d envoyés, %d erreurs", digests.Notified, digests.Errors)
+				}
+			}
+		}
+	}
+}
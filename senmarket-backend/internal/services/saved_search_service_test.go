@@ -0,0 +1,127 @@
+// internal/services/saved_search_service_test.go
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"senmarket/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func TestSavedSearchFilters(t *testing.T) {
+	categoryID := uuid.New()
+	minPrice, maxPrice := 50000.0, 250000.0
+	search := models.SavedSearch{
+		CategoryID: &categoryID,
+		Region:     "Thiès",
+		MinPrice:   &minPrice,
+		MaxPrice:   &maxPrice,
+		Keywords:   "iphone 13",
+	}
+
+	filters := search.Filters()
+	if filters["category_id"] != categoryID.String() || filters["region"] != "Thiès" {
+		t.Errorf("filtres catégorie/région inattendus: %v", filters)
+	}
+	if filters["min_price"] != minPrice || filters["max_price"] != maxPrice {
+		t.Errorf("filtres prix inattendus: %v", filters)
+	}
+	if filters["search"] != "iphone 13" {
+		t.Errorf("mots-clés inattendus: %v", filters)
+	}
+
+	if got := (&models.SavedSearch{Region: "Dakar"}).Filters(); len(got) != 1 {
+		t.Errorf("seuls les critères renseignés doivent être filtrés: %v", got)
+	}
+}
+
+func TestSavedSearchRequestValidation(t *testing.T) {
+	s := &SavedSearchService{}
+	low, high := 1000.0, 500.0
+
+	tests := map[string]struct {
+		req  SavedSearchRequest
+		want error
+	}{
+		"sans critère":        {SavedSearchRequest{Name: "Tout", Keywords: "   "}, ErrSavedSearchEmpty},
+		"prix inversés":       {SavedSearchRequest{Name: "Prix", MinPrice: &low, MaxPrice: &high}, ErrInvalidPriceRange},
+		"région inconnue":     {SavedSearchRequest{Name: "Région", Region: "Paris"}, ErrInvalidRegion},
+		"mots-clés seuls":     {SavedSearchRequest{Name: "Vélo", Keywords: " vélo  enfant "}, nil},
+		"région et fréquence": {SavedSearchRequest{Name: "Thiès", Region: "Thiès", Frequency: "daily"}, nil},
+	}
+
+	for name, tt := range tests {
+		search := &models.SavedSearch{Channel: models.AlertChannelSMS, Frequency: models.AlertFrequencyInstant}
+		err := s.applyRequest(search, &tt.req)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: erreur = %v, attendu %v", name, err, tt.want)
+		}
+		if err == nil && search.Channel != models.AlertChannelSMS {
+			t.Errorf("%s: canal par défaut perdu", name)
+		}
+	}
+
+	search := &models.SavedSearch{}
+	req := SavedSearchRequest{Name: "Vélo", Keywords: " vélo  enfant "}
+	if err := s.applyRequest(search, &req); err != nil || search.Keywords != "vélo enfant" {
+		t.Errorf("mots-clés non normalisés: %q (%v)", search.Keywords, err)
+	}
+}
+
+func TestBuildAlertMessages(t *testing.T) {
+	search := &models.SavedSearch{Name: "Villas Saly", UnsubscribeToken: "tok123"}
+	matches := []models.SavedSearchMatch{
+		{Listing: models.Listing{ID: uuid.New(), Title: "Villa F4 piscine", Price: 85000000, Region: "Thiès"}},
+	}
+
+	single := buildInstantAlertMessage(search, matches)
+	for _, want := range []string{"Villas Saly", "Villa F4 piscine", "85000000 FCFA", "/listings/" + matches[0].Listing.ID.String(), "/unsubscribe/tok123"} {
+		if !strings.Contains(single, want) {
+			t.Errorf("alerte sans %q:\n%s", want, single)
+		}
+	}
+
+	for i := 0; i < 4; i++ {
+		matches = append(matches, models.SavedSearchMatch{Listing: models.Listing{ID: uuid.New(), Title: "Autre villa", Price: 1000, Region: "Thiès"}})
+	}
+	multiple := buildInstantAlertMessage(search, matches)
+	if !strings.Contains(multiple, "5 nouvelles annonces") || !strings.Contains(multiple, "et 2 autre(s)") {
+		t.Errorf("alerte groupée inattendue:\n%s", multiple)
+	}
+
+	digest := buildDigestMessage([]savedSearchDigest{
+		{Search: *search, Matches: matches[:2]},
+		{Search: models.SavedSearch{Name: "Motos Dakar", UnsubscribeToken: "tok456"}, Matches: matches[2:3]},
+	})
+	for _, want := range []string{"3 nouvelle(s) annonce(s)", "« Villas Saly » (2)", "« Motos Dakar » (1)", "tok123?all=true"} {
+		if !strings.Contains(digest, want) {
+			t.Errorf("résumé sans %q:\n%s", want, digest)
+		}
+	}
+}
+
+func TestMatchesCheckpoint(t *testing.T) {
+	now := time.Now()
+	oldest := now.Add(-2 * time.Hour)
+	newest := now.Add(-time.Hour)
+	found := []savedSearchCandidate{
+		{ID: uuid.New(), UpdatedAt: oldest},
+		{ID: uuid.New(), UpdatedAt: newest},
+	}
+
+	// Lot incomplet : toute la fenêtre a été vue
+	if got := matchesCheckpoint(found, 3, now); !got.Equal(now) {
+		t.Errorf("lot incomplet: last_checked_at = %v, attendu %v", got, now)
+	}
+	// Lot plein : la suite de la fenêtre doit être reprise au passage suivant
+	if got := matchesCheckpoint(found, 2, now); !got.Equal(newest) {
+		t.Errorf("lot plein: last_checked_at = %v, attendu %v", got, newest)
+	}
+	if got := matchesCheckpoint(nil, 2, now); !got.Equal(now) {
+		t.Errorf("aucune annonce: last_checked_at = %v, attendu %v", got, now)
+	}
+}
@@ -0,0 +1,112 @@
+// internal/services/testdb_test.go
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testTx transaction sur une base PostgreSQL migrée (TEST_DATABASE_URL), annulée en fin de test.
+// Sans TEST_DATABASE_URL le test est ignoré.
+func testTx(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL non défini : test sur base ignoré")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("connexion base de test: %v", err)
+	}
+
+	tx := db.Begin()
+	if tx.Error != nil {
+		t.Fatalf("ouverture transaction: %v", tx.Error)
+	}
+	t.Cleanup(func() {
+		tx.Rollback()
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return tx
+}
+
+// dryRunDB base PostgreSQL sans connexion : les requêtes sont construites mais jamais exécutées.
+// Retourne aussi une fonction donnant le SQL des requêtes SELECT et UPDATE construites.
+func dryRunDB(t *testing.T) (*gorm.DB, func() []string) {
+	t.Helper()
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: dryRunConnPool{}}), &gorm.Config{
+		DryRun:                 true,
+		SkipDefaultTransaction: true,
+		DisableAutomaticPing:   true,
+		Logger:                 logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("base dry-run: %v", err)
+	}
+
+	var (
+		mu      sync.Mutex
+		queries []string
+	)
+	capture := func(tx *gorm.DB) {
+		mu.Lock()
+		defer mu.Unlock()
+		if sql := tx.Statement.SQL.String(); sql != "" {
+			queries = append(queries, sql)
+		}
+	}
+	if err := db.Callback().Query().After("gorm:query").Register("test:capture", capture); err != nil {
+		t.Fatalf("enregistrement callback: %v", err)
+	}
+	if err := db.Callback().Update().After("gorm:update").Register("test:capture", capture); err != nil {
+		t.Fatalf("enregistrement callback: %v", err)
+	}
+
+	return db, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), queries...)
+	}
+}
+
+var errDryRun = errors.New("base dry-run : aucune requête exécutée")
+
+// dryRunConnPool connexion factice : les transactions s'ouvrent sans serveur,
+// aucune requête n'est jamais envoyée en dry-run
+type dryRunConnPool struct{}
+
+func (dryRunConnPool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return nil, errDryRun
+}
+
+func (dryRunConnPool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return nil, errDryRun
+}
+
+func (dryRunConnPool) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return nil, errDryRun
+}
+
+func (dryRunConnPool) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return nil
+}
+
+func (p dryRunConnPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	return p, nil
+}
+
+func (dryRunConnPool) Commit() error   { return nil }
+func (dryRunConnPool) Rollback() error { return nil }
@@ -11,16 +11,18 @@ type ListingResponse struct {
 
 // PaginationInfo informations de pagination
 type PaginationInfo struct {
-	Page       int   `json:"page"`
-	Limit      int   `json:"limit"`
-	Total      int64 `json:"total"`
-	TotalPages int   `json:"total_pages"`
+	Page       int    `json:"page"`
+	Limit      int    `json:"limit"`
+	Total      int64  `json:"total"`
+	TotalPages int    `json:"total_pages"`
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 // CategoryWithStats catégorie avec statistiques
 type CategoryWithStats struct {
 	models.Category
-	ListingCount int64 `json:"listing_count"`
+	ListingCount       int64 `json:"listing_count"`                 // Sous-catégories incluses
+	DirectListingCount int64 `json:"direct_listing_count" gorm:"-"` // Annonces de la catégorie elle-même
 }
 
 // CreateListingRequest structure pour créer une annonce
@@ -30,9 +32,14 @@ type CreateListingRequest struct {
 	Price       float64  `json:"price" validate:"required,min=0"`
 	CategoryID  string   `json:"category_id" validate:"required,uuid"`
 	Region      string   `json:"region" validate:"required"`
+	Commune     string   `json:"commune" validate:"max=100"`
+	Latitude    *float64 `json:"latitude" validate:"omitempty,min=-90,max=90"`
+	Longitude   *float64 `json:"longitude" validate:"omitempty,min=-180,max=180"`
 	Images      []string `json:"images" validate:"max=5"`
 	Phone       string   `json:"phone" validate:"required"`
-	Featured    bool     `json:"featured"`
+
+	// Attributs selon le schéma de la catégorie (ex: {"brand": "Toyota", "year": 2016})
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
 }
 
 // UpdateListingRequest structure pour mettre à jour une annonce
@@ -41,9 +48,15 @@ type UpdateListingRequest struct {
 	Description *string  `json:"description,omitempty"`
 	Price       *float64 `json:"price,omitempty"`
 	Region      *string  `json:"region,omitempty"`
+	Commune     *string  `json:"commune,omitempty"`
+	Latitude    *float64 `json:"latitude,omitempty"`
+	Longitude   *float64 `json:"longitude,omitempty"`
 	Images      []string `json:"images,omitempty"`
 	Phone       *string  `json:"phone,omitempty"`
 	Status      *string  `json:"status,omitempty"`
+
+	// Remplace l'ensemble des attributs (revalidés contre le schéma courant)
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
 }
 
 // Ajouter ces champs dans ListingQuery
@@ -56,6 +69,10 @@ type ListingQuery struct {
 	Sort       string  `form:"sort" json:"sort"`
 	Page       int     `form:"page,default=1" json:"page"`
 	Limit      int     `form:"limit,default=20" json:"limit"`
+	Cursor     string  `form:"cursor" json:"cursor"`
 	UserID     string  `form:"user_id" json:"user_id"`        // NOUVEAU
 	Status     string  `form:"status" json:"status"`          // NOUVEAU
+
+	// Filtres attr.* (clé=valeur, clé_min, clé_max)
+	Attributes map[string]string `form:"-" json:"attributes,omitempty"`
 }
\ No newline at end of file
@@ -0,0 +1,362 @@
+// internal/services/wallet_service.go
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"senmarket/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInsufficientBalance         = errors.New("solde du portefeuille insuffisant")
+	ErrUnbalancedWalletTransaction = errors.New("transaction de portefeuille déséquilibrée")
+	ErrInvalidTopUpAmount          = errors.New("montant de rechargement invalide")
+)
+
+const (
+	WalletTopUpMin = 200.0
+	WalletTopUpMax = 500000.0
+)
+
+// WalletService portefeuille prépayé tenu en partie double.
+// Chaque opération crée une transaction dont les écritures s'annulent ;
+// le solde d'un compte est la somme de ses écritures.
+type WalletService struct {
+	db *gorm.DB
+}
+
+// WalletLine ligne d'historique ou de relevé vue depuis le compte de l'utilisateur
+type WalletLine struct {
+	TransactionID uuid.UUID  `json:"transaction_id"`
+	Date          time.Time  `json:"date"`
+	Type          string     `json:"type"`
+	Purpose       string     `json:"purpose,omitempty"`
+	Description   string     `json:"description"`
+	Amount        float64    `json:"amount"` // positif = crédit, négatif = débit
+	BalanceAfter  float64    `json:"balance_after"`
+	PaymentID     *uuid.UUID `json:"payment_id,omitempty"`
+}
+
+// WalletStatement relevé de compte sur une période
+type WalletStatement struct {
+	AccountCode    string       `json:"account_code"`
+	Currency       string       `json:"currency"`
+	From           time.Time    `json:"from"`
+	To             time.Time    `json:"to"`
+	OpeningBalance float64      `json:"opening_balance"`
+	ClosingBalance float64      `json:"closing_balance"`
+	TotalCredits   float64      `json:"total_credits"`
+	TotalDebits    float64      `json:"total_debits"`
+	Lines          []WalletLine `json:"lines"`
+}
+
+// walletLeg écriture à passer sur un compte
+type walletLeg struct {
+	account *models.WalletAccount
+	amount  float64
+}
+
+func NewWalletService(db *gorm.DB) *WalletService {
+	return &WalletService{db: db}
+}
+
+// walletAccountCode code du compte d'un utilisateur
+func walletAccountCode(userID uuid.UUID) string {
+	return "user:" + userID.String()
+}
+
+// lockUserAccountTx récupère (ou crée) le compte de l'utilisateur et le verrouille
+func (s *WalletService) lockUserAccountTx(tx *gorm.DB, userID uuid.UUID) (*models.WalletAccount, error) {
+	account := models.WalletAccount{
+		Code:     walletAccountCode(userID),
+		Type:     "user",
+		UserID:   &userID,
+		Currency: "XOF",
+	}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&account).Error; err != nil {
+		return nil, fmt.Errorf("erreur création compte portefeuille: %w", err)
+	}
+
+	// Relecture dans une variable vierge : si le compte existait déjà, l'ID généré
+	// par BeforeCreate ne correspond à aucune ligne et ne doit pas filtrer la requête
+	var locked models.WalletAccount
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("code = ?", walletAccountCode(userID)).
+		First(&locked).Error; err != nil {
+		return nil, fmt.Errorf("erreur récupération compte portefeuille: %w", err)
+	}
+	return &locked, nil
+}
+
+// systemAccountTx récupère un compte système de contrepartie
+func (s *WalletService) systemAccountTx(tx *gorm.DB, code string) (*models.WalletAccount, error) {
+	var account models.WalletAccount
+	if err := tx.Where("code = ? AND type = ?", code, "system").First(&account).Error; err != nil {
+		return nil, fmt.Errorf("compte système %s introuvable: %w", code, err)
+	}
+	return &account, nil
+}
+
+// postTx enregistre une transaction et ses écritures. Les comptes utilisateurs doivent
+// être verrouillés au préalable. Retourne false si la transaction existait déjà
+// (même paiement, même type) : l'opération est alors ignorée.
+func (s *WalletService) postTx(tx *gorm.DB, transaction *models.WalletTransaction, legs ...walletLeg) (bool, error) {
+	if err := checkWalletLegs(legs); err != nil {
+		return false, err
+	}
+
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(transaction)
+	if result.Error != nil {
+		return false, fmt.Errorf("erreur création transaction portefeuille: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+
+	for _, leg := range legs {
+		if err := tx.Model(leg.account).
+			Clauses(clause.Returning{Columns: []clause.Column{{Name: "balance"}}}).
+			UpdateColumns(map[string]interface{}{
+				"balance":    gorm.Expr("balance + ?", leg.amount),
+				"updated_at": time.Now(),
+			}).Error; err != nil {
+			return false, fmt.Errorf("erreur mise à jour solde: %w", err)
+		}
+
+		if err := tx.Create(&models.WalletEntry{
+			TransactionID: transaction.ID,
+			AccountID:     leg.account.ID,
+			Amount:        leg.amount,
+			BalanceAfter:  leg.account.Balance,
+		}).Error; err != nil {
+			return false, fmt.Errorf("erreur écriture portefeuille: %w", err)
+		}
+	}
+
+	return true, nil
+}
+
+// checkWalletLegs vérifie qu'une transaction est équilibrée
+func checkWalletLegs(legs []walletLeg) error {
+	if len(legs) < 2 {
+		return ErrUnbalancedWalletTransaction
+	}
+
+	var sum float64
+	for _, leg := range legs {
+		if leg.amount == 0 {
+			return ErrUnbalancedWalletTransaction
+		}
+		sum += leg.amount
+	}
+	if math.Abs(sum) > 0.001 {
+		return ErrUnbalancedWalletTransaction
+	}
+	return nil
+}
+
+// CreditTopUpTx crédite le portefeuille après confirmation d'un rechargement
+func (s *WalletService) CreditTopUpTx(tx *gorm.DB, payment *models.Payment) error {
+	account, err := s.lockUserAccountTx(tx, payment.UserID)
+	if err != nil {
+		return err
+	}
+	topups, err := s.systemAccountTx(tx, models.WalletAccountTopUps)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.postTx(tx, &models.WalletTransaction{
+		UserID:      payment.UserID,
+		Type:        "topup",
+		Purpose:     payment.Purpose,
+		Amount:      payment.Amount,
+		PaymentID:   &payment.ID,
+		Description: fmt.Sprintf("Rechargement %s", payment.PaymentMethod),
+	},
+		walletLeg{account: topups, amount: -payment.Amount},
+		walletLeg{account: account, amount: payment.Amount},
+	)
+	return err
+}
+
+// DebitTx débite le portefeuille pour un achat réglé par portefeuille
+func (s *WalletService) DebitTx(tx *gorm.DB, payment *models.Payment) error {
+	account, err := s.lockUserAccountTx(tx, payment.UserID)
+	if err != nil {
+		return err
+	}
+	if account.Balance < payment.Amount {
+		return ErrInsufficientBalance
+	}
+
+	revenue, err := s.systemAccountTx(tx, models.WalletAccountRevenue)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.postTx(tx, &models.WalletTransaction{
+		UserID:      payment.UserID,
+		Type:        "purchase",
+		Purpose:     payment.Purpose,
+		Amount:      payment.Amount,
+		PaymentID:   &payment.ID,
+		Description: walletPurposeLabel(payment.Purpose),
+	},
+		walletLeg{account: account, amount: -payment.Amount},
+		walletLeg{account: revenue, amount: payment.Amount},
+	)
+	return err
+}
+
+// RefundToWallet recrédite le portefeuille pour le remboursement d'un achat
+func (s *WalletService) RefundToWallet(payment *models.Payment, refund *models.Refund) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		account, err := s.lockUserAccountTx(tx, payment.UserID)
+		if err != nil {
+			return err
+		}
+		revenue, err := s.systemAccountTx(tx, models.WalletAccountRevenue)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.postTx(tx, &models.WalletTransaction{
+			UserID:      payment.UserID,
+			Type:        "refund",
+			Purpose:     payment.Purpose,
+			Amount:      refund.Amount,
+			RefundID:    &refund.ID,
+			Description: "Remboursement: " + refund.Reason,
+		},
+			walletLeg{account: revenue, amount: -refund.Amount},
+			walletLeg{account: account, amount: refund.Amount},
+		)
+		return err
+	})
+}
+
+// GetAccount retourne le compte de l'utilisateur (créé à la première consultation)
+func (s *WalletService) GetAccount(userID uuid.UUID) (*models.WalletAccount, error) {
+	var account *models.WalletAccount
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		account, err = s.lockUserAccountTx(tx, userID)
+		return err
+	})
+	return account, err
+}
+
+// walletLinesQuery écritures du compte de l'utilisateur jointes à leur transaction
+func (s *WalletService) walletLinesQuery(userID uuid.UUID) *gorm.DB {
+	return s.db.Table("wallet_entries e").
+		Select("t.id AS transaction_id, e.created_at AS date, t.type, t.purpose, t.description, e.amount, e.balance_after, t.payment_id").
+		Joins("JOIN wallet_transactions t ON t.id = e.transaction_id").
+		Joins("JOIN wallet_accounts a ON a.id = e.account_id").
+		Where("a.code = ?", walletAccountCode(userID))
+}
+
+// GetTransactions historique paginé du portefeuille (plus récent en premier)
+func (s *WalletService) GetTransactions(userID uuid.UUID, page, limit int) ([]WalletLine, int64, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	var total int64
+	if err := s.walletLinesQuery(userID).Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("erreur comptage transactions: %w", err)
+	}
+
+	lines := []WalletLine{}
+	if err := s.walletLinesQuery(userID).
+		Order("e.created_at DESC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Scan(&lines).Error; err != nil {
+		return nil, 0, fmt.Errorf("erreur récupération transactions: %w", err)
+	}
+
+	return lines, total, nil
+}
+
+// GetStatement relevé du portefeuille entre deux dates (bornes incluse/exclue)
+func (s *WalletService) GetStatement(userID uuid.UUID, from, to time.Time) (*WalletStatement, error) {
+	account, err := s.GetAccount(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	statement := &WalletStatement{
+		AccountCode: account.Code,
+		Currency:    account.Currency,
+		From:        from,
+		To:          to,
+		Lines:       []WalletLine{},
+	}
+
+	// Solde d'ouverture : solde après la dernière écriture antérieure à la période
+	var opening []float64
+	if err := s.db.Model(&models.WalletEntry{}).
+		Where("account_id = ? AND created_at < ?", account.ID, from).
+		Order("created_at DESC").
+		Limit(1).
+		Pluck("balance_after", &opening).Error; err != nil {
+		return nil, fmt.Errorf("erreur calcul solde d'ouverture: %w", err)
+	}
+	if len(opening) > 0 {
+		statement.OpeningBalance = opening[0]
+	}
+
+	if err := s.walletLinesQuery(userID).
+		Where("e.created_at >= ? AND e.created_at < ?", from, to).
+		Order("e.created_at ASC").
+		Scan(&statement.Lines).Error; err != nil {
+		return nil, fmt.Errorf("erreur récupération relevé: %w", err)
+	}
+
+	summarizeWalletStatement(statement)
+	return statement, nil
+}
+
+// summarizeWalletStatement calcule les totaux et le solde de clôture
+func summarizeWalletStatement(statement *WalletStatement) {
+	statement.ClosingBalance = statement.OpeningBalance
+	for _, line := range statement.Lines {
+		if line.Amount > 0 {
+			statement.TotalCredits += line.Amount
+		} else {
+			statement.TotalDebits -= line.Amount
+		}
+		statement.ClosingBalance = line.BalanceAfter
+	}
+}
+
+// walletPurposeLabel libellé d'un achat réglé par portefeuille
+func walletPurposeLabel(purpose string) string {
+	switch purpose {
+	case models.PaymentPurposeListing:
+		return "Publication d'annonce"
+	case models.PaymentPurposePack5:
+		return "Pack 5 annonces"
+	case models.PaymentPurposePack10:
+		return "Pack 10 annonces"
+	case models.PaymentPurposeBoost:
+		return "Mise en avant d'annonce"
+	case models.PaymentPurposeHighlight:
+		return "Mise en couleur d'annonce"
+	case models.PaymentPurposeRenewal:
+		return "Renouvellement d'annonce"
+	default:
+		return "Achat"
+	}
+}
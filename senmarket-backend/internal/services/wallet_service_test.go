@@ -0,0 +1,134 @@
+// internal/services/wallet_service_test.go
+package services
+
+import (
+	"errors"
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+
+	"senmarket/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func TestCheckWalletLegs(t *testing.T) {
+	user := &models.WalletAccount{Code: "user:test"}
+	revenue := &models.WalletAccount{Code: models.WalletAccountRevenue}
+
+	tests := []struct {
+		name string
+		legs []walletLeg
+		want error
+	}{
+		{"équilibrée", []walletLeg{{user, -200}, {revenue, 200}}, nil},
+		{"déséquilibrée", []walletLeg{{user, -200}, {revenue, 150}}, ErrUnbalancedWalletTransaction},
+		{"écriture unique", []walletLeg{{user, 200}}, ErrUnbalancedWalletTransaction},
+		{"écriture nulle", []walletLeg{{user, 0}, {revenue, 0}}, ErrUnbalancedWalletTransaction},
+	}
+
+	for _, tt := range tests {
+		if err := checkWalletLegs(tt.legs); !errors.Is(err, tt.want) {
+			t.Errorf("%s: erreur = %v, attendu %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestSummarizeWalletStatement(t *testing.T) {
+	statement := &WalletStatement{
+		OpeningBalance: 1000,
+		Lines: []WalletLine{
+			{Type: "topup", Amount: 5000, BalanceAfter: 6000},
+			{Type: "purchase", Amount: -200, BalanceAfter: 5800},
+			{Type: "purchase", Amount: -1500, BalanceAfter: 4300},
+			{Type: "refund", Amount: 200, BalanceAfter: 4500},
+		},
+	}
+
+	summarizeWalletStatement(statement)
+
+	if statement.TotalCredits != 5200 || statement.TotalDebits != 1700 {
+		t.Fatalf("totaux = %.0f / %.0f, attendu 5200 / 1700", statement.TotalCredits, statement.TotalDebits)
+	}
+	if statement.ClosingBalance != 4500 {
+		t.Fatalf("solde de clôture = %.0f, attendu 4500", statement.ClosingBalance)
+	}
+	if statement.OpeningBalance+statement.TotalCredits-statement.TotalDebits != statement.ClosingBalance {
+		t.Fatal("le relevé ne s'équilibre pas")
+	}
+
+	empty := &WalletStatement{OpeningBalance: 300}
+	summarizeWalletStatement(empty)
+	if empty.ClosingBalance != 300 {
+		t.Fatalf("relevé vide: clôture = %.0f, attendu 300", empty.ClosingBalance)
+	}
+}
+
+func TestResolveWalletTopUpPurpose(t *testing.T) {
+	service := NewPaymentService(nil, NewPaymentProviderRegistry(), nil, nil, nil, NewWalletService(nil))
+
+	purpose, amount, err := service.resolvePaymentPurpose(&CreatePaymentRequest{Amount: 5000, Purpose: models.PaymentPurposeWalletTopUp})
+	if err != nil || purpose != models.PaymentPurposeWalletTopUp || amount != 5000 {
+		t.Fatalf("rechargement: %q %.0f %v", purpose, amount, err)
+	}
+
+	for _, invalid := range []float64{0, 100, WalletTopUpMax + 1} {
+		if _, _, err := service.resolvePaymentPurpose(&CreatePaymentRequest{Amount: invalid, Purpose: models.PaymentPurposeWalletTopUp}); !errors.Is(err, ErrInvalidTopUpAmount) {
+			t.Errorf("montant %.0f: erreur attendue ErrInvalidTopUpAmount, obtenu %v", invalid, err)
+		}
+	}
+
+	if methods := service.SupportedMethods(); len(methods) != 1 || methods[0] != models.PaymentMethodWallet {
+		t.Fatalf("moyens de paiement = %v", methods)
+	}
+}
+
+func TestLockUserAccountTxQueriesByCodeOnly(t *testing.T) {
+	db, queries := dryRunDB(t)
+	service := NewWalletService(db)
+	userID := uuid.New()
+
+	if _, err := service.lockUserAccountTx(db, userID); err != nil {
+		t.Fatalf("verrouillage: %v", err)
+	}
+
+	got := queries()
+	if len(got) != 1 {
+		t.Fatalf("requêtes = %v, attendu une seule lecture", got)
+	}
+	if strings.Contains(got[0], `"wallet_accounts"."id" =`) {
+		t.Fatalf("la lecture du compte ne doit filtrer que sur le code: %s", got[0])
+	}
+	if !strings.Contains(got[0], "FOR UPDATE") {
+		t.Fatalf("la lecture du compte doit le verrouiller: %s", got[0])
+	}
+}
+
+func TestLockUserAccountTxTwice(t *testing.T) {
+	tx := testTx(t)
+	service := NewWalletService(tx)
+
+	user := models.User{
+		Phone:        "+22177" + strconv.Itoa(1000000+rand.Intn(8999999)),
+		PasswordHash: "x",
+		FirstName:    "Test",
+		LastName:     "Wallet",
+		Region:       "Dakar",
+	}
+	if err := tx.Omit("email").Create(&user).Error; err != nil {
+		t.Fatalf("création utilisateur: %v", err)
+	}
+
+	first, err := service.lockUserAccountTx(tx, user.ID)
+	if err != nil {
+		t.Fatalf("premier appel: %v", err)
+	}
+	second, err := service.lockUserAccountTx(tx, user.ID)
+	if err != nil {
+		t.Fatalf("second appel (compte existant): %v", err)
+	}
+	if first.ID != second.ID {
+		t.Fatalf("compte = %s puis %s, attendu le même", first.ID, second.ID)
+	}
+}
@@ -0,0 +1,321 @@
+// internal/services/wave_client.go
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	ErrWaveInvalidSignature = errors.New("signature webhook Wave invalide")
+	ErrWaveAPI              = errors.New("erreur API Wave")
+)
+
+const (
+	WaveDefaultAPIURL = "https://api.wave.com"
+
+	// Statuts d'une session Checkout Wave
+	WaveCheckoutStatusOpen     = "open"
+	WaveCheckoutStatusComplete = "complete"
+	WaveCheckoutStatusExpired  = "expired"
+
+	// Statuts du paiement associé à la session
+	WavePaymentStatusProcessing = "processing"
+	WavePaymentStatusCancelled  = "cancelled"
+	WavePaymentStatusSucceeded  = "succeeded"
+
+	// Types d'événements webhook
+	WaveEventCheckoutCompleted     = "checkout.session.completed"
+	WaveEventCheckoutPaymentFailed = "checkout.session.payment_failed"
+)
+
+// WaveClient client HTTP pour l'API Wave Checkout
+type WaveClient struct {
+	apiURL        string
+	apiKey        string
+	webhookSecret string
+	environment   string // Webhooks non signés tolérés uniquement en développement
+	httpClient    *http.Client
+}
+
+// WaveCheckoutRequest corps de création d'une session Checkout
+type WaveCheckoutRequest struct {
+	Amount          string `json:"amount"`
+	Currency        string `json:"currency"`
+	ErrorURL        string `json:"error_url"`
+	SuccessURL      string `json:"success_url"`
+	ClientReference string `json:"client_reference,omitempty"`
+}
+
+// WaveCheckoutSession session Checkout telle que renvoyée par Wave
+type WaveCheckoutSession struct {
+	ID               string            `json:"id"`
+	Amount           string            `json:"amount"`
+	Currency         string            `json:"currency"`
+	BusinessName     string            `json:"business_name,omitempty"`
+	CheckoutStatus   string            `json:"checkout_status"`
+	PaymentStatus    string            `json:"payment_status"`
+	ClientReference  string            `json:"client_reference,omitempty"`
+	TransactionID    string            `json:"transaction_id,omitempty"`
+	WaveLaunchURL    string            `json:"wave_launch_url"`
+	SuccessURL       string            `json:"success_url,omitempty"`
+	ErrorURL         string            `json:"error_url,omitempty"`
+	LastPaymentError *WavePaymentError `json:"last_payment_error,omitempty"`
+	WhenCreated      string            `json:"when_created,omitempty"`
+	WhenCompleted    string            `json:"when_completed,omitempty"`
+	WhenExpires      string            `json:"when_expires,omitempty"`
+}
+
+// WavePaymentError détail d'un échec de paiement Wave
+type WavePaymentError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// WaveWebhookEvent événement envoyé par Wave sur l'URL de notification
+type WaveWebhookEvent struct {
+	ID   string              `json:"id"`
+	Type string              `json:"type"`
+	Data WaveCheckoutSession `json:"data"`
+}
+
+type waveErrorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func NewWaveClient(apiURL, apiKey, webhookSecret, environment string) *WaveClient {
+	if apiURL == "" {
+		apiURL = WaveDefaultAPIURL
+	}
+
+	return &WaveClient{
+		apiURL:        strings.TrimRight(apiURL, "/"),
+		apiKey:        apiKey,
+		webhookSecret: webhookSecret,
+		environment:   environment,
+		httpClient:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// IsConfigured vérifie si la clé API Wave est présente
+func (c *WaveClient) IsConfigured() bool {
+	return c.apiKey != "" && c.apiURL != "sandbox"
+}
+
+// checkSimulationAllowed les sessions simulées ne sont permises qu'en développement :
+// ailleurs, un client sans clé API renvoie une erreur au lieu d'une fausse URL de paiement
+func (c *WaveClient) checkSimulationAllowed() error {
+	if c.environment != "development" {
+		return fmt.Errorf("%w: WAVE_API_KEY non configuré", ErrWaveAPI)
+	}
+	return nil
+}
+
+// CreateCheckoutSession crée une session de paiement Wave
+func (c *WaveClient) CreateCheckoutSession(req *WaveCheckoutRequest) (*WaveCheckoutSession, error) {
+	// En mode développement, simuler la session
+	if !c.IsConfigured() {
+		if err := c.checkSimulationAllowed(); err != nil {
+			return nil, err
+		}
+		ref := req.ClientReference
+		if len(ref) > 8 {
+			ref = ref[:8]
+		}
+		log.Printf("🌊 WAVE DEV MODE - Session simulée pour %s (%s %s)", req.ClientReference, req.Amount, req.Currency)
+		return &WaveCheckoutSession{
+			ID:              "cos-sandbox-" + ref,
+			Amount:          req.Amount,
+			Currency:        req.Currency,
+			CheckoutStatus:  WaveCheckoutStatusOpen,
+			PaymentStatus:   WavePaymentStatusProcessing,
+			ClientReference: req.ClientReference,
+			WaveLaunchURL:   "https://pay.wave.com/c/cos-sandbox-" + ref,
+			SuccessURL:      req.SuccessURL,
+			ErrorURL:        req.ErrorURL,
+		}, nil
+	}
+
+	var session WaveCheckoutSession
+	if err := c.do(http.MethodPost, "/v1/checkout/sessions", req, &session); err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// GetCheckoutSession récupère l'état d'une session Checkout
+func (c *WaveClient) GetCheckoutSession(sessionID string) (*WaveCheckoutSession, error) {
+	if !c.IsConfigured() {
+		if err := c.checkSimulationAllowed(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: client non configuré", ErrWaveAPI)
+	}
+
+	var session WaveCheckoutSession
+	if err := c.do(http.MethodGet, "/v1/checkout/sessions/"+sessionID, nil, &session); err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// RefundCheckoutSession rembourse intégralement une session Checkout terminée
+func (c *WaveClient) RefundCheckoutSession(sessionID string) error {
+	if !c.IsConfigured() {
+		if err := c.checkSimulationAllowed(); err != nil {
+			return err
+		}
+		log.Printf("🌊 WAVE DEV MODE - Remboursement simulé pour %s", sessionID)
+		return nil
+	}
+
+	return c.do(http.MethodPost, "/v1/checkout/sessions/"+sessionID+"/refund", nil, nil)
+}
+
+// VerifyWebhookSignature vérifie l'en-tête Wave-Signature (t=<timestamp>,v1=<hmac>)
+func (c *WaveClient) VerifyWebhookSignature(header string, body []byte) error {
+	if c.webhookSecret == "" {
+		// Sans secret, n'importe qui pourrait forger un webhook "completed"
+		if c.environment != "development" {
+			return fmt.Errorf("%w: WAVE_WEBHOOK_SECRET non configuré", ErrWaveInvalidSignature)
+		}
+		log.Printf("⚠️  WAVE_WEBHOOK_SECRET manquant, vérification de signature ignorée (développement)")
+		return nil
+	}
+
+	timestamp, signatures := parseWaveSignatureHeader(header)
+	if timestamp == "" || len(signatures) == 0 {
+		return ErrWaveInvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(c.webhookSecret))
+	mac.Write([]byte(timestamp))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+
+	for _, signature := range signatures {
+		if hmac.Equal([]byte(signature), []byte(expected)) {
+			return checkWebhookTimestamp(timestamp, WebhookTimestampTolerance, time.Now())
+		}
+	}
+
+	return ErrWaveInvalidSignature
+}
+
+// ParseWebhookEvent vérifie la signature puis décode l'événement
+func (c *WaveClient) ParseWebhookEvent(header string, body []byte) (*WaveWebhookEvent, error) {
+	if err := c.VerifyWebhookSignature(header, body); err != nil {
+		return nil, err
+	}
+
+	return c.DecodeWebhookEvent(body)
+}
+
+// DecodeWebhookEvent décode un événement déjà authentifié
+func (c *WaveClient) DecodeWebhookEvent(body []byte) (*WaveWebhookEvent, error) {
+	var event WaveWebhookEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, fmt.Errorf("erreur décodage webhook Wave: %w", err)
+	}
+
+	return &event, nil
+}
+
+// do exécute une requête authentifiée vers l'API Wave
+func (c *WaveClient) do(method, path string, payload interface{}, dest interface{}) error {
+	var body io.Reader
+	if payload != nil {
+		jsonData, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("erreur sérialisation JSON: %w", err)
+		}
+		body = bytes.NewBuffer(jsonData)
+	}
+
+	req, err := http.NewRequest(method, c.apiURL+path, body)
+	if err != nil {
+		return fmt.Errorf("erreur création requête: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("erreur appel API Wave: %w", err)
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("erreur lecture réponse: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var apiErr waveErrorResponse
+		if json.Unmarshal(bodyBytes, &apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("%w: HTTP %d %s (%s)", ErrWaveAPI, resp.StatusCode, apiErr.Message, apiErr.Code)
+		}
+		return fmt.Errorf("%w: HTTP %d", ErrWaveAPI, resp.StatusCode)
+	}
+
+	if dest == nil || len(bodyBytes) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(bodyBytes, dest); err != nil {
+		return fmt.Errorf("erreur décodage réponse: %w", err)
+	}
+
+	return nil
+}
+
+// parseWaveSignatureHeader extrait le timestamp et les signatures v1
+func parseWaveSignatureHeader(header string) (string, []string) {
+	var timestamp string
+	var signatures []string
+
+	for _, part := range strings.Split(header, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(part), "=")
+		if !found {
+			continue
+		}
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	return timestamp, signatures
+}
+
+// WaveSessionPaymentStatus convertit l'état d'une session Wave en statut models.Payment
+func WaveSessionPaymentStatus(session *WaveCheckoutSession) string {
+	switch {
+	case session.PaymentStatus == WavePaymentStatusSucceeded:
+		return "completed"
+	case session.CheckoutStatus == WaveCheckoutStatusExpired:
+		return "cancelled"
+	case session.PaymentStatus == WavePaymentStatusCancelled:
+		return "failed"
+	default:
+		return "pending"
+	}
+}
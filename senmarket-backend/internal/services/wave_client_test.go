@@ -0,0 +1,220 @@
+// internal/services/wave_client_test.go
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func newWaveTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/checkout/sessions", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer wave-test-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(waveErrorResponse{Code: "missing-auth-header", Message: "Unauthorized"})
+			return
+		}
+
+		var req WaveCheckoutRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		json.NewEncoder(w).Encode(WaveCheckoutSession{
+			ID:              "cos-18qq25rgr100a",
+			Amount:          req.Amount,
+			Currency:        req.Currency,
+			CheckoutStatus:  WaveCheckoutStatusOpen,
+			PaymentStatus:   WavePaymentStatusProcessing,
+			ClientReference: req.ClientReference,
+			WaveLaunchURL:   "https://pay.wave.com/c/cos-18qq25rgr100a",
+		})
+	})
+	mux.HandleFunc("/v1/checkout/sessions/cos-18qq25rgr100a", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(WaveCheckoutSession{
+			ID:             "cos-18qq25rgr100a",
+			CheckoutStatus: WaveCheckoutStatusComplete,
+			PaymentStatus:  WavePaymentStatusSucceeded,
+			TransactionID:  "TCN4Y4ZC3FM",
+		})
+	})
+	mux.HandleFunc("/v1/checkout/sessions/cos-18qq25rgr100a/refund", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/v1/checkout/sessions/cos-unknown", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(waveErrorResponse{Code: "checkout-session-not-found", Message: "Session introuvable"})
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestWaveClientCheckoutSession(t *testing.T) {
+	server := newWaveTestServer(t)
+	client := NewWaveClient(server.URL, "wave-test-key", "", "development")
+
+	session, err := client.CreateCheckoutSession(&WaveCheckoutRequest{
+		Amount:          "200",
+		Currency:        "XOF",
+		ErrorURL:        "https://senmarket.sn/payment/cancel",
+		SuccessURL:      "https://senmarket.sn/payment/success",
+		ClientReference: "4f0c1a52-6a3e-4b4c-9a55-3f0c8e2d1b7a",
+	})
+	if err != nil {
+		t.Fatalf("création session: %v", err)
+	}
+	if session.ID != "cos-18qq25rgr100a" || session.WaveLaunchURL == "" {
+		t.Fatalf("session inattendue: %+v", session)
+	}
+	if session.Amount != "200" || session.ClientReference != "4f0c1a52-6a3e-4b4c-9a55-3f0c8e2d1b7a" {
+		t.Fatalf("requête mal transmise: %+v", session)
+	}
+
+	status, err := client.GetCheckoutSession(session.ID)
+	if err != nil {
+		t.Fatalf("lecture session: %v", err)
+	}
+	if got := WaveSessionPaymentStatus(status); got != "completed" {
+		t.Fatalf("statut = %q, attendu completed", got)
+	}
+
+	if err := client.RefundCheckoutSession(session.ID); err != nil {
+		t.Fatalf("remboursement: %v", err)
+	}
+
+	if _, err := client.GetCheckoutSession("cos-unknown"); !errors.Is(err, ErrWaveAPI) {
+		t.Fatalf("erreur attendue ErrWaveAPI, obtenu %v", err)
+	}
+}
+
+func TestWaveClientRejectsBadAPIKey(t *testing.T) {
+	server := newWaveTestServer(t)
+	client := NewWaveClient(server.URL, "mauvaise-cle", "", "development")
+
+	_, err := client.CreateCheckoutSession(&WaveCheckoutRequest{Amount: "200", Currency: "XOF"})
+	if !errors.Is(err, ErrWaveAPI) {
+		t.Fatalf("erreur attendue ErrWaveAPI, obtenu %v", err)
+	}
+}
+
+func TestWaveClientVerifyWebhookSignature(t *testing.T) {
+	client := NewWaveClient("", "wave-test-key", "wave-webhook-secret", "production")
+	body := []byte(`{"id":"EV_QvEZuDSQbLdI","type":"checkout.session.completed","data":{"id":"cos-18qq25rgr100a","checkout_status":"complete","payment_status":"succeeded"}}`)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	sign := func(ts string) string {
+		mac := hmac.New(sha256.New, []byte("wave-webhook-secret"))
+		mac.Write([]byte(ts))
+		mac.Write(body)
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+	signature := sign(timestamp)
+
+	event, err := client.ParseWebhookEvent("t="+timestamp+",v1=deadbeef,v1="+signature, body)
+	if err != nil {
+		t.Fatalf("signature valide rejetée: %v", err)
+	}
+	if event.Type != WaveEventCheckoutCompleted || WaveSessionPaymentStatus(&event.Data) != "completed" {
+		t.Fatalf("événement inattendu: %+v", event)
+	}
+
+	tests := map[string]string{
+		"signature falsifiée": "t=" + timestamp + ",v1=deadbeef",
+		"timestamp modifié":   "t=1639081944,v1=" + signature,
+		"en-tête vide":        "",
+		"sans v1":             "t=" + timestamp,
+	}
+	for name, header := range tests {
+		if err := client.VerifyWebhookSignature(header, body); !errors.Is(err, ErrWaveInvalidSignature) {
+			t.Errorf("%s: erreur attendue ErrWaveInvalidSignature, obtenu %v", name, err)
+		}
+	}
+
+	// Signature valide mais horodatage hors tolérance
+	stale := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	if err := client.VerifyWebhookSignature("t="+stale+",v1="+sign(stale), body); !errors.Is(err, ErrWebhookExpired) {
+		t.Errorf("erreur attendue ErrWebhookExpired, obtenu %v", err)
+	}
+}
+
+func TestWaveClientMissingWebhookSecret(t *testing.T) {
+	body := []byte(`{"id":"EV_1","type":"checkout.session.completed"}`)
+
+	for _, env := range []string{"production", "staging", ""} {
+		client := NewWaveClient("", "wave-test-key", "", env)
+		if err := client.VerifyWebhookSignature("", body); !errors.Is(err, ErrWaveInvalidSignature) {
+			t.Errorf("ENV=%q sans secret: erreur attendue ErrWaveInvalidSignature, obtenu %v", env, err)
+		}
+	}
+
+	client := NewWaveClient("", "wave-test-key", "", "development")
+	if err := client.VerifyWebhookSignature("", body); err != nil {
+		t.Fatalf("développement sans secret: %v", err)
+	}
+}
+
+func TestWaveClientWithoutAPIKey(t *testing.T) {
+	req := &WaveCheckoutRequest{Amount: "200", Currency: "XOF", ClientReference: "0b6f7d2e-1111-2222-3333-444455556666"}
+
+	// Hors développement, jamais de session simulée ni de remboursement fictif
+	for _, apiURL := range []string{"", "sandbox"} {
+		client := NewWaveClient(apiURL, "", "wave-webhook-secret", "production")
+		if session, err := client.CreateCheckoutSession(req); !errors.Is(err, ErrWaveAPI) {
+			t.Errorf("production sans clé: session %+v, erreur %v", session, err)
+		}
+		if _, err := client.GetCheckoutSession("cos-1"); !errors.Is(err, ErrWaveAPI) {
+			t.Errorf("production sans clé: consultation acceptée (%v)", err)
+		}
+		if err := client.RefundCheckoutSession("cos-1"); !errors.Is(err, ErrWaveAPI) {
+			t.Errorf("production sans clé: remboursement accepté (%v)", err)
+		}
+	}
+
+	client := NewWaveClient("", "", "", "development")
+	session, err := client.CreateCheckoutSession(req)
+	if err != nil || !strings.HasPrefix(session.ID, "cos-sandbox-") {
+		t.Fatalf("développement sans clé: session %+v, erreur %v", session, err)
+	}
+	if err := client.RefundCheckoutSession(session.ID); err != nil {
+		t.Fatalf("développement sans clé: remboursement simulé refusé: %v", err)
+	}
+}
+
+func TestWaveSessionPaymentStatus(t *testing.T) {
+	tests := []struct {
+		session WaveCheckoutSession
+		want    string
+	}{
+		{WaveCheckoutSession{CheckoutStatus: WaveCheckoutStatusOpen, PaymentStatus: WavePaymentStatusProcessing}, "pending"},
+		{WaveCheckoutSession{CheckoutStatus: WaveCheckoutStatusComplete, PaymentStatus: WavePaymentStatusSucceeded}, "completed"},
+		{WaveCheckoutSession{CheckoutStatus: WaveCheckoutStatusOpen, PaymentStatus: WavePaymentStatusCancelled}, "failed"},
+		{WaveCheckoutSession{CheckoutStatus: WaveCheckoutStatusExpired, PaymentStatus: WavePaymentStatusCancelled}, "cancelled"},
+	}
+
+	for _, tt := range tests {
+		if got := WaveSessionPaymentStatus(&tt.session); got != tt.want {
+			t.Errorf("%s/%s = %q, attendu %q", tt.session.CheckoutStatus, tt.session.PaymentStatus, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// internal/services/wave_provider.go
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"senmarket/internal/models"
+)
+
+// WaveProvider provider Wave Checkout
+type WaveProvider struct {
+	client *WaveClient
+}
+
+func NewWaveProvider(client *WaveClient) *WaveProvider {
+	return &WaveProvider{client: client}
+}
+
+func (p *WaveProvider) Name() string {
+	return "wave"
+}
+
+// Initiate crée une session Wave Checkout
+func (p *WaveProvider) Initiate(payment *models.Payment, phone string) (*PaymentInitiation, error) {
+	orderID := payment.ID.String()
+
+	session, err := p.client.CreateCheckoutSession(&WaveCheckoutRequest{
+		Amount:          fmt.Sprintf("%.0f", payment.Amount),
+		Currency:        "XOF",
+		ErrorURL:        "https://senmarket.sn/payment/cancel",
+		SuccessURL:      "https://senmarket.sn/payment/success",
+		ClientReference: orderID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// L'identifiant de session sert de référence pour les webhooks et le suivi
+	return &PaymentInitiation{
+		Status:     "success",
+		PaymentURL: session.WaveLaunchURL,
+		OrderID:    orderID,
+		Reference:  session.ID,
+		Message:    "Redirection vers Wave",
+	}, nil
+}
+
+// VerifySignature vérifie l'en-tête Wave-Signature
+func (p *WaveProvider) VerifySignature(headers http.Header, body []byte) error {
+	if err := p.client.VerifyWebhookSignature(headers.Get("Wave-Signature"), body); err != nil {
+		if errors.Is(err, ErrWebhookExpired) {
+			return err
+		}
+		return fmt.Errorf("%w: %v", ErrInvalidWebhookSignature, err)
+	}
+	return nil
+}
+
+// ParseWebhook décode un événement checkout.session.*
+func (p *WaveProvider) ParseWebhook(body []byte) (*PaymentNotification, error) {
+	event, err := p.client.DecodeWebhookEvent(body)
+	if err != nil {
+		return nil, err
+	}
+
+	notification := waveSessionNotification(&event.Data)
+	notification.EventID = event.ID
+	if event.Type == WaveEventCheckoutPaymentFailed && notification.Status == "pending" {
+		notification.Status = "failed"
+	}
+
+	return notification, nil
+}
+
+// QueryStatus lit l'état de la session Checkout associée
+func (p *WaveProvider) QueryStatus(payment *models.Payment) (*PaymentNotification, error) {
+	if !p.client.IsConfigured() {
+		if err := p.client.checkSimulationAllowed(); err != nil {
+			return nil, err
+		}
+		return &PaymentNotification{
+			PaymentID: payment.ID.String(),
+			Reference: payment.TransactionID,
+			Status:    payment.Status,
+		}, nil
+	}
+
+	session, err := p.client.GetCheckoutSession(payment.TransactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return waveSessionNotification(session), nil
+}
+
+// Refund rembourse la session Checkout (Wave ne gère que le remboursement total)
+func (p *WaveProvider) Refund(payment *models.Payment, amount float64) error {
+	if amount < payment.Amount {
+		return fmt.Errorf("%w: remboursement partiel Wave", ErrOperationNotSupported)
+	}
+	return p.client.RefundCheckoutSession(payment.TransactionID)
+}
+
+// waveSessionNotification convertit une session Wave en notification normalisée
+func waveSessionNotification(session *WaveCheckoutSession) *PaymentNotification {
+	notification := &PaymentNotification{
+		PaymentID: session.ClientReference,
+		Reference: session.ID,
+		Status:    WaveSessionPaymentStatus(session),
+		Currency:  session.Currency,
+	}
+	// Wave transmet le montant en chaîne décimale
+	if amount, err := strconv.ParseFloat(session.Amount, 64); err == nil {
+		notification.Amount = amount
+	}
+
+	if session.LastPaymentError != nil {
+		notification.FailureReason = session.LastPaymentError.Message
+	} else if session.CheckoutStatus == WaveCheckoutStatusExpired {
+		notification.FailureReason = "session Wave expirée"
+	}
+
+	return notification
+}
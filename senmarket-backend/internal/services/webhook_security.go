@@ -0,0 +1,138 @@
+// internal/services/webhook_security.go
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrWebhookExpired = errors.New("webhook expiré")
+	ErrWebhookReplay  = errors.New("webhook déjà traité")
+)
+
+const (
+	WebhookSignatureHeader    = "X-Signature"
+	WebhookTimestampHeader    = "X-Timestamp"
+	WebhookTimestampTolerance = 5 * time.Minute
+	WebhookNonceTTL           = 24 * time.Hour
+)
+
+// HMACWebhookVerifier vérifie les notifications signées avec le secret marchand.
+//
+// Format attendu : X-Timestamp (unix) et X-Signature = hex(HMAC-SHA256(secret, timestamp + "." + body)).
+// À défaut d'en-têtes, les champs "signature" et "timestamp" du corps (PaymentWebhook) sont acceptés.
+type HMACWebhookVerifier struct {
+	secret      string
+	environment string // Secret vide toléré uniquement en développement
+	tolerance   time.Duration
+	now         func() time.Time
+}
+
+func NewHMACWebhookVerifier(secret, environment string) *HMACWebhookVerifier {
+	return &HMACWebhookVerifier{
+		secret:      secret,
+		environment: environment,
+		tolerance:   WebhookTimestampTolerance,
+		now:         time.Now,
+	}
+}
+
+// Verify authentifie la notification et contrôle la fraîcheur de l'horodatage
+func (v *HMACWebhookVerifier) Verify(headers http.Header, body []byte) error {
+	if v.secret == "" {
+		// Sans secret, signature, horodatage et anti-rejeu ne protègent plus rien
+		if v.environment != "development" {
+			return fmt.Errorf("%w: secret webhook non configuré", ErrInvalidWebhookSignature)
+		}
+		log.Printf("⚠️  Secret webhook manquant, vérification de signature ignorée (développement)")
+		return nil
+	}
+
+	timestamp := headers.Get(WebhookTimestampHeader)
+	signature := headers.Get(WebhookSignatureHeader)
+	message := []byte(timestamp + "." + string(body))
+
+	// Signature transmise dans le corps (PaymentWebhook)
+	if signature == "" {
+		var payload PaymentWebhook
+		if err := json.Unmarshal(body, &payload); err != nil || payload.Signature == "" {
+			return fmt.Errorf("%w: signature absente", ErrInvalidWebhookSignature)
+		}
+		timestamp = strconv.FormatInt(payload.Timestamp, 10)
+		signature = payload.Signature
+		message = []byte(payload.canonicalString())
+	}
+
+	if timestamp == "" {
+		return fmt.Errorf("%w: horodatage absent", ErrInvalidWebhookSignature)
+	}
+
+	expected := SignWebhookPayload(v.secret, message)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return ErrInvalidWebhookSignature
+	}
+
+	return checkWebhookTimestamp(timestamp, v.tolerance, v.now())
+}
+
+// SignWebhookPayload calcule la signature HMAC-SHA256 hexadécimale d'un message
+func SignWebhookPayload(secret string, message []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(message)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// canonicalString message signé quand la signature est portée par le corps
+func (w *PaymentWebhook) canonicalString() string {
+	return fmt.Sprintf("%s|%s|%.2f|%s|%s|%d", w.OrderID, w.Status, w.Amount, w.TransactionID, w.Reference, w.Timestamp)
+}
+
+// checkWebhookTimestamp rejette les notifications trop anciennes ou datées du futur
+func checkWebhookTimestamp(timestamp string, tolerance time.Duration, now time.Time) error {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: horodatage invalide", ErrInvalidWebhookSignature)
+	}
+
+	delta := now.Sub(time.Unix(seconds, 0))
+	if delta < 0 {
+		delta = -delta
+	}
+	if delta > tolerance {
+		return fmt.Errorf("%w: décalage de %s", ErrWebhookExpired, delta.Round(time.Second))
+	}
+
+	return nil
+}
+
+// webhookNonce identifiant unique d'une notification pour la protection anti-rejeu
+func webhookNonce(notification *PaymentNotification, body []byte) string {
+	if notification.EventID != "" {
+		return notification.EventID
+	}
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// webhookRejectionReason classe une erreur de webhook pour l'audit
+func webhookRejectionReason(err error) string {
+	switch {
+	case errors.Is(err, ErrWebhookReplay):
+		return "replay"
+	case errors.Is(err, ErrWebhookExpired):
+		return "expired"
+	case errors.Is(err, ErrInvalidWebhookSignature):
+		return "invalid_signature"
+	default:
+		return "invalid_payload"
+	}
+}
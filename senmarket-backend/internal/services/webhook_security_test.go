@@ -0,0 +1,106 @@
+// internal/services/webhook_security_test.go
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestHMACWebhookVerifierHeaders(t *testing.T) {
+	verifier := NewHMACWebhookVerifier("demo-secret", "production")
+	body := []byte(`{"order_id":"4f0c1a52-6a3e-4b4c-9a55-3f0c8e2d1b7a","status":"success"}`)
+	now := time.Now()
+
+	signed := func(ts time.Time, secret string) http.Header {
+		timestamp := strconv.FormatInt(ts.Unix(), 10)
+		headers := http.Header{}
+		headers.Set(WebhookTimestampHeader, timestamp)
+		headers.Set(WebhookSignatureHeader, SignWebhookPayload(secret, []byte(timestamp+"."+string(body))))
+		return headers
+	}
+
+	if err := verifier.Verify(signed(now, "demo-secret"), body); err != nil {
+		t.Fatalf("signature valide rejetée: %v", err)
+	}
+
+	if err := verifier.Verify(signed(now, "autre-secret"), body); !errors.Is(err, ErrInvalidWebhookSignature) {
+		t.Errorf("mauvais secret: erreur attendue ErrInvalidWebhookSignature, obtenu %v", err)
+	}
+
+	if err := verifier.Verify(signed(now.Add(-10*time.Minute), "demo-secret"), body); !errors.Is(err, ErrWebhookExpired) {
+		t.Errorf("horodatage ancien: erreur attendue ErrWebhookExpired, obtenu %v", err)
+	}
+
+	if err := verifier.Verify(http.Header{}, body); !errors.Is(err, ErrInvalidWebhookSignature) {
+		t.Errorf("sans signature: erreur attendue ErrInvalidWebhookSignature, obtenu %v", err)
+	}
+
+	tampered := signed(now, "demo-secret")
+	if err := verifier.Verify(tampered, []byte(`{"order_id":"4f0c1a52-6a3e-4b4c-9a55-3f0c8e2d1b7a","status":"failed"}`)); !errors.Is(err, ErrInvalidWebhookSignature) {
+		t.Errorf("corps modifié: erreur attendue ErrInvalidWebhookSignature, obtenu %v", err)
+	}
+}
+
+func TestHMACWebhookVerifierBodySignature(t *testing.T) {
+	verifier := NewHMACWebhookVerifier("demo-secret", "production")
+
+	webhook := PaymentWebhook{
+		OrderID:       "4f0c1a52-6a3e-4b4c-9a55-3f0c8e2d1b7a",
+		Status:        "success",
+		Amount:        200,
+		TransactionID: "OM-123456",
+		Reference:     "SENMARKET-4f0c1a52",
+		Timestamp:     time.Now().Unix(),
+	}
+	webhook.Signature = SignWebhookPayload("demo-secret", []byte(webhook.canonicalString()))
+
+	body, _ := json.Marshal(webhook)
+	if err := verifier.Verify(http.Header{}, body); err != nil {
+		t.Fatalf("signature du corps rejetée: %v", err)
+	}
+
+	webhook.Amount = 1
+	body, _ = json.Marshal(webhook)
+	if err := verifier.Verify(http.Header{}, body); !errors.Is(err, ErrInvalidWebhookSignature) {
+		t.Errorf("montant modifié: erreur attendue ErrInvalidWebhookSignature, obtenu %v", err)
+	}
+}
+
+func TestWebhookNonce(t *testing.T) {
+	body := []byte(`{"order_id":"abc","status":"success"}`)
+
+	if got := webhookNonce(&PaymentNotification{EventID: "EV_1"}, body); got != "EV_1" {
+		t.Errorf("nonce = %q, attendu EV_1", got)
+	}
+
+	first := webhookNonce(&PaymentNotification{}, body)
+	if first != webhookNonce(&PaymentNotification{}, body) {
+		t.Error("le nonce d'un même corps doit être stable")
+	}
+	if first == webhookNonce(&PaymentNotification{}, []byte(`{"order_id":"abc","status":"failed"}`)) {
+		t.Error("deux corps différents ne doivent pas partager de nonce")
+	}
+
+	if reason := webhookRejectionReason(ErrWebhookReplay); reason != "replay" {
+		t.Errorf("raison = %q, attendu replay", reason)
+	}
+}
+
+func TestHMACWebhookVerifierMissingSecret(t *testing.T) {
+	body := []byte(`{"order_id":"4f0c1a52-6a3e-4b4c-9a55-3f0c8e2d1b7a","status":"success"}`)
+
+	for _, env := range []string{"production", "staging", ""} {
+		verifier := NewHMACWebhookVerifier("", env)
+		if err := verifier.Verify(http.Header{}, body); !errors.Is(err, ErrInvalidWebhookSignature) {
+			t.Errorf("ENV=%q sans secret: erreur attendue ErrInvalidWebhookSignature, obtenu %v", env, err)
+		}
+	}
+
+	if err := NewHMACWebhookVerifier("", "development").Verify(http.Header{}, body); err != nil {
+		t.Fatalf("développement sans secret: %v", err)
+	}
+}
@@ -2,12 +2,13 @@
 package services
 
 import (
+	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
-	"math/rand"
+	"math/big"
 	"net/http"
 	"net/url"
 	"strings"
@@ -65,12 +66,16 @@ func (s *WhatsAppService) SendVerificationCode(phone string) (*WhatsAppResponse,
 	}
 
 	// 2. Générer un nouveau code à 6 chiffres
-	code := s.generateVerificationCode()
+	code, err := s.generateVerificationCode()
+	if err != nil {
+		return nil, err
+	}
 
 	// 3. Sauvegarder en base (réutiliser la table SMS)
 	verification := models.SMSVerification{
 		Phone:     phone,
 		Code:      code,
+		Purpose:   models.VerificationPurposePhone,
 		Verified:  false,
 		ExpiresAt: time.Now().Add(10 * time.Minute),
 	}
@@ -114,11 +119,12 @@ func (s *WhatsAppService) ResendVerificationCode(phone string) (*WhatsAppRespons
 func (s *WhatsAppService) VerifyCode(phone, code string) error {
 	var verification models.SMSVerification
 	
-	if err := s.db.Where("phone = ? AND code = ? AND verified = ? AND expires_at > ?", 
-		phone, code, false, time.Now()).
+	if err := s.db.Where("phone = ? AND code = ? AND purpose = ? AND verified = ? AND expires_at > ?", 
+		phone, code, models.VerificationPurposePhone, false, time.Now()).
 		Order("created_at DESC").
 		First(&verification).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
+			s.registerFailedAttempt(phone)
 			return fmt.Errorf("code invalide ou expiré")
 		}
 		return fmt.Errorf("erreur vérification code: %w", err)
@@ -129,6 +135,12 @@ func (s *WhatsAppService) VerifyCode(phone, code string) error {
 	return s.db.Save(&verification).Error
 }
 
+// SendCode envoie un code déjà généré et enregistré par l'appelant (connexion OTP)
+func (s *WhatsAppService) SendCode(phone, code string) error {
+	_, err := s.sendWhatsAppMessage(phone, s.createVerificationMessage(code))
+	return err
+}
+
 // SendWelcomeMessage envoie un message de bienvenue
 func (s *WhatsAppService) SendWelcomeMessage(phone, firstName string) error {
 	message := fmt.Sprintf(`🎉 Bienvenue sur *SenMarket*, %s !
@@ -148,13 +160,40 @@ Bonne vente ! 🇸🇳`, firstName)
 	return err
 }
 
+// SendMessage envoie un message libre (alertes, notifications)
+func (s *WhatsAppService) SendMessage(phone, message string) error {
+	_, err := s.sendWhatsAppMessage(phone, message)
+	return err
+}
+
 // ===============================
 // MÉTHODES PRIVÉES
 // ===============================
 
-func (s *WhatsAppService) generateVerificationCode() string {
-	code := rand.Intn(900000) + 100000
-	return fmt.Sprintf("%06d", code)
+func (s *WhatsAppService) generateVerificationCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(900000))
+	if err != nil {
+		return "", fmt.Errorf("erreur génération code: %w", err)
+	}
+	return fmt.Sprintf("%06d", n.Int64()+100000), nil
+}
+
+// registerFailedAttempt compte une saisie erronée sur le code actif et l'invalide
+// au-delà de models.MaxVerificationAttempts
+func (s *WhatsAppService) registerFailedAttempt(phone string) {
+	result := s.db.Model(&models.SMSVerification{}).
+		Where("id = (?)", s.db.Model(&models.SMSVerification{}).
+			Select("id").
+			Where("phone = ? AND purpose = ? AND verified = ? AND expires_at > ?", phone, models.VerificationPurposePhone, false, time.Now()).
+			Order("created_at DESC").
+			Limit(1)).
+		Updates(map[string]interface{}{
+			"attempts": gorm.Expr("attempts + 1"),
+			"verified": gorm.Expr("attempts + 1 >= ?", models.MaxVerificationAttempts),
+		})
+	if result.Error != nil {
+		log.Printf("Erreur comptage tentatives WhatsApp: %v", result.Error)
+	}
 }
 
 func (s *WhatsAppService) createVerificationMessage(code string) string {
@@ -170,7 +209,7 @@ Besoin d'aide ? Répondez HELP`, code)
 
 func (s *WhatsAppService) invalidateOldCodes(phone string) error {
 	return s.db.Model(&models.SMSVerification{}).
-		Where("phone = ? AND verified = ? AND expires_at > ?", phone, false, time.Now()).
+		Where("phone = ? AND purpose = ? AND verified = ? AND expires_at > ?", phone, models.VerificationPurposePhone, false, time.Now()).
 		Update("verified", true).Error
 }
 
@@ -0,0 +1,8 @@
+-- migrations/014_add_mock_payment_method.down.sql
+-- Rollback du provider de paiement 'mock'
+
+DELETE FROM payments WHERE payment_method = 'mock';
+
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_payment_method_check;
+ALTER TABLE payments ADD CONSTRAINT payments_payment_method_check
+    CHECK (payment_method IN ('orange_money', 'wave', 'free_money', 'card'));
@@ -0,0 +1,6 @@
+-- migrations/014_add_mock_payment_method.up.sql
+-- Autoriser le provider de paiement 'mock' (développement et tests)
+
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_payment_method_check;
+ALTER TABLE payments ADD CONSTRAINT payments_payment_method_check
+    CHECK (payment_method IN ('orange_money', 'wave', 'free_money', 'card', 'mock'));
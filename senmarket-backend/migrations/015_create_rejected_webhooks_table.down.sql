@@ -0,0 +1,2 @@
+-- Supprimer la table rejected_webhooks
+DROP TABLE IF EXISTS rejected_webhooks;
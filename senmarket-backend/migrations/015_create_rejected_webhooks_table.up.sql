@@ -0,0 +1,22 @@
+-- migrations/015_create_rejected_webhooks_table.up.sql
+-- Journal d'audit des webhooks de paiement rejetés (signature, horodatage, rejeu)
+
+CREATE TABLE rejected_webhooks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    provider VARCHAR(50) NOT NULL,
+    reason VARCHAR(100) NOT NULL,
+    error_message TEXT,
+    signature TEXT,
+    headers TEXT,
+    payload TEXT,
+    remote_ip VARCHAR(45),
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+COMMENT ON TABLE rejected_webhooks IS 'Webhooks de paiement refusés, conservés pour audit';
+COMMENT ON COLUMN rejected_webhooks.reason IS 'invalid_signature, expired, replay, invalid_payload';
+COMMENT ON COLUMN rejected_webhooks.headers IS 'En-têtes HTTP reçus (JSON)';
+
+CREATE INDEX idx_rejected_webhooks_provider ON rejected_webhooks(provider);
+CREATE INDEX idx_rejected_webhooks_reason ON rejected_webhooks(reason);
+CREATE INDEX idx_rejected_webhooks_created_at ON rejected_webhooks(created_at DESC);
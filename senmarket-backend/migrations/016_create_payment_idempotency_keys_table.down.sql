@@ -0,0 +1,2 @@
+-- Supprimer la table payment_idempotency_keys
+DROP TABLE IF EXISTS payment_idempotency_keys;
@@ -0,0 +1,23 @@
+-- migrations/016_create_payment_idempotency_keys_table.up.sql
+-- Clés d'idempotence pour l'initiation des paiements (en-tête Idempotency-Key)
+
+CREATE TABLE payment_idempotency_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    idempotency_key VARCHAR(255) NOT NULL,
+    request_hash VARCHAR(64) NOT NULL,
+    payment_id UUID REFERENCES payments(id) ON DELETE CASCADE,
+    response TEXT,
+    created_at TIMESTAMP DEFAULT NOW(),
+    expires_at TIMESTAMP NOT NULL,
+
+    -- Une clé est propre à chaque utilisateur
+    UNIQUE(user_id, idempotency_key)
+);
+
+COMMENT ON TABLE payment_idempotency_keys IS 'Clés Idempotency-Key des initiations de paiement';
+COMMENT ON COLUMN payment_idempotency_keys.request_hash IS 'SHA-256 du corps de la requête d''origine';
+COMMENT ON COLUMN payment_idempotency_keys.payment_id IS 'NULL tant que l''initiation est en cours';
+COMMENT ON COLUMN payment_idempotency_keys.response IS 'Réponse provider renvoyée lors des rejeux (JSON)';
+
+CREATE INDEX idx_payment_idempotency_keys_expires_at ON payment_idempotency_keys(expires_at);
@@ -0,0 +1,9 @@
+-- Supprimer la table refunds et les statuts de remboursement
+DROP TABLE IF EXISTS refunds;
+
+UPDATE payments SET status = 'completed' WHERE status IN ('refunded', 'partially_refunded');
+ALTER TABLE payments DROP COLUMN IF EXISTS refunded_amount;
+
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_status_check;
+ALTER TABLE payments ADD CONSTRAINT payments_status_check
+    CHECK (status IN ('pending', 'completed', 'failed', 'cancelled'));
@@ -0,0 +1,33 @@
+-- migrations/017_create_refunds_table.up.sql
+-- Remboursements (totaux ou partiels) des paiements
+
+-- Nouveaux statuts de paiement
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_status_check;
+ALTER TABLE payments ADD CONSTRAINT payments_status_check
+    CHECK (status IN ('pending', 'completed', 'failed', 'cancelled', 'refunded', 'partially_refunded'));
+
+-- Montant cumulé remboursé (ou en cours de remboursement)
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS refunded_amount DECIMAL(10,2) DEFAULT 0 NOT NULL CHECK (refunded_amount >= 0);
+
+CREATE TABLE refunds (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    payment_id UUID NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+    amount DECIMAL(10,2) NOT NULL CHECK (amount > 0),
+    currency VARCHAR(3) DEFAULT 'XOF',
+    reason TEXT NOT NULL,
+    status VARCHAR(20) DEFAULT 'pending' CHECK (status IN ('pending', 'completed', 'failed')),
+    failure_reason TEXT,
+    unpublish_listing BOOLEAN DEFAULT FALSE,
+    requested_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    completed_at TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+COMMENT ON TABLE refunds IS 'Remboursements des paiements via les providers';
+COMMENT ON COLUMN refunds.unpublish_listing IS 'Repasser l''annonce payée en brouillon';
+COMMENT ON COLUMN refunds.requested_by IS 'Administrateur à l''origine du remboursement';
+
+CREATE INDEX idx_refunds_payment_id ON refunds(payment_id);
+CREATE INDEX idx_refunds_status ON refunds(status);
+CREATE INDEX idx_refunds_created_at ON refunds(created_at DESC);
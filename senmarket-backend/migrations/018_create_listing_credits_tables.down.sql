@@ -0,0 +1,6 @@
+-- Supprimer les crédits d'annonces
+DROP TABLE IF EXISTS listing_credit_transactions;
+DROP TABLE IF EXISTS listing_credits;
+
+DROP INDEX IF EXISTS idx_payments_purpose;
+ALTER TABLE payments DROP COLUMN IF EXISTS purpose;
@@ -0,0 +1,44 @@
+-- migrations/018_create_listing_credits_tables.up.sql
+-- Packs d'annonces prépayées : lots de crédits avec expiration et journal des mouvements
+
+-- Objet du paiement (publication d'annonce, achat de pack...)
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS purpose VARCHAR(30) DEFAULT 'listing' NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_payments_purpose ON payments(purpose);
+
+-- Lots de crédits (un lot par pack acheté)
+CREATE TABLE listing_credits (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    payment_id UUID UNIQUE REFERENCES payments(id) ON DELETE SET NULL,
+    pack VARCHAR(20) NOT NULL,
+    credits_total INTEGER NOT NULL CHECK (credits_total > 0),
+    credits_remaining INTEGER NOT NULL CHECK (credits_remaining >= 0 AND credits_remaining <= credits_total),
+    price DECIMAL(10,2) NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+COMMENT ON TABLE listing_credits IS 'Lots de crédits d''annonces issus des packs achetés';
+COMMENT ON COLUMN listing_credits.payment_id IS 'Paiement du pack (unique : un paiement crédite une seule fois)';
+COMMENT ON COLUMN listing_credits.credits_remaining IS 'Crédits encore utilisables dans ce lot';
+
+CREATE INDEX idx_listing_credits_available ON listing_credits(user_id, expires_at)
+WHERE credits_remaining > 0;
+
+-- Journal des mouvements de crédits
+CREATE TABLE listing_credit_transactions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    credit_id UUID NOT NULL REFERENCES listing_credits(id) ON DELETE CASCADE,
+    listing_id UUID REFERENCES listings(id) ON DELETE SET NULL,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('purchase', 'consume', 'expire', 'revoke')),
+    quantity INTEGER NOT NULL, -- positif = crédit, négatif = débit
+    description TEXT,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+COMMENT ON TABLE listing_credit_transactions IS 'Journal des crédits d''annonces (achat, consommation, expiration, révocation)';
+
+CREATE INDEX idx_listing_credit_transactions_user ON listing_credit_transactions(user_id, created_at DESC);
+CREATE INDEX idx_listing_credit_transactions_credit ON listing_credit_transactions(credit_id);
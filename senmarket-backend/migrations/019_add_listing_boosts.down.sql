@@ -0,0 +1,9 @@
+-- Supprimer les options premium
+DROP TABLE IF EXISTS listing_boosts;
+
+DROP INDEX IF EXISTS idx_listings_highlighted_until;
+DROP INDEX IF EXISTS idx_listings_boosted_until;
+
+ALTER TABLE listings DROP COLUMN IF EXISTS highlighted_until;
+ALTER TABLE listings DROP COLUMN IF EXISTS is_highlighted;
+ALTER TABLE listings DROP COLUMN IF EXISTS boosted_until;
@@ -0,0 +1,31 @@
+-- migrations/019_add_listing_boosts.up.sql
+-- Mise en avant payante (boost) et mise en couleur limitées dans le temps
+
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS boosted_until TIMESTAMP;
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS is_highlighted BOOLEAN DEFAULT FALSE NOT NULL;
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS highlighted_until TIMESTAMP;
+
+COMMENT ON COLUMN listings.boosted_until IS 'Fin de la mise en avant payante (is_featured)';
+COMMENT ON COLUMN listings.highlighted_until IS 'Fin de la mise en couleur payante (is_highlighted)';
+
+-- La mise en avant gratuite à la création n'est plus possible
+UPDATE listings SET is_featured = FALSE WHERE is_featured = TRUE AND boosted_until IS NULL;
+
+CREATE INDEX idx_listings_boosted_until ON listings(boosted_until DESC) WHERE is_featured = TRUE;
+CREATE INDEX idx_listings_highlighted_until ON listings(highlighted_until) WHERE is_highlighted = TRUE;
+
+-- Historique des options achetées
+CREATE TABLE listing_boosts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    listing_id UUID NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    payment_id UUID UNIQUE REFERENCES payments(id) ON DELETE SET NULL,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('boost', 'highlight')),
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+COMMENT ON TABLE listing_boosts IS 'Options premium achetées (boost, mise en couleur)';
+
+CREATE INDEX idx_listing_boosts_listing_id ON listing_boosts(listing_id, created_at DESC);
@@ -0,0 +1,10 @@
+-- Supprimer le portefeuille
+DROP TABLE IF EXISTS wallet_entries;
+DROP TABLE IF EXISTS wallet_transactions;
+DROP TABLE IF EXISTS wallet_accounts;
+
+DELETE FROM payments WHERE payment_method = 'wallet';
+
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_payment_method_check;
+ALTER TABLE payments ADD CONSTRAINT payments_payment_method_check
+    CHECK (payment_method IN ('orange_money', 'wave', 'free_money', 'card', 'mock'));
@@ -0,0 +1,65 @@
+-- migrations/020_create_wallet_tables.up.sql
+-- Portefeuille prépayé en partie double : comptes, transactions et écritures
+
+-- Nouveau moyen de paiement interne
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_payment_method_check;
+ALTER TABLE payments ADD CONSTRAINT payments_payment_method_check
+    CHECK (payment_method IN ('orange_money', 'wave', 'free_money', 'card', 'mock', 'wallet'));
+
+-- Comptes : un par utilisateur + comptes système de contrepartie
+CREATE TABLE wallet_accounts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code VARCHAR(60) NOT NULL UNIQUE,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('user', 'system')),
+    user_id UUID UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+    balance DECIMAL(12,2) DEFAULT 0 NOT NULL,
+    currency VARCHAR(3) DEFAULT 'XOF',
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+
+    -- Un compte utilisateur ne peut jamais être débiteur
+    CONSTRAINT wallet_accounts_user_balance_check CHECK (type <> 'user' OR balance >= 0),
+    CONSTRAINT wallet_accounts_user_check CHECK ((type = 'user') = (user_id IS NOT NULL))
+);
+
+COMMENT ON TABLE wallet_accounts IS 'Comptes du portefeuille (utilisateurs et comptes système)';
+COMMENT ON COLUMN wallet_accounts.balance IS 'Solde courant, égal à la somme des écritures du compte';
+
+INSERT INTO wallet_accounts (code, type) VALUES
+    ('system:topups', 'system'),   -- Contrepartie des rechargements mobile money
+    ('system:revenue', 'system');  -- Achats réglés par portefeuille
+
+-- Transactions : une opération métier équilibrée
+CREATE TABLE wallet_transactions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('topup', 'purchase', 'refund')),
+    purpose VARCHAR(30),
+    amount DECIMAL(12,2) NOT NULL CHECK (amount > 0),
+    payment_id UUID REFERENCES payments(id) ON DELETE SET NULL,
+    refund_id UUID REFERENCES refunds(id) ON DELETE SET NULL,
+    description TEXT,
+    created_at TIMESTAMP DEFAULT NOW(),
+
+    -- Un paiement ne peut être comptabilisé qu'une fois par type d'opération
+    UNIQUE(payment_id, type)
+);
+
+COMMENT ON TABLE wallet_transactions IS 'Opérations du portefeuille (rechargement, achat, remboursement)';
+
+CREATE INDEX idx_wallet_transactions_user ON wallet_transactions(user_id, created_at DESC);
+
+-- Écritures : la somme des montants d'une transaction est toujours nulle
+CREATE TABLE wallet_entries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    transaction_id UUID NOT NULL REFERENCES wallet_transactions(id) ON DELETE CASCADE,
+    account_id UUID NOT NULL REFERENCES wallet_accounts(id) ON DELETE CASCADE,
+    amount DECIMAL(12,2) NOT NULL CHECK (amount <> 0), -- positif = crédit, négatif = débit
+    balance_after DECIMAL(12,2) NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+COMMENT ON TABLE wallet_entries IS 'Écritures en partie double des transactions du portefeuille';
+
+CREATE INDEX idx_wallet_entries_account ON wallet_entries(account_id, created_at DESC);
+CREATE INDEX idx_wallet_entries_transaction ON wallet_entries(transaction_id);
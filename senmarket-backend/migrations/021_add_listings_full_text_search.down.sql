@@ -0,0 +1,16 @@
+-- migrations/021_add_listings_full_text_search.down.sql
+
+DROP INDEX IF EXISTS idx_listings_search_vector;
+
+DROP TRIGGER IF EXISTS trg_categories_search_vector ON categories;
+DROP TRIGGER IF EXISTS trg_listings_search_vector ON listings;
+DROP FUNCTION IF EXISTS categories_search_vector_update();
+DROP FUNCTION IF EXISTS listings_search_vector_update();
+DROP FUNCTION IF EXISTS listing_search_vector(TEXT, TEXT, UUID);
+
+ALTER TABLE listings DROP COLUMN IF EXISTS search_vector;
+
+DROP TEXT SEARCH CONFIGURATION IF EXISTS french_unaccent;
+
+CREATE INDEX IF NOT EXISTS idx_listings_search_title ON listings USING gin(to_tsvector('french', title)) WHERE status = 'published';
+CREATE INDEX IF NOT EXISTS idx_listings_search_desc ON listings USING gin(to_tsvector('french', description)) WHERE status = 'published';
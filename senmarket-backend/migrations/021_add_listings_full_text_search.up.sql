@@ -0,0 +1,63 @@
+-- migrations/021_add_listings_full_text_search.up.sql
+-- Recherche plein texte pondérée (titre > description > catégorie), insensible aux accents
+
+CREATE EXTENSION IF NOT EXISTS unaccent;
+
+-- Configuration française qui retire les accents avant la racinisation
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_ts_config WHERE cfgname = 'french_unaccent') THEN
+        CREATE TEXT SEARCH CONFIGURATION french_unaccent (COPY = french);
+        ALTER TEXT SEARCH CONFIGURATION french_unaccent
+            ALTER MAPPING FOR hword, hword_part, word WITH unaccent, french_stem;
+    END IF;
+END
+$$;
+
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS search_vector tsvector;
+
+COMMENT ON COLUMN listings.search_vector IS 'Vecteur de recherche (A: titre, B: description, C: catégorie)';
+
+CREATE OR REPLACE FUNCTION listing_search_vector(p_title TEXT, p_description TEXT, p_category_id UUID)
+RETURNS tsvector AS $$
+    SELECT setweight(to_tsvector('french_unaccent', COALESCE(p_title, '')), 'A') ||
+           setweight(to_tsvector('french_unaccent', COALESCE(p_description, '')), 'B') ||
+           setweight(to_tsvector('french_unaccent', COALESCE((SELECT name FROM categories WHERE id = p_category_id), '')), 'C');
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION listings_search_vector_update()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.search_vector := listing_search_vector(NEW.title, NEW.description, NEW.category_id);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_listings_search_vector
+    BEFORE INSERT OR UPDATE OF title, description, category_id ON listings
+    FOR EACH ROW EXECUTE FUNCTION listings_search_vector_update();
+
+-- Renommer une catégorie met à jour les annonces associées
+CREATE OR REPLACE FUNCTION categories_search_vector_update()
+RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE listings
+    SET search_vector = listing_search_vector(title, description, category_id)
+    WHERE category_id = NEW.id;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_categories_search_vector
+    AFTER UPDATE OF name ON categories
+    FOR EACH ROW WHEN (OLD.name IS DISTINCT FROM NEW.name)
+    EXECUTE FUNCTION categories_search_vector_update();
+
+-- Remplissage des annonces existantes
+UPDATE listings SET search_vector = listing_search_vector(title, description, category_id);
+
+-- Les index de la migration 008 ne sont jamais utilisés (statut 'published' inexistant)
+DROP INDEX IF EXISTS idx_listings_search_title;
+DROP INDEX IF EXISTS idx_listings_search_desc;
+
+CREATE INDEX idx_listings_search_vector ON listings USING gin(search_vector);
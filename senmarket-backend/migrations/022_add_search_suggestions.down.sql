@@ -0,0 +1,5 @@
+-- migrations/022_add_search_suggestions.down.sql
+
+DROP INDEX IF EXISTS idx_categories_name_trgm;
+DROP INDEX IF EXISTS idx_listings_title_trgm;
+DROP FUNCTION IF EXISTS search_normalize(TEXT);
@@ -0,0 +1,14 @@
+-- migrations/022_add_search_suggestions.up.sql
+-- Autocomplétion : préfixes et similarité trigramme (tolérance aux fautes de frappe)
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE EXTENSION IF NOT EXISTS unaccent;
+
+-- unaccent() n'est pas IMMUTABLE : enveloppe utilisable dans les index
+CREATE OR REPLACE FUNCTION search_normalize(TEXT)
+RETURNS TEXT AS $$
+    SELECT lower(public.unaccent('public.unaccent'::regdictionary, $1));
+$$ LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT;
+
+CREATE INDEX idx_listings_title_trgm ON listings USING gin (search_normalize(title) gin_trgm_ops) WHERE status = 'active';
+CREATE INDEX idx_categories_name_trgm ON categories USING gin (search_normalize(name) gin_trgm_ops);
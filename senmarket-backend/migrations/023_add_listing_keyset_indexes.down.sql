@@ -0,0 +1,7 @@
+-- migrations/023_add_listing_keyset_indexes.down.sql
+
+DROP INDEX IF EXISTS idx_listings_user_created_id;
+DROP INDEX IF EXISTS idx_listings_category_created_id;
+DROP INDEX IF EXISTS idx_listings_active_views_id;
+DROP INDEX IF EXISTS idx_listings_active_price_id;
+DROP INDEX IF EXISTS idx_listings_active_created_id;
@@ -0,0 +1,8 @@
+-- migrations/023_add_listing_keyset_indexes.up.sql
+-- Index de pagination par curseur : colonne de tri + id (départage stable)
+
+CREATE INDEX IF NOT EXISTS idx_listings_active_created_id ON listings(created_at DESC, id DESC) WHERE status = 'active';
+CREATE INDEX IF NOT EXISTS idx_listings_active_price_id ON listings(price, id) WHERE status = 'active';
+CREATE INDEX IF NOT EXISTS idx_listings_active_views_id ON listings(views_count DESC, id DESC) WHERE status = 'active';
+CREATE INDEX IF NOT EXISTS idx_listings_category_created_id ON listings(category_id, created_at DESC, id DESC) WHERE status = 'active';
+CREATE INDEX IF NOT EXISTS idx_listings_user_created_id ON listings(user_id, created_at DESC, id DESC);
@@ -0,0 +1,4 @@
+-- migrations/024_create_saved_searches.down.sql
+
+DROP TABLE IF EXISTS saved_search_matches;
+DROP TABLE IF EXISTS saved_searches;
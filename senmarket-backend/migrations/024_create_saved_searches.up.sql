@@ -0,0 +1,39 @@
+-- migrations/024_create_saved_searches.up.sql
+-- Recherches sauvegardées et alertes SMS/WhatsApp sur les nouvelles annonces
+
+CREATE TABLE saved_searches (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    category_id UUID REFERENCES categories(id) ON DELETE CASCADE,
+    region VARCHAR(100) DEFAULT '' NOT NULL,
+    min_price DECIMAL(12,2),
+    max_price DECIMAL(12,2),
+    keywords VARCHAR(200) DEFAULT '' NOT NULL,
+    channel VARCHAR(20) DEFAULT 'sms' NOT NULL CHECK (channel IN ('sms', 'whatsapp')),
+    frequency VARCHAR(20) DEFAULT 'instant' NOT NULL CHECK (frequency IN ('instant', 'daily')),
+    is_active BOOLEAN DEFAULT TRUE NOT NULL,
+    unsubscribe_token VARCHAR(64) UNIQUE NOT NULL,
+    last_checked_at TIMESTAMP DEFAULT NOW() NOT NULL,
+    last_notified_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+COMMENT ON TABLE saved_searches IS 'Filtres enregistrés par les acheteurs avec alerte sur les nouvelles annonces';
+COMMENT ON COLUMN saved_searches.last_checked_at IS 'Annonces publiées ou modifiées après cette date restent à examiner';
+
+CREATE INDEX idx_saved_searches_user_id ON saved_searches(user_id);
+CREATE INDEX idx_saved_searches_active ON saved_searches(last_checked_at) WHERE is_active = TRUE;
+
+-- Annonces déjà signalées (évite les doublons, alimente le résumé quotidien)
+CREATE TABLE saved_search_matches (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    saved_search_id UUID NOT NULL REFERENCES saved_searches(id) ON DELETE CASCADE,
+    listing_id UUID NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+    notified_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE(saved_search_id, listing_id)
+);
+
+CREATE INDEX idx_saved_search_matches_pending ON saved_search_matches(saved_search_id) WHERE notified_at IS NULL;
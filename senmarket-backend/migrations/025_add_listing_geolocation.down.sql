@@ -0,0 +1,9 @@
+-- migrations/025_add_listing_geolocation.down.sql
+
+DROP INDEX IF EXISTS idx_listings_commune;
+DROP INDEX IF EXISTS idx_listings_location;
+
+ALTER TABLE listings DROP CONSTRAINT IF EXISTS listings_coordinates_check;
+ALTER TABLE listings DROP COLUMN IF EXISTS commune;
+ALTER TABLE listings DROP COLUMN IF EXISTS longitude;
+ALTER TABLE listings DROP COLUMN IF EXISTS latitude;
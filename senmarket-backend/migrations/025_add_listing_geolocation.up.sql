@@ -0,0 +1,20 @@
+-- migrations/025_add_listing_geolocation.up.sql
+-- Géolocalisation optionnelle des annonces et recherche par distance (sans PostGIS)
+
+CREATE EXTENSION IF NOT EXISTS cube;
+CREATE EXTENSION IF NOT EXISTS earthdistance;
+
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS latitude DOUBLE PRECISION;
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS longitude DOUBLE PRECISION;
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS commune VARCHAR(100) DEFAULT '' NOT NULL;
+
+ALTER TABLE listings ADD CONSTRAINT listings_coordinates_check CHECK (
+    (latitude IS NULL AND longitude IS NULL) OR
+    (latitude BETWEEN -90 AND 90 AND longitude BETWEEN -180 AND 180)
+);
+
+COMMENT ON COLUMN listings.commune IS 'Commune ou quartier (Plateau, Parcelles Assainies, Almadies...)';
+
+CREATE INDEX idx_listings_location ON listings USING gist (ll_to_earth(latitude, longitude))
+    WHERE latitude IS NOT NULL AND status = 'active';
+CREATE INDEX idx_listings_commune ON listings(LOWER(commune)) WHERE status = 'active';
@@ -0,0 +1,9 @@
+-- migrations/026_add_category_attributes.down.sql
+
+DROP INDEX IF EXISTS idx_listings_attributes;
+
+ALTER TABLE listings DROP COLUMN IF EXISTS attribute_schema_version;
+ALTER TABLE listings DROP COLUMN IF EXISTS attributes;
+ALTER TABLE categories DROP COLUMN IF EXISTS attribute_schema_version;
+
+DROP TABLE IF EXISTS category_attribute_schemas;
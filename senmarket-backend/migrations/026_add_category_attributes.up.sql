@@ -0,0 +1,55 @@
+-- migrations/026_add_category_attributes.up.sql
+-- Attributs structurés par catégorie (schémas versionnés) et valeurs JSONB sur les annonces
+
+CREATE TABLE category_attribute_schemas (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    category_id UUID NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL CHECK (version > 0),
+    fields JSONB NOT NULL DEFAULT '[]',
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE (category_id, version)
+);
+
+-- Version courante du schéma (0 = pas d'attributs)
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS attribute_schema_version INTEGER NOT NULL DEFAULT 0;
+
+-- Valeurs validées et version du schéma utilisée pour la validation
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS attributes JSONB NOT NULL DEFAULT '{}';
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS attribute_schema_version INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX idx_listings_attributes ON listings USING gin (attributes jsonb_path_ops) WHERE status = 'active';
+
+-- Schémas initiaux (version 1)
+INSERT INTO category_attribute_schemas (category_id, version, fields)
+SELECT id, 1, '[
+    {"key": "brand", "label": "Marque", "type": "string", "required": true, "filterable": true},
+    {"key": "model", "label": "Modèle", "type": "string", "filterable": true},
+    {"key": "year", "label": "Année", "type": "integer", "required": true, "min": 1950, "max": 2100, "filterable": true},
+    {"key": "mileage", "label": "Kilométrage", "type": "integer", "min": 0, "unit": "km", "filterable": true},
+    {"key": "fuel", "label": "Carburant", "type": "enum", "options": ["essence", "diesel", "hybride", "electrique"], "filterable": true},
+    {"key": "gearbox", "label": "Boîte de vitesses", "type": "enum", "options": ["manuelle", "automatique"], "filterable": true}
+]'::jsonb
+FROM categories WHERE slug = 'vehicles';
+
+INSERT INTO category_attribute_schemas (category_id, version, fields)
+SELECT id, 1, '[
+    {"key": "transaction", "label": "Type de transaction", "type": "enum", "options": ["location", "vente"], "required": true, "filterable": true},
+    {"key": "property_type", "label": "Type de bien", "type": "enum", "options": ["appartement", "villa", "studio", "chambre", "terrain", "bureau"], "filterable": true},
+    {"key": "rooms", "label": "Pièces", "type": "integer", "min": 0, "max": 50, "filterable": true},
+    {"key": "surface", "label": "Surface", "type": "number", "min": 0, "unit": "m²", "filterable": true},
+    {"key": "furnished", "label": "Meublé", "type": "boolean", "filterable": true}
+]'::jsonb
+FROM categories WHERE slug = 'real-estate';
+
+INSERT INTO category_attribute_schemas (category_id, version, fields)
+SELECT id, 1, '[
+    {"key": "brand", "label": "Marque", "type": "string", "filterable": true},
+    {"key": "model", "label": "Modèle", "type": "string"},
+    {"key": "condition", "label": "État", "type": "enum", "options": ["neuf", "comme_neuf", "occasion", "pour_pieces"], "required": true, "filterable": true},
+    {"key": "storage_gb", "label": "Stockage", "type": "integer", "min": 0, "unit": "Go", "filterable": true}
+]'::jsonb
+FROM categories WHERE slug = 'electronics';
+
+UPDATE categories SET attribute_schema_version = 1
+WHERE id IN (SELECT category_id FROM category_attribute_schemas);
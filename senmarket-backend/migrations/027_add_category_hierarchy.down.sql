@@ -0,0 +1,9 @@
+-- migrations/027_add_category_hierarchy.down.sql
+
+DROP TRIGGER IF EXISTS trg_categories_prevent_cycle ON categories;
+DROP FUNCTION IF EXISTS categories_prevent_cycle();
+
+-- Les sous-catégories créées par la migration sont conservées à plat
+DROP INDEX IF EXISTS idx_categories_parent;
+ALTER TABLE categories DROP CONSTRAINT IF EXISTS categories_parent_not_self;
+ALTER TABLE categories DROP COLUMN IF EXISTS parent_id;
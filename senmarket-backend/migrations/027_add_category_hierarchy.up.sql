@@ -0,0 +1,62 @@
+-- migrations/027_add_category_hierarchy.up.sql
+-- Sous-catégories : arborescence parent/enfant (slugs toujours uniques globalement)
+
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS parent_id UUID REFERENCES categories(id) ON DELETE SET NULL;
+ALTER TABLE categories ADD CONSTRAINT categories_parent_not_self CHECK (parent_id IS NULL OR parent_id <> id);
+
+CREATE INDEX idx_categories_parent ON categories(parent_id);
+
+-- Empêcher les cycles (A > B > A)
+CREATE OR REPLACE FUNCTION categories_prevent_cycle() RETURNS trigger AS $$
+BEGIN
+    IF NEW.parent_id IS NULL THEN
+        RETURN NEW;
+    END IF;
+
+    IF EXISTS (
+        WITH RECURSIVE ancestors AS (
+            SELECT id, parent_id FROM categories WHERE id = NEW.parent_id
+            UNION
+            SELECT c.id, c.parent_id FROM categories c JOIN ancestors a ON c.id = a.parent_id
+        )
+        SELECT 1 FROM ancestors WHERE id = NEW.id
+    ) THEN
+        RAISE EXCEPTION 'cycle dans l''arborescence des catégories (%)', NEW.slug;
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_categories_prevent_cycle
+    BEFORE INSERT OR UPDATE OF parent_id ON categories
+    FOR EACH ROW EXECUTE FUNCTION categories_prevent_cycle();
+
+-- Sous-catégories initiales
+INSERT INTO categories (slug, name, icon, description, sort_order, parent_id)
+SELECT v.slug, v.name, v.icon, v.description, v.sort_order, p.id
+FROM (VALUES
+    ('vehicles', 'cars', 'Voitures', 'fa-car', 'Voitures neuves et d''occasion', 1),
+    ('vehicles', 'motorcycles', 'Motos', 'fa-motorcycle', 'Motos, scooters, Jakarta', 2),
+    ('vehicles', 'vehicle-parts', 'Pièces détachées', 'fa-cogs', 'Pièces et accessoires auto-moto', 3),
+    ('real-estate', 'apartments', 'Appartements', 'fa-building', 'Appartements et studios', 1),
+    ('real-estate', 'houses', 'Maisons & Villas', 'fa-home', 'Maisons et villas', 2),
+    ('real-estate', 'land', 'Terrains', 'fa-map', 'Terrains et parcelles', 3),
+    ('electronics', 'phones', 'Téléphones', 'fa-mobile-alt', 'Smartphones et tablettes', 1),
+    ('electronics', 'computers', 'Ordinateurs', 'fa-laptop', 'Ordinateurs portables et de bureau', 2),
+    ('electronics', 'tv-audio', 'TV & Audio', 'fa-tv', 'Télévisions, enceintes, casques', 3)
+) AS v(parent_slug, slug, name, icon, description, sort_order)
+JOIN categories p ON p.slug = v.parent_slug
+ON CONFLICT (slug) DO NOTHING;
+
+-- Les sous-catégories reprennent le schéma d'attributs de leur parent
+INSERT INTO category_attribute_schemas (category_id, version, fields)
+SELECT c.id, 1, s.fields
+FROM categories c
+JOIN categories p ON p.id = c.parent_id
+JOIN category_attribute_schemas s ON s.category_id = p.id AND s.version = p.attribute_schema_version
+WHERE c.attribute_schema_version = 0;
+
+UPDATE categories SET attribute_schema_version = 1
+WHERE attribute_schema_version = 0
+  AND id IN (SELECT category_id FROM category_attribute_schemas);
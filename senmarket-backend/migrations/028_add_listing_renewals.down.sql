@@ -0,0 +1,8 @@
+-- migrations/028_add_listing_renewals.down.sql
+
+DROP TABLE IF EXISTS listing_renewals;
+
+DROP INDEX IF EXISTS idx_listings_active_expires_at;
+
+ALTER TABLE listings DROP COLUMN IF EXISTS renewal_count;
+ALTER TABLE listings DROP COLUMN IF EXISTS expiry_warned_at;
@@ -0,0 +1,29 @@
+-- migrations/028_add_listing_renewals.up.sql
+-- Expiration planifiée des annonces, avertissement des vendeurs et renouvellement
+
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS expiry_warned_at TIMESTAMP;
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS renewal_count INTEGER DEFAULT 0 NOT NULL;
+
+COMMENT ON COLUMN listings.expiry_warned_at IS 'Envoi du SMS d''avertissement avant expiration (remis à zéro au renouvellement)';
+
+-- Annonces anciennes sans date d'expiration
+UPDATE listings SET expires_at = created_at + INTERVAL '30 days' WHERE expires_at IS NULL;
+
+CREATE INDEX idx_listings_active_expires_at ON listings(expires_at) WHERE status = 'active' AND deleted_at IS NULL;
+
+-- Historique des renouvellements (gratuits ou payés)
+CREATE TABLE listing_renewals (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    listing_id UUID NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    payment_id UUID UNIQUE REFERENCES payments(id) ON DELETE SET NULL,
+    phase VARCHAR(20) NOT NULL,
+    price DECIMAL(10,2) DEFAULT 0 NOT NULL,
+    previous_expires_at TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+COMMENT ON TABLE listing_renewals IS 'Renouvellements d''annonces (prolongation de expires_at)';
+
+CREATE INDEX idx_listing_renewals_listing_id ON listing_renewals(listing_id, created_at DESC);
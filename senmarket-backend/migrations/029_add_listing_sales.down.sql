@@ -0,0 +1,5 @@
+-- migrations/029_add_listing_sales.down.sql
+
+DROP TABLE IF EXISTS listing_sales;
+
+ALTER TABLE listings DROP COLUMN IF EXISTS sold_at;
@@ -0,0 +1,30 @@
+-- migrations/029_add_listing_sales.up.sql
+-- Déclaration de vente des annonces et suivi des résultats (plateforme, prix final, acheteur)
+
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS sold_at TIMESTAMP;
+
+-- Une vente par annonce ; catégorie et région copiées pour les statistiques
+CREATE TABLE listing_sales (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    listing_id UUID NOT NULL UNIQUE REFERENCES listings(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    category_id UUID NOT NULL REFERENCES categories(id),
+    region VARCHAR(100) NOT NULL,
+    sold_on_platform BOOLEAN NOT NULL,
+    final_price DECIMAL(12,2),
+    listed_price DECIMAL(12,2) NOT NULL,
+    currency VARCHAR(3) DEFAULT 'XOF' NOT NULL,
+    buyer_contact_id UUID REFERENCES contacts(id) ON DELETE SET NULL,
+    buyer_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    listed_at TIMESTAMP NOT NULL,
+    sold_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMP DEFAULT NOW(),
+    CONSTRAINT chk_listing_sales_final_price CHECK (final_price IS NULL OR final_price >= 0)
+);
+
+COMMENT ON TABLE listing_sales IS 'Résultats des ventes déclarées par les vendeurs (alimente les statistiques catégorie/région)';
+COMMENT ON COLUMN listing_sales.sold_on_platform IS 'Vendu grâce à SenMarket (false : vendu ailleurs)';
+
+CREATE INDEX idx_listing_sales_category_id ON listing_sales(category_id, sold_at DESC);
+CREATE INDEX idx_listing_sales_region ON listing_sales(region, sold_at DESC);
+CREATE INDEX idx_listing_sales_user_id ON listing_sales(user_id);
@@ -0,0 +1,6 @@
+-- migrations/030_add_user_roles.down.sql
+
+DROP INDEX IF EXISTS idx_users_role;
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS chk_users_role;
+ALTER TABLE users DROP COLUMN IF EXISTS role;
@@ -0,0 +1,10 @@
+-- migrations/030_add_user_roles.up.sql
+-- Rôles d'accès (user, moderator, admin) pour les endpoints d'administration
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(20) DEFAULT 'user' NOT NULL;
+
+ALTER TABLE users ADD CONSTRAINT chk_users_role CHECK (role IN ('user', 'moderator', 'admin'));
+
+COMMENT ON COLUMN users.role IS 'Rôle d''accès : user, moderator, admin (les admins initiaux sont promus via ADMIN_PHONES)';
+
+CREATE INDEX idx_users_role ON users(role) WHERE role <> 'user';
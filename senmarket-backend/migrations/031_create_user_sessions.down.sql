@@ -0,0 +1,4 @@
+-- migrations/031_create_user_sessions.down.sql
+
+DROP TABLE IF EXISTS refresh_tokens;
+DROP TABLE IF EXISTS user_sessions;
@@ -0,0 +1,35 @@
+-- migrations/031_create_user_sessions.up.sql
+-- Sessions utilisateur et refresh tokens opaques (rotation, détection de réutilisation, révocation)
+
+CREATE TABLE user_sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    device_name VARCHAR(255),
+    user_agent VARCHAR(512),
+    ip_address VARCHAR(64),
+    last_seen_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP,
+    revoked_reason VARCHAR(50),
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+COMMENT ON TABLE user_sessions IS 'Une session par connexion (appareil) ; révoquée à la déconnexion ou en cas de réutilisation d''un refresh token';
+
+CREATE INDEX idx_user_sessions_user_active ON user_sessions(user_id, last_seen_at DESC) WHERE revoked_at IS NULL;
+
+-- Seule l'empreinte SHA-256 du refresh token est conservée
+CREATE TABLE refresh_tokens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    session_id UUID NOT NULL REFERENCES user_sessions(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    rotated_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+COMMENT ON COLUMN refresh_tokens.rotated_at IS 'Token déjà échangé : toute nouvelle présentation révoque la session';
+
+CREATE INDEX idx_refresh_tokens_session_id ON refresh_tokens(session_id);
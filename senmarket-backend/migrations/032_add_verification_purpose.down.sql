@@ -0,0 +1,6 @@
+-- migrations/032_add_verification_purpose.down.sql
+
+DROP INDEX IF EXISTS idx_sms_phone_purpose;
+
+ALTER TABLE sms_verifications DROP CONSTRAINT IF EXISTS chk_sms_verifications_purpose;
+ALTER TABLE sms_verifications DROP COLUMN IF EXISTS purpose;
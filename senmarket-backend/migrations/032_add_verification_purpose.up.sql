@@ -0,0 +1,11 @@
+-- migrations/032_add_verification_purpose.up.sql
+-- Usage des codes SMS : vérification du téléphone ou réinitialisation du mot de passe
+
+ALTER TABLE sms_verifications ADD COLUMN IF NOT EXISTS purpose VARCHAR(30) DEFAULT 'phone_verification' NOT NULL;
+
+ALTER TABLE sms_verifications ADD CONSTRAINT chk_sms_verifications_purpose
+    CHECK (purpose IN ('phone_verification', 'password_reset'));
+
+COMMENT ON COLUMN sms_verifications.purpose IS 'Un code n''est accepté que pour l''usage pour lequel il a été émis';
+
+CREATE INDEX idx_sms_phone_purpose ON sms_verifications(phone, purpose, created_at DESC) WHERE verified = FALSE;
@@ -0,0 +1,8 @@
+-- migrations/033_add_login_otp.down.sql
+
+ALTER TABLE sms_verifications DROP COLUMN IF EXISTS channel;
+
+DELETE FROM sms_verifications WHERE purpose = 'login';
+ALTER TABLE sms_verifications DROP CONSTRAINT IF EXISTS chk_sms_verifications_purpose;
+ALTER TABLE sms_verifications ADD CONSTRAINT chk_sms_verifications_purpose
+    CHECK (purpose IN ('phone_verification', 'password_reset'));
@@ -0,0 +1,11 @@
+-- migrations/033_add_login_otp.up.sql
+-- Connexion sans mot de passe : codes de connexion et canal d'envoi (WhatsApp, SMS)
+
+ALTER TABLE sms_verifications DROP CONSTRAINT IF EXISTS chk_sms_verifications_purpose;
+ALTER TABLE sms_verifications ADD CONSTRAINT chk_sms_verifications_purpose
+    CHECK (purpose IN ('phone_verification', 'password_reset', 'login'));
+
+ALTER TABLE sms_verifications ADD COLUMN IF NOT EXISTS channel VARCHAR(20) DEFAULT 'sms' NOT NULL;
+
+COMMENT ON COLUMN sms_verifications.channel IS 'Canal ayant délivré le code : whatsapp ou sms';
+
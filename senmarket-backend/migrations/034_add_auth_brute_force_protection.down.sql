@@ -0,0 +1,5 @@
+-- migrations/034_add_auth_brute_force_protection.down.sql
+
+DROP TABLE IF EXISTS auth_security_events;
+
+ALTER TABLE sms_verifications DROP COLUMN IF EXISTS attempts;
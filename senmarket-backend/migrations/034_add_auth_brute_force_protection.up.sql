@@ -0,0 +1,27 @@
+-- migrations/034_add_auth_brute_force_protection.up.sql
+-- Protection contre la force brute : tentatives par code et journal des verrouillages
+
+ALTER TABLE sms_verifications ADD COLUMN IF NOT EXISTS attempts INT DEFAULT 0 NOT NULL;
+
+COMMENT ON COLUMN sms_verifications.attempts IS 'Saisies erronées ; le code est invalidé au-delà de la limite';
+
+CREATE TABLE auth_security_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    event_type VARCHAR(50) NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    scope VARCHAR(20),
+    phone VARCHAR(20),
+    ip_address VARCHAR(45),
+    failures INT DEFAULT 0 NOT NULL,
+    locked_until TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+COMMENT ON TABLE auth_security_events IS 'Verrouillages et codes invalidés après trop de tentatives, conservés pour audit';
+COMMENT ON COLUMN auth_security_events.event_type IS 'lockout, code_burned';
+COMMENT ON COLUMN auth_security_events.action IS 'login, code';
+COMMENT ON COLUMN auth_security_events.scope IS 'phone, ip';
+
+CREATE INDEX idx_auth_security_events_phone ON auth_security_events(phone);
+CREATE INDEX idx_auth_security_events_ip ON auth_security_events(ip_address);
+CREATE INDEX idx_auth_security_events_created_at ON auth_security_events(created_at DESC);